@@ -22,7 +22,7 @@ It offers two main commands: scan and update.
 # Scan
 
 The scan command parses the given bicep file or directory, fetches the latest API versions for each Azure resource referenced in the file(s),
-and prints the results to stdout.
+and prints the results to stdout. ARM JSON templates and Terraform files using the azapi provider are scanned as well.
 
 Example usage:
 
@@ -65,6 +65,17 @@ Use silent mode:
 
 For full usage details, run `bruh update --help` or `bruh help update`.
 
+# Diff versions
+
+The diff-versions command prints the property changes of a resource type between two API versions,
+using a local clone of bicep-types-az (https://github.com/Azure/bicep-types-az).
+
+Example usage:
+
+	bruh diff-versions Microsoft.Web/sites 2021-02-01 latest --types-dir ./bicep-types-az/generated
+
+For full usage details, run `bruh diff-versions --help` or `bruh help diff-versions`.
+
 Note: all the API versions are fetched from the official Microsoft Learn website (https://learn.microsoft.com/en-us/azure/templates/).
 */
 package main
@@ -0,0 +1,194 @@
+package action
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/christosgalano/bruh/internal/types"
+)
+
+// getenv returns a function looking up the given variables.
+func getenv(variables map[string]string) func(string) string {
+	return func(key string) string {
+		return variables[key]
+	}
+}
+
+func TestParseInputs(t *testing.T) {
+	tests := []struct {
+		name      string
+		variables map[string]string
+		want      *Inputs
+		wantErrs  []string
+	}{
+		{
+			name:      "defaults",
+			variables: map[string]string{"INPUT_COMMAND": "scan"},
+			want:      &Inputs{Command: "scan", Path: ".", Output: "normal", InPlace: true},
+		},
+		{
+			name: "all-inputs",
+			variables: map[string]string{
+				"INPUT_COMMAND": "update", "INPUT_PATH": " ./bicep ", "INPUT_INCLUDE-PREVIEW": "True", "INPUT_SUMMARY": "TRUE",
+				"INPUT_OUTDATED": "false", "INPUT_OUTPUT": "markdown", "INPUT_IN-PLACE": "false", "INPUT_SILENT": "true",
+			},
+			want: &Inputs{Command: "update", Path: "./bicep", IncludePreview: true, Summary: true, Output: "markdown", Silent: true},
+		},
+		{
+			name:      "underscores",
+			variables: map[string]string{"INPUT_COMMAND": "scan", "INPUT_INCLUDE_PREVIEW": "true", "INPUT_IN_PLACE": "false"},
+			want:      &Inputs{Command: "scan", Path: ".", IncludePreview: true, Output: "normal"},
+		},
+		{
+			name:      "missing-command",
+			variables: map[string]string{},
+			wantErrs:  []string{"missing input command"},
+		},
+		{
+			name:      "invalid-inputs",
+			variables: map[string]string{"INPUT_COMMAND": "upgrade", "INPUT_OUTPUT": "json", "INPUT_SUMMARY": "yes", "INPUT_SILENT": "1"},
+			wantErrs: []string{
+				`invalid value "upgrade" for input command`,
+				`invalid value "json" for input output`,
+				`invalid value "yes" for input summary`,
+				`invalid value "1" for input silent`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseInputs(getenv(tt.variables))
+			if len(tt.wantErrs) > 0 {
+				if err == nil {
+					t.Fatalf("ParseInputs() error = nil, want %v", tt.wantErrs)
+				}
+				for _, want := range tt.wantErrs {
+					if !strings.Contains(err.Error(), want) {
+						t.Errorf("ParseInputs() error = %v, want it to contain %q", err, want)
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseInputs() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseInputs() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnvironment(t *testing.T) {
+	dir := t.TempDir()
+	env := NewEnvironment(getenv(map[string]string{
+		"GITHUB_OUTPUT":       filepath.Join(dir, "output"),
+		"GITHUB_STEP_SUMMARY": filepath.Join(dir, "summary"),
+		"GITHUB_WORKSPACE":    dir,
+	}))
+
+	if err := env.SetOutput("result", "line 1\nline 2"); err != nil {
+		t.Fatal(err)
+	}
+	if err := env.SetOutput("outdated", "3"); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(env.Output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	re := regexp.MustCompile(`^result<<(ghadelimiter_[0-9a-f]{32})\nline 1\nline 2\n(ghadelimiter_[0-9a-f]{32})\noutdated<<(ghadelimiter_[0-9a-f]{32})\n3\n(ghadelimiter_[0-9a-f]{32})\n$`)
+	match := re.FindStringSubmatch(string(data))
+	if match == nil || match[1] != match[2] || match[3] != match[4] || match[1] == match[3] {
+		t.Errorf("SetOutput() wrote %q, want each output between the same random delimiters", data)
+	}
+
+	for _, markdown := range []string{"## Scan results\n", "| a | b |\n"} {
+		if err := env.AppendSummary(markdown); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if data, err := os.ReadFile(env.StepSummary); err != nil || string(data) != "## Scan results\n| a | b |\n" {
+		t.Errorf("AppendSummary() wrote %q, %v", data, err)
+	}
+
+	if got, want := env.Relative(filepath.Join(dir, "bicep", "main.bicep")), "bicep/main.bicep"; got != want {
+		t.Errorf("Relative() = %q, want %q", got, want)
+	}
+
+	// Outside of GitHub Actions, the files are not written
+	if err := (Environment{}).SetOutput("result", "ignored"); err != nil {
+		t.Errorf("SetOutput() error = %v, want nil without GITHUB_OUTPUT", err)
+	}
+}
+
+// files returns scanned files with resources of every status.
+func files() []types.BicepFile {
+	return []types.BicepFile{{
+		Path: "main.bicep",
+		Resources: []types.Resource{
+			{ID: "Microsoft.Web/sites", Line: 1, CurrentAPIVersion: "2021-02-01", AvailableAPIVersions: []string{"2023-01-01", "2022-09-01", "2021-02-01"}},
+			{ID: "Microsoft.Web/serverfarms", Line: 5, CurrentAPIVersion: "2022-09-01", AvailableAPIVersions: []string{"2022-09-01"}},
+			{ID: "Microsoft.KeyVault/vaults", Line: 9, CurrentAPIVersion: "2022-02-01-preview", AvailableAPIVersions: []string{"2022-07-01"}},
+			{ID: "Microsoft.Storage/storageAcounts", Line: 13, CurrentAPIVersion: "2021-01-01", Unknown: true, Suggestions: []string{"Microsoft.Storage/storageAccounts"}},
+			{ID: "Microsoft.Storage/storageAccounts", Line: 20, Function: "listKeys", CurrentAPIVersion: "2021-01-01", AvailableAPIVersions: []string{"2023-01-01"}, Skipped: true},
+		},
+	}}
+}
+
+func TestAnnotations(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations func(Environment, []types.BicepFile) []Annotation
+		want        []string
+	}{
+		{
+			name:        "scan",
+			annotations: ScanAnnotations,
+			want: []string{
+				"::warning file=main.bicep,line=1,title=Outdated API version::Microsoft.Web/sites is using 2021-02-01 while the latest version is 2023-01-01 (2 versions behind)",
+				"::warning file=main.bicep,line=9,title=Promotable preview API version::Microsoft.KeyVault/vaults is using preview version 2022-02-01-preview while GA version 2022-07-01 is available",
+				"::warning file=main.bicep,line=13,title=Unknown resource type::Microsoft.Storage/storageAcounts is an unknown resource type (did you mean Microsoft.Storage/storageAccounts?)",
+				"::warning file=main.bicep,line=20,title=Outdated API version::listKeys(Microsoft.Storage/storageAccounts) is using 2021-01-01 while the latest version is 2023-01-01 (1 versions behind)",
+			},
+		},
+		{
+			name:        "update",
+			annotations: UpdateAnnotations,
+			want: []string{
+				"::notice file=main.bicep,line=1,title=Updated API version::Microsoft.Web/sites was updated from 2021-02-01 to 2023-01-01",
+				"::notice file=main.bicep,line=9,title=Updated API version::Microsoft.KeyVault/vaults was updated from 2022-02-01-preview to 2022-07-01",
+				"::warning file=main.bicep,line=13,title=Unknown resource type::Microsoft.Storage/storageAcounts is an unknown resource type and was not updated",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []string{}
+			for _, annotation := range tt.annotations(Environment{}, files()) {
+				got = append(got, annotation.String())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("annotations = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnnotation_String(t *testing.T) {
+	annotation := Annotation{Level: LevelWarning, File: "dir,1/a:b.bicep", Line: 3, Title: "50% done", Message: "first\nsecond: 100%"}
+	want := "::warning file=dir%2C1/a%3Ab.bicep,line=3,title=50%25 done::first%0Asecond: 100%25"
+	if got := annotation.String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestCount(t *testing.T) {
+	if got, want := Count(files()), (Counts{Outdated: 3, Unknown: 1, Updated: 2}); got != want {
+		t.Errorf("Count() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,91 @@
+package action
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/christosgalano/bruh/internal/types"
+)
+
+// Level is the level of an annotation.
+type Level string
+
+const (
+	// LevelNotice is the level of informative annotations (e.g. updated API versions).
+	LevelNotice Level = "notice"
+
+	// LevelWarning is the level of annotations about outdated or unknown API versions.
+	LevelWarning Level = "warning"
+)
+
+// Annotation is a workflow command annotating the line of a file in the checks of a workflow run.
+type Annotation struct {
+	Level   Level
+	File    string
+	Line    int
+	Title   string
+	Message string
+}
+
+// dataEscaper escapes the message of a workflow command, and propertyEscaper the values of its properties.
+var (
+	dataEscaper     = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+	propertyEscaper = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+)
+
+// String returns the workflow command of the annotation (e.g. ::warning file=main.bicep,line=3,title=Outdated API version::...).
+func (a Annotation) String() string {
+	return fmt.Sprintf("::%s file=%s,line=%d,title=%s::%s", a.Level, propertyEscaper.Replace(a.File), a.Line, propertyEscaper.Replace(a.Title), dataEscaper.Replace(a.Message))
+}
+
+// ScanAnnotations returns the annotations of the outdated, promotable and unknown resources of the given files.
+func ScanAnnotations(env Environment, bicepFiles []types.BicepFile) []Annotation {
+	annotations := []Annotation{}
+	for _, bicepFile := range bicepFiles {
+		file := env.Relative(bicepFile.Path)
+		for _, resource := range bicepFile.Resources {
+			annotation := Annotation{Level: LevelWarning, File: file, Line: resource.Line}
+			switch resource.Status() {
+			case types.StatusOutdated:
+				annotation.Title = "Outdated API version"
+				annotation.Message = fmt.Sprintf("%s is using %s while the latest version is %s (%d versions behind)",
+					resource.Label(), resource.CurrentAPIVersion, resource.LatestAPIVersion(), resource.VersionsBehind())
+			case types.StatusPromotable:
+				annotation.Title = "Promotable preview API version"
+				annotation.Message = fmt.Sprintf("%s is using preview version %s while GA version %s is available",
+					resource.Label(), resource.CurrentAPIVersion, resource.GAAPIVersion())
+			case types.StatusUnknown:
+				annotation.Title = "Unknown resource type"
+				annotation.Message = fmt.Sprintf("%s is an unknown resource type", resource.Label())
+				if len(resource.Suggestions) > 0 {
+					annotation.Message += fmt.Sprintf(" (did you mean %s?)", strings.Join(resource.Suggestions, ", "))
+				}
+			default:
+				continue
+			}
+			annotations = append(annotations, annotation)
+		}
+	}
+	return annotations
+}
+
+// UpdateAnnotations returns the annotations of the resources of the given files that are about to be updated, along with
+// the unknown ones, which are left untouched. They must be computed before the update, which replaces the current versions.
+func UpdateAnnotations(env Environment, bicepFiles []types.BicepFile) []Annotation {
+	annotations := []Annotation{}
+	for _, bicepFile := range bicepFiles {
+		file := env.Relative(bicepFile.Path)
+		for _, resource := range bicepFile.Resources {
+			switch status := resource.Status(); {
+			case status == types.StatusUnknown:
+				annotations = append(annotations, Annotation{Level: LevelWarning, File: file, Line: resource.Line, Title: "Unknown resource type",
+					Message: fmt.Sprintf("%s is an unknown resource type and was not updated", resource.Label())})
+			case resource.Skipped || (status != types.StatusOutdated && status != types.StatusPromotable):
+			default:
+				annotations = append(annotations, Annotation{Level: LevelNotice, File: file, Line: resource.Line, Title: "Updated API version",
+					Message: fmt.Sprintf("%s was updated from %s to %s", resource.Label(), resource.CurrentAPIVersion, resource.LatestAPIVersion())})
+			}
+		}
+	}
+	return annotations
+}
@@ -0,0 +1,118 @@
+/*
+Package action provides the GitHub Action mode of bruh, run by the action's container instead of a shell entrypoint.
+
+The inputs of the action are read from the INPUT_* environment variables set by the runner, validated and typed by ParseInputs.
+The results are reported through the files of the workflow commands: the step outputs (GITHUB_OUTPUT), the job summary
+(GITHUB_STEP_SUMMARY), and annotations on the lines of the API versions, printed to standard output.
+*/
+package action
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	// CommandScan is the command scanning the files.
+	CommandScan = "scan"
+
+	// CommandUpdate is the command updating the files.
+	CommandUpdate = "update"
+)
+
+// Inputs are the inputs of the action:
+//   - Command: the command to run (scan or update)
+//   - Path: the path to the file or directory, relative to the workspace (the workspace itself by default)
+//   - IncludePreview: whether preview API versions are considered
+//   - Summary: whether the results are written to the job summary
+//   - Outdated and Output: whether only outdated resources are printed, and the output format (scan only)
+//   - InPlace and Silent: whether the files are updated in place, and whether the results are not printed (update only)
+type Inputs struct {
+	Command        string
+	Path           string
+	IncludePreview bool
+	Summary        bool
+	Outdated       bool
+	Output         string
+	InPlace        bool
+	Silent         bool
+}
+
+// input returns the value of an input, set by the runner as INPUT_<NAME> with the name in uppercase.
+// The name is looked up with underscores instead of hyphens as well, for runners that do not allow hyphens in variable names.
+func input(getenv func(string) string, name string) string {
+	key := "INPUT_" + strings.ToUpper(strings.ReplaceAll(name, " ", "_"))
+	value := getenv(key)
+	if value == "" {
+		value = getenv(strings.ReplaceAll(key, "-", "_"))
+	}
+	return strings.TrimSpace(value)
+}
+
+// boolInput returns the value of a boolean input, following the YAML 1.2 core schema (true, True, TRUE, false, False or FALSE),
+// or the default value if it is not set.
+func boolInput(getenv func(string) string, name string, defaultValue bool) (bool, error) {
+	switch value := input(getenv, name); value {
+	case "":
+		return defaultValue, nil
+	case "true", "True", "TRUE":
+		return true, nil
+	case "false", "False", "FALSE":
+		return false, nil
+	default:
+		return false, fmt.Errorf("invalid value %q for input %s: expected true or false", value, name)
+	}
+}
+
+// ParseInputs reads and validates the inputs of the action with the given function (e.g. os.Getenv), reporting every invalid input.
+// Unset inputs get the defaults of action.yaml.
+func ParseInputs(getenv func(string) string) (*Inputs, error) {
+	inputs := &Inputs{
+		Command: input(getenv, "command"),
+		Path:    input(getenv, "path"),
+		Output:  input(getenv, "output"),
+	}
+	errs := []error{}
+
+	switch inputs.Command {
+	case CommandScan, CommandUpdate:
+	case "":
+		errs = append(errs, errors.New("missing input command: expected scan or update"))
+	default:
+		errs = append(errs, fmt.Errorf("invalid value %q for input command: expected scan or update", inputs.Command))
+	}
+	if inputs.Path == "" {
+		inputs.Path = "."
+	}
+	switch inputs.Output {
+	case "":
+		inputs.Output = "normal"
+	case "normal", "table", "markdown":
+	default:
+		errs = append(errs, fmt.Errorf("invalid value %q for input output: expected normal, table or markdown", inputs.Output))
+	}
+
+	for _, b := range []struct {
+		name         string
+		value        *bool
+		defaultValue bool
+	}{
+		{name: "include-preview", value: &inputs.IncludePreview},
+		{name: "summary", value: &inputs.Summary},
+		{name: "outdated", value: &inputs.Outdated},
+		{name: "in-place", value: &inputs.InPlace, defaultValue: true},
+		{name: "silent", value: &inputs.Silent},
+	} {
+		value, err := boolInput(getenv, b.name, b.defaultValue)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		*b.value = value
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return inputs, nil
+}
@@ -0,0 +1,114 @@
+package action
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/christosgalano/bruh/internal/types"
+)
+
+// Environment is the environment of the step running the action:
+//   - Output: the file of the step outputs (GITHUB_OUTPUT)
+//   - StepSummary: the file of the job summary (GITHUB_STEP_SUMMARY)
+//   - Workspace: the directory of the repository (GITHUB_WORKSPACE), which the paths of the annotations are relative to
+//
+// Files that are not set are ignored, so that the action can run outside of GitHub Actions.
+type Environment struct {
+	Output      string
+	StepSummary string
+	Workspace   string
+}
+
+// NewEnvironment returns the environment of the step with the given function (e.g. os.Getenv).
+func NewEnvironment(getenv func(string) string) Environment {
+	return Environment{
+		Output:      getenv("GITHUB_OUTPUT"),
+		StepSummary: getenv("GITHUB_STEP_SUMMARY"),
+		Workspace:   getenv("GITHUB_WORKSPACE"),
+	}
+}
+
+// appendFile appends content to a file.
+func appendFile(path, content string) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	_, err = f.WriteString(content)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// SetOutput sets a step output, using a random delimiter so that the value can span multiple lines.
+func (e Environment) SetOutput(name, value string) error {
+	if e.Output == "" {
+		return nil
+	}
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return err
+	}
+	delimiter := "ghadelimiter_" + hex.EncodeToString(random)
+	if !strings.HasSuffix(value, "\n") {
+		value += "\n"
+	}
+	return appendFile(e.Output, fmt.Sprintf("%s<<%s\n%s%s\n", name, delimiter, value, delimiter))
+}
+
+// AppendSummary appends markdown to the job summary.
+func (e Environment) AppendSummary(markdown string) error {
+	if e.StepSummary == "" {
+		return nil
+	}
+	return appendFile(e.StepSummary, markdown)
+}
+
+// Relative returns the path of a file relative to the workspace, with forward slashes, as expected by annotations.
+// Paths outside the workspace are returned as they are.
+func (e Environment) Relative(path string) string {
+	if e.Workspace == "" {
+		return filepath.ToSlash(filepath.Clean(path))
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	rel, err := filepath.Rel(e.Workspace, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return filepath.ToSlash(path)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// Counts are the number of resources per outcome, set as step outputs.
+type Counts struct {
+	Outdated int
+	Unknown  int
+	Updated  int
+}
+
+// Count returns the number of outdated (or promotable) and unknown resources of the given files.
+// Outdated resources that will be updated (i.e. not skipped) are counted as updated as well.
+func Count(bicepFiles []types.BicepFile) Counts {
+	counts := Counts{}
+	for _, bicepFile := range bicepFiles {
+		for _, resource := range bicepFile.Resources {
+			switch resource.Status() {
+			case types.StatusOutdated, types.StatusPromotable:
+				counts.Outdated++
+				if !resource.Skipped {
+					counts.Updated++
+				}
+			case types.StatusUnknown:
+				counts.Unknown++
+			}
+		}
+	}
+	return counts
+}
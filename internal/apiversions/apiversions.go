@@ -5,6 +5,7 @@ The API versions are fetched from the official Microsoft Learn website (https://
 package apiversions
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -14,22 +15,74 @@ import (
 	"sync"
 	"time"
 
+	"github.com/christosgalano/bruh/internal/registry"
 	"github.com/christosgalano/bruh/internal/types"
 )
 
 const (
 	// dateFormat is the format used for parsing the dates in the API versions.
 	dateFormat = "2006-01-02"
+
+	// maxSuggestions is the maximum number of suggestions reported for an unknown resource type.
+	maxSuggestions = 3
+)
+
+var (
+	// baseURL is the URL of the Microsoft Learn website containing the Azure resource templates.
+	baseURL = "https://learn.microsoft.com/en-us/azure/templates/"
+
+	// ErrNotFound is returned when the requested page does not exist.
+	ErrNotFound = errors.New("page not found")
 )
 
-// fetchResourcePage fetches the HTML content of a given URL.
+// Client fetches the available API versions of Azure resources with its HTTP client:
+//   - HTTPClient: the client used for the Microsoft Learn website and registries, http.DefaultClient if nil
+//   - BaseURL: the URL of the Azure resource templates, the Microsoft Learn website if empty
+//
+// The zero value is ready to use.
+type Client struct {
+	HTTPClient *http.Client
+	BaseURL    string
+}
+
+// httpClient returns the HTTP client of the client, or http.DefaultClient if it is not set.
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient == nil {
+		return http.DefaultClient
+	}
+	return c.HTTPClient
+}
+
+// baseURL returns the base URL of the client, or the Microsoft Learn website if it is not set.
+func (c *Client) baseURL() string {
+	if c.BaseURL == "" {
+		return baseURL
+	}
+	return c.BaseURL
+}
+
+// fetchResourcePage fetches the HTML content of a given URL with http.DefaultClient.
+// If the page does not exist, the function returns ErrNotFound.
 func fetchResourcePage(url string) (string, error) {
-	resp, err := http.Get(url)
+	return (&Client{}).fetchPage(url)
+}
+
+// fetchPage fetches the HTML content of a given URL.
+// If the page does not exist, the method returns ErrNotFound.
+func (c *Client) fetchPage(url string) (string, error) {
+	resp, err := c.httpClient().Get(url)
 	if err != nil {
 		return "", err
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotFound {
+		return "", fmt.Errorf("%w: %s", ErrNotFound, url)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status %q: %s", resp.Status, url)
+	}
+
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return "", err
@@ -81,10 +134,124 @@ func extractAPIVersions(body string, pattern string) ([]string, error) {
 	return versions, nil
 }
 
+// extractResourceTypes extracts the names of all the resource types referenced in a namespace page.
+// Each name is returned once, using the casing of the link text when available.
+func extractResourceTypes(body string) []string {
+	re := regexp.MustCompile(`href="(?:\d{4}-\d{2}-\d{2}(?:-preview)?/)?([a-z0-9]+)"[^>]*>\s*([a-zA-Z0-9]*)`)
+	matches := re.FindAllStringSubmatch(body, -1)
+
+	seen := map[string]bool{}
+	names := []string{}
+	for _, match := range matches {
+		name := match[1]
+		if strings.EqualFold(name, match[2]) {
+			name = match[2]
+		}
+		if name == "allversions" || seen[strings.ToLower(name)] {
+			continue
+		}
+		seen[strings.ToLower(name)] = true
+		names = append(names, name)
+	}
+	return names
+}
+
+// levenshtein returns the case-insensitive edit distance between two strings.
+func levenshtein(a, b string) int {
+	s, t := []rune(strings.ToLower(a)), []rune(strings.ToLower(b))
+	previous := make([]int, len(t)+1)
+	current := make([]int, len(t)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(s); i++ {
+		current[0] = i
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			current[j] = minInt(minInt(previous[j]+1, current[j-1]+1), previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(t)]
+}
+
+// minInt returns the smallest of two integers.
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// suggestResourceTypes returns the known resource types closest to the given name, sorted by edit distance.
+// Only names whose distance is at most a third of the name length (or 2 for short names) are considered.
+func suggestResourceTypes(name string, known []string) []string {
+	threshold := len(name) / 3
+	if threshold < 2 {
+		threshold = 2
+	}
+
+	type candidate struct {
+		name     string
+		distance int
+	}
+	candidates := []candidate{}
+	for _, k := range known {
+		if d := levenshtein(name, k); d <= threshold {
+			candidates = append(candidates, candidate{name: k, distance: d})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].distance < candidates[j].distance
+	})
+
+	suggestions := []string{}
+	for i := 0; i < len(candidates) && i < maxSuggestions; i++ {
+		suggestions = append(suggestions, candidates[i].name)
+	}
+	return suggestions
+}
+
+// markUnknown marks a resource as unknown and fills its suggestions with the closest known resource types of its namespace.
+// If the namespace page cannot be fetched, the resource is still marked as unknown, without suggestions.
+func (c *Client) markUnknown(resource *types.Resource) {
+	resource.Unknown = true
+	resource.AvailableAPIVersions = nil
+	resource.Suggestions = nil
+
+	body, err := c.fetchPage(c.baseURL() + strings.ToLower(resource.Namespace) + "/allversions")
+	if err != nil {
+		return
+	}
+	for _, name := range suggestResourceTypes(resource.Name, extractResourceTypes(body)) {
+		resource.Suggestions = append(resource.Suggestions, resource.Namespace+"/"+name)
+	}
+}
+
+// UpdateResource updates the available API versions for a given resource, using http.DefaultClient.
+// See Client.UpdateResource for details.
+func UpdateResource(resource *types.Resource, includePreview bool) error {
+	return (&Client{}).UpdateResource(resource, includePreview)
+}
+
 // UpdateResource updates the available API versions for a given resource.
 // If includePreview is true, preview API versions will be included.
-func UpdateResource(resource *types.Resource, includePreview bool) error {
-	url := "https://learn.microsoft.com/en-us/azure/templates/" + strings.ToLower(resource.Namespace) + "/" + strings.ToLower(resource.Name)
+// If the resource type does not exist, the resource is marked as unknown instead of returning an error.
+// Resources with an unresolved API version are left untouched, while the tags of registry modules are fetched from their registry.
+func (c *Client) UpdateResource(resource *types.Resource, includePreview bool) error {
+	if resource.Module {
+		return (&registry.Client{HTTPClient: c.HTTPClient}).UpdateResource(resource, includePreview)
+	}
+
+	// API versions that are not statically resolvable cannot be compared with the available ones
+	if resource.Unresolved {
+		return nil
+	}
+
+	url := c.baseURL() + strings.ToLower(resource.Namespace) + "/" + strings.ToLower(resource.Name)
 
 	var pattern string
 	if includePreview {
@@ -93,14 +260,18 @@ func UpdateResource(resource *types.Resource, includePreview bool) error {
 		pattern = `href="(\d{4}-\d{2}-\d{2})/` + strings.ToLower(resource.Name) + `"`
 	}
 
-	body, err := fetchResourcePage(url)
+	body, err := c.fetchPage(url)
+	if errors.Is(err, ErrNotFound) {
+		c.markUnknown(resource)
+		return nil
+	}
 	if err != nil {
 		return err
 	}
 
 	versions, err := extractAPIVersions(body, pattern)
 	if err != nil {
-		return err
+		return fmt.Errorf("%s: %w", resource.ID, err)
 	}
 
 	resource.AvailableAPIVersions = versions
@@ -1,6 +1,9 @@
 package apiversions
 
 import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
 	"testing"
 
@@ -102,10 +105,11 @@ func TestUpdateResource(t *testing.T) {
 		includePreview bool
 	}
 	tests := []struct {
-		name    string
-		args    args
-		subset  []string
-		wantErr bool
+		name        string
+		args        args
+		subset      []string
+		wantUnknown bool
+		wantErr     bool
 	}{
 		{
 			name: "valid-resource",
@@ -155,8 +159,9 @@ func TestUpdateResource(t *testing.T) {
 				},
 				includePreview: true,
 			},
-			subset:  nil,
-			wantErr: true,
+			subset:      nil,
+			wantUnknown: true,
+			wantErr:     false,
 		},
 	}
 	for _, tt := range tests {
@@ -165,6 +170,10 @@ func TestUpdateResource(t *testing.T) {
 				t.Fatalf("UpdateResource() error = %v, wantErr %v", err, tt.wantErr)
 			}
 
+			if tt.args.resource.Unknown != tt.wantUnknown {
+				t.Errorf("UpdateResource() unknown = %v, want %v", tt.args.resource.Unknown, tt.wantUnknown)
+			}
+
 			if !isSubset(tt.subset, tt.args.resource.AvailableAPIVersions) {
 				t.Errorf("UpdateResource() = %v is not superset of %v", tt.args.resource.AvailableAPIVersions, tt.subset)
 			}
@@ -208,7 +217,7 @@ func TestUpdateBicepFile(t *testing.T) {
 			wantErr: false,
 		},
 		{
-			name: "invalid-file",
+			name: "unknown-resource-file",
 			args: args{
 				bicepFile: &types.BicepFile{
 					Path: "invalid.bicep",
@@ -221,8 +230,8 @@ func TestUpdateBicepFile(t *testing.T) {
 					},
 				},
 			},
-			subset:  nil,
-			wantErr: true,
+			subset:  [][]string{nil},
+			wantErr: false,
 		},
 	}
 	for _, tt := range tests {
@@ -287,7 +296,7 @@ func TestUpdateBicepDirectory(t *testing.T) {
 			wantErr: false,
 		},
 		{
-			name: "invalid-directory",
+			name: "unknown-resource-directory",
 			args: args{
 				bicepDirectory: &types.BicepDirectory{
 					Path: "invalid",
@@ -305,8 +314,8 @@ func TestUpdateBicepDirectory(t *testing.T) {
 					},
 				},
 			},
-			subset:  nil,
-			wantErr: true,
+			subset:  [][][]string{{nil}},
+			wantErr: false,
 		},
 	}
 	for _, tt := range tests {
@@ -330,6 +339,169 @@ func TestUpdateBicepDirectory(t *testing.T) {
 	}
 }
 
+func Test_extractResourceTypes(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{
+			name: "link-text-casing",
+			body: `<a href="2023-01-01/storageaccounts">storageAccounts</a><a href="2022-09-01/storageaccounts">storageAccounts</a><a href="2023-01-01/deletedaccounts">deletedAccounts</a>`,
+			want: []string{"storageAccounts", "deletedAccounts"},
+		},
+		{
+			name: "lowercase-fallback",
+			body: `<a href="2023-01-01/storageaccounts" data-linktype="relative-path">Storage accounts</a>`,
+			want: []string{"storageaccounts"},
+		},
+		{
+			name: "no-types",
+			body: "invalid",
+			want: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := extractResourceTypes(tt.body); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("extractResourceTypes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_levenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"storageAccounts", "storageAccounts", 0},
+		{"storageAcounts", "storageAccounts", 1},
+		{"STORAGEACCOUNTS", "storageaccounts", 0},
+		{"", "sites", 5},
+		{"sites", "slots", 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.a+"-"+tt.b, func(t *testing.T) {
+			if got := levenshtein(tt.a, tt.b); got != tt.want {
+				t.Errorf("levenshtein() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_suggestResourceTypes(t *testing.T) {
+	known := []string{"storageAccounts", "deletedAccounts", "storageTasks"}
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{name: "storageAcounts", want: []string{"storageAccounts"}},
+		{name: "storageAccount", want: []string{"storageAccounts"}},
+		{name: "virtualNetworks", want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := suggestResourceTypes(tt.name, known); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("suggestResourceTypes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateResourceUnknown(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/microsoft.storage/storageaccounts":
+			fmt.Fprint(w, `<a href="2023-01-01/storageaccounts">2023-01-01</a>`)
+		case "/microsoft.storage/allversions":
+			fmt.Fprint(w, `<a href="2023-01-01/storageaccounts">storageAccounts</a><a href="2023-01-01/deletedaccounts">deletedAccounts</a>`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	defer func(url string) { baseURL = url }(baseURL)
+	baseURL = server.URL + "/"
+
+	tests := []struct {
+		name     string
+		resource types.Resource
+		want     types.Resource
+	}{
+		{
+			name: "known-type",
+			resource: types.Resource{
+				ID:        "Microsoft.Storage/storageAccounts",
+				Name:      "storageAccounts",
+				Namespace: "Microsoft.Storage",
+			},
+			want: types.Resource{
+				ID:                   "Microsoft.Storage/storageAccounts",
+				Name:                 "storageAccounts",
+				Namespace:            "Microsoft.Storage",
+				AvailableAPIVersions: []string{"2023-01-01"},
+			},
+		},
+		{
+			name: "unresolved-version",
+			resource: types.Resource{
+				ID:                "Microsoft.Storage/storageAccounts",
+				Name:              "storageAccounts",
+				Namespace:         "Microsoft.Storage",
+				CurrentAPIVersion: "[variables('apiVersion')]",
+				Unresolved:        true,
+			},
+			want: types.Resource{
+				ID:                "Microsoft.Storage/storageAccounts",
+				Name:              "storageAccounts",
+				Namespace:         "Microsoft.Storage",
+				CurrentAPIVersion: "[variables('apiVersion')]",
+				Unresolved:        true,
+			},
+		},
+		{
+			name: "misspelled-type",
+			resource: types.Resource{
+				ID:        "Microsoft.Storage/storageAcounts",
+				Name:      "storageAcounts",
+				Namespace: "Microsoft.Storage",
+			},
+			want: types.Resource{
+				ID:          "Microsoft.Storage/storageAcounts",
+				Name:        "storageAcounts",
+				Namespace:   "Microsoft.Storage",
+				Unknown:     true,
+				Suggestions: []string{"Microsoft.Storage/storageAccounts"},
+			},
+		},
+		{
+			name: "unknown-namespace",
+			resource: types.Resource{
+				ID:        "Microsoft.Invalid/things",
+				Name:      "things",
+				Namespace: "Microsoft.Invalid",
+			},
+			want: types.Resource{
+				ID:        "Microsoft.Invalid/things",
+				Name:      "things",
+				Namespace: "Microsoft.Invalid",
+				Unknown:   true,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := UpdateResource(&tt.resource, false); err != nil {
+				t.Fatalf("UpdateResource() error = %v", err)
+			}
+			if !reflect.DeepEqual(tt.resource, tt.want) {
+				t.Errorf("UpdateResource() = %v, want %v", tt.resource, tt.want)
+			}
+		})
+	}
+}
+
 /// Benchmarks ///
 
 //revive:disable:unhandled-error
@@ -0,0 +1,224 @@
+package arm
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// nodeKind represents the kind of a JSON value.
+type nodeKind int8
+
+const (
+	nodeObject  nodeKind = iota // nodeObject corresponds to a JSON object
+	nodeArray                   // nodeArray corresponds to a JSON array
+	nodeString                  // nodeString corresponds to a JSON string
+	nodeLiteral                 // nodeLiteral corresponds to a JSON number, boolean or null
+)
+
+// byteOrderMark is the UTF-8 byte order mark, which some editors prepend to ARM templates.
+const byteOrderMark = "\uFEFF"
+
+// node is a JSON value along with its position in the source, so that it can be edited in place.
+type node struct {
+	kind  nodeKind
+	start int // offset of the first byte of the value (e.g. the opening quote of a string)
+	end   int // offset right after the last byte of the value
+
+	str    string   // decoded value of a string
+	keys   []string // keys of an object, in order
+	values []*node  // values of an object (in the order of keys) or items of an array
+}
+
+// get returns the value of an object member, matching its key case-insensitively as ARM does.
+func (n *node) get(key string) *node {
+	if n == nil || n.kind != nodeObject {
+		return nil
+	}
+	for i, k := range n.keys {
+		if strings.EqualFold(k, key) {
+			return n.values[i]
+		}
+	}
+	return nil
+}
+
+// jsoncParser is a parser of JSON with comments, which ARM templates allow.
+type jsoncParser struct {
+	src string
+	pos int
+}
+
+// parseJSONC parses a JSON document that may contain comments.
+func parseJSONC(src string) (*node, error) {
+	p := &jsoncParser{src: src}
+	p.skipSpace()
+	root, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.src) {
+		return nil, p.errorf("unexpected content after the root value")
+	}
+	return root, nil
+}
+
+// errorf returns an error annotated with the current line.
+func (p *jsoncParser) errorf(format string, args ...any) error {
+	line := strings.Count(p.src[:p.pos], "\n") + 1
+	return fmt.Errorf("line %d: %s", line, fmt.Sprintf(format, args...))
+}
+
+// skipSpace skips whitespace and comments.
+func (p *jsoncParser) skipSpace() {
+	for p.pos < len(p.src) {
+		switch {
+		case strings.ContainsRune(" \t\r\n", rune(p.src[p.pos])):
+			p.pos++
+		case strings.HasPrefix(p.src[p.pos:], "//"):
+			end := strings.IndexByte(p.src[p.pos:], '\n')
+			if end < 0 {
+				p.pos = len(p.src)
+			} else {
+				p.pos += end
+			}
+		case strings.HasPrefix(p.src[p.pos:], "/*"):
+			end := strings.Index(p.src[p.pos+2:], "*/")
+			if end < 0 {
+				p.pos = len(p.src)
+			} else {
+				p.pos += end + 4
+			}
+		case strings.HasPrefix(p.src[p.pos:], byteOrderMark):
+			p.pos += len(byteOrderMark)
+		default:
+			return
+		}
+	}
+}
+
+// parseValue parses the value at the current position.
+func (p *jsoncParser) parseValue() (*node, error) {
+	if p.pos >= len(p.src) {
+		return nil, p.errorf("unexpected end of input")
+	}
+	switch c := p.src[p.pos]; {
+	case c == '{':
+		return p.parseObject()
+	case c == '[':
+		return p.parseArray()
+	case c == '"':
+		return p.parseString()
+	default:
+		start := p.pos
+		for p.pos < len(p.src) && !strings.ContainsRune(" \t\r\n,]}/", rune(p.src[p.pos])) {
+			p.pos++
+		}
+		if p.pos == start {
+			return nil, p.errorf("unexpected character %q", c)
+		}
+		return &node{kind: nodeLiteral, start: start, end: p.pos}, nil
+	}
+}
+
+// parseString parses a string at the current position.
+func (p *jsoncParser) parseString() (*node, error) {
+	start := p.pos
+	p.pos++
+	for p.pos < len(p.src) && p.src[p.pos] != '"' {
+		if p.src[p.pos] == '\\' {
+			p.pos++
+		}
+		p.pos++
+	}
+	if p.pos >= len(p.src) {
+		return nil, p.errorf("unterminated string")
+	}
+	p.pos++
+
+	var value string
+	if err := json.Unmarshal([]byte(p.src[start:p.pos]), &value); err != nil {
+		return nil, p.errorf("invalid string: %v", err)
+	}
+	return &node{kind: nodeString, start: start, end: p.pos, str: value}, nil
+}
+
+// parseObject parses an object at the current position.
+func (p *jsoncParser) parseObject() (*node, error) {
+	n := &node{kind: nodeObject, start: p.pos}
+	p.pos++
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.src) {
+			return nil, p.errorf("unterminated object")
+		}
+		if p.src[p.pos] == '}' {
+			p.pos++
+			n.end = p.pos
+			return n, nil
+		}
+		if len(n.keys) > 0 {
+			if p.src[p.pos] != ',' {
+				return nil, p.errorf("expected ',' in object")
+			}
+			p.pos++
+			p.skipSpace()
+			if p.pos < len(p.src) && p.src[p.pos] == '}' {
+				continue // trailing comma
+			}
+		}
+
+		if p.pos >= len(p.src) || p.src[p.pos] != '"' {
+			return nil, p.errorf("expected object key")
+		}
+		key, err := p.parseString()
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		if p.pos >= len(p.src) || p.src[p.pos] != ':' {
+			return nil, p.errorf("expected ':' after object key")
+		}
+		p.pos++
+		p.skipSpace()
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		n.keys = append(n.keys, key.str)
+		n.values = append(n.values, value)
+	}
+}
+
+// parseArray parses an array at the current position.
+func (p *jsoncParser) parseArray() (*node, error) {
+	n := &node{kind: nodeArray, start: p.pos}
+	p.pos++
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.src) {
+			return nil, p.errorf("unterminated array")
+		}
+		if p.src[p.pos] == ']' {
+			p.pos++
+			n.end = p.pos
+			return n, nil
+		}
+		if len(n.values) > 0 {
+			if p.src[p.pos] != ',' {
+				return nil, p.errorf("expected ',' in array")
+			}
+			p.pos++
+			p.skipSpace()
+			if p.pos < len(p.src) && p.src[p.pos] == ']' {
+				continue // trailing comma
+			}
+		}
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		n.values = append(n.values, value)
+	}
+}
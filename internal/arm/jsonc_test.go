@@ -0,0 +1,84 @@
+package arm
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_parseJSONC(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		wantKeys []string
+		wantErr  bool
+	}{
+		{
+			name:     "plain-json",
+			src:      `{"a": 1, "b": "x", "c": [true, null], "d": {}}`,
+			wantKeys: []string{"a", "b", "c", "d"},
+		},
+		{
+			name:     "comments",
+			src:      "{\n  // line comment\n  \"a\": 1, /* block\n comment */ \"b\": 2\n}",
+			wantKeys: []string{"a", "b"},
+		},
+		{
+			name:     "trailing-commas",
+			src:      `{"a": [1, 2,], "b": 2,}`,
+			wantKeys: []string{"a", "b"},
+		},
+		{
+			name:     "byte-order-mark",
+			src:      byteOrderMark + `{"a": 1}`,
+			wantKeys: []string{"a"},
+		},
+		{
+			name:    "unterminated-object",
+			src:     `{"a": 1`,
+			wantErr: true,
+		},
+		{
+			name:    "missing-colon",
+			src:     `{"a" 1}`,
+			wantErr: true,
+		},
+		{
+			name:    "content-after-root",
+			src:     `{} {}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseJSONC(tt.src)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseJSONC() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got.keys, tt.wantKeys) {
+				t.Errorf("parseJSONC() keys = %v, want %v", got.keys, tt.wantKeys)
+			}
+		})
+	}
+}
+
+func Test_parseJSONC_positions(t *testing.T) {
+	src := `{"type": "Microsoft.Web/sites", "apiVersion": "2022-03-01"}`
+	root, err := parseJSONC(src)
+	if err != nil {
+		t.Fatalf("parseJSONC() error = %v", err)
+	}
+
+	version := root.get("APIVERSION")
+	if version == nil {
+		t.Fatalf("get() = nil, want apiVersion")
+	}
+	if got := src[version.start:version.end]; got != `"2022-03-01"` {
+		t.Errorf("source of apiVersion = %s, want %q", got, "2022-03-01")
+	}
+	if version.str != "2022-03-01" {
+		t.Errorf("apiVersion = %s, want 2022-03-01", version.str)
+	}
+}
@@ -0,0 +1,319 @@
+/*
+Package arm provides a set of functions to manipulate ARM JSON templates.
+
+It offers methods for parsing templates to extract the type and API version of each resource, including the resources
+declared in nested "resources" arrays and in the inline templates of nested deployments.
+The results are returned as types.BicepFile objects, so that they can be processed the same way as Bicep files.
+
+The package also includes UpdateFile, which rewrites the apiVersion values of a template while preserving its formatting.
+API versions given as template language expressions (e.g. [variables('apiVersion')]) are reported as unresolved and never overwritten.
+*/
+package arm
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/christosgalano/bruh/internal/functions"
+	"github.com/christosgalano/bruh/internal/types"
+)
+
+const (
+	// templateSchema is the name of the JSON schema referenced by all deployment templates.
+	templateSchema = "deploymenttemplate.json"
+
+	// deploymentsType is the resource type of nested deployments.
+	deploymentsType = "Microsoft.Resources/deployments"
+)
+
+var (
+	// ErrNotTemplate is returned when a JSON file is not an ARM deployment template (e.g. a parameters file).
+	ErrNotTemplate = errors.New("not an ARM template")
+
+	// versionRegex is the regex used to match literal API versions.
+	versionRegex = regexp.MustCompile(`^[0-9]{4}-[0-9]{2}-[0-9]{2}(-preview)?$`)
+
+	// armOnlyProperties are the properties of a resource declaration that are handled by ARM itself,
+	// and thus are not part of the resource body.
+	armOnlyProperties = map[string]bool{
+		"type":       true,
+		"apiversion": true,
+		"dependson":  true,
+		"condition":  true,
+		"copy":       true,
+		"comments":   true,
+		"resources":  true,
+		"scope":      true,
+	}
+)
+
+// readTemplate reads and parses an ARM template.
+// If the file does not exist, is a directory, or does not have the .json extension, the function returns an error
+// wrapping types.ErrFileNotExist, types.ErrIsDirectory or types.ErrInvalidExtension respectively.
+// If the file is not a valid deployment template, the function returns ErrNotTemplate.
+func readTemplate(filePath string) (string, *node, error) {
+	f, err := os.Stat(filePath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", nil, fmt.Errorf("%w %q", types.ErrFileNotExist, filePath)
+		}
+		return "", nil, err
+	}
+
+	if f.IsDir() {
+		return "", nil, fmt.Errorf("%w %q", types.ErrIsDirectory, filePath)
+	}
+
+	if ext := filepath.Ext(filePath); ext != ".json" {
+		return "", nil, fmt.Errorf("%w %q", types.ErrInvalidExtension, ext)
+	}
+
+	data, err := os.ReadFile(filepath.Clean(filePath))
+	if err != nil {
+		return "", nil, err
+	}
+	content := string(data)
+
+	root, err := parseTemplate(filePath, content)
+	if err != nil {
+		return "", nil, err
+	}
+	return content, root, nil
+}
+
+// parseTemplate parses the content of an ARM template.
+// If the content is not a valid deployment template, the function returns ErrNotTemplate.
+func parseTemplate(filePath, content string) (*node, error) {
+	root, err := parseJSONC(content)
+	if err != nil {
+		return nil, fmt.Errorf("%w %q: %s", ErrNotTemplate, filePath, err)
+	}
+	schema := root.get("$schema")
+	if schema == nil || schema.kind != nodeString || !strings.Contains(strings.ToLower(schema.str), templateSchema) {
+		return nil, fmt.Errorf("%w %q", ErrNotTemplate, filePath)
+	}
+	return root, nil
+}
+
+// isExpression returns true if a string is a template language expression (e.g. "[variables('apiVersion')]").
+// Strings starting with "[[" are escaped literals.
+func isExpression(s string) bool {
+	return strings.HasPrefix(s, "[") && !strings.HasPrefix(s, "[[") && strings.HasSuffix(s, "]")
+}
+
+// declaration is a resource declaration of a template, along with the nodes of its type and API version.
+type declaration struct {
+	resourceType string
+	typeNode     *node
+	versionNode  *node
+	body         *node
+}
+
+// collectDeclarations appends the declarations of a "resources" array (or object of symbolic names) and all their nested resources.
+// The types of nested resources are prefixed with the type of their parent, unless they are already fully qualified.
+func collectDeclarations(resources *node, parentType string, declarations *[]declaration) {
+	if resources == nil || (resources.kind != nodeArray && resources.kind != nodeObject) {
+		return
+	}
+
+	for _, item := range resources.values {
+		if item.kind != nodeObject {
+			continue
+		}
+
+		typeNode := item.get("type")
+		resourceType := ""
+		if typeNode != nil && typeNode.kind == nodeString && !isExpression(typeNode.str) {
+			resourceType = typeNode.str
+			if parentType != "" && !strings.Contains(strings.SplitN(resourceType, "/", 2)[0], ".") {
+				resourceType = parentType + "/" + resourceType
+			}
+		}
+
+		*declarations = append(*declarations, declaration{
+			resourceType: resourceType,
+			typeNode:     typeNode,
+			versionNode:  item.get("apiVersion"),
+			body:         item,
+		})
+
+		// Nested resources
+		if resourceType != "" {
+			collectDeclarations(item.get("resources"), resourceType, declarations)
+		}
+
+		// Inline templates of nested deployments
+		if strings.EqualFold(resourceType, deploymentsType) {
+			template := item.get("properties").get("template")
+			collectDeclarations(template.get("resources"), "", declarations)
+		}
+	}
+}
+
+// resourceDeclarations returns the declarations of a template that correspond to resources, in document order.
+func resourceDeclarations(root *node) []declaration {
+	declarations := []declaration{}
+	collectDeclarations(root.get("resources"), "", &declarations)
+
+	results := []declaration{}
+	for _, d := range declarations {
+		if _, ok := newResource(d); ok {
+			results = append(results, d)
+		}
+	}
+	return results
+}
+
+// templateCalls returns the calls of template functions with a hard-coded API version and an inferred type
+// (e.g. [listKeys(resourceId('Microsoft.Storage/storageAccounts', 'name'), '2021-04-01')]) found in the expressions of a template.
+// The offsets of the calls refer to the content of the template.
+func templateCalls(content string, root *node) []functions.Call {
+	// Symbolic names of templates with languageVersion 2.0
+	symbols := map[string]string{}
+	if resources := root.get("resources"); resources != nil && resources.kind == nodeObject {
+		for i, key := range resources.keys {
+			if t := resources.values[i].get("type"); t != nil && t.kind == nodeString {
+				symbols[key] = t.str
+			}
+		}
+	}
+
+	calls := []functions.Call{}
+	var walk func(n *node)
+	walk = func(n *node) {
+		if n.kind == nodeString && isExpression(n.str) {
+			offset := n.start + 1
+			for _, call := range functions.Find(content[offset:n.end-1], symbols) {
+				if call.Type != "" {
+					call.Start += offset
+					call.End += offset
+					calls = append(calls, call)
+				}
+			}
+		}
+		for _, value := range n.values {
+			walk(value)
+		}
+	}
+	walk(root)
+	return calls
+}
+
+// bodyProperties returns the paths of the properties set in a resource declaration.
+// Nested properties are joined with dots, while the items of an array are denoted by "[]" (e.g. properties.subnets[].name).
+func bodyProperties(body *node) []string {
+	paths := []string{}
+	seen := map[string]bool{}
+
+	var walk func(n *node, prefix string)
+	walk = func(n *node, prefix string) {
+		switch n.kind {
+		case nodeObject:
+			for i, key := range n.keys {
+				if prefix == "" && armOnlyProperties[strings.ToLower(key)] {
+					continue
+				}
+				path := key
+				if prefix != "" {
+					path = prefix + "." + key
+				}
+				if !seen[path] {
+					seen[path] = true
+					paths = append(paths, path)
+				}
+				walk(n.values[i], path)
+			}
+		case nodeArray:
+			for _, item := range n.values {
+				if item.kind == nodeObject {
+					walk(item, prefix+"[]")
+				}
+			}
+		}
+	}
+	walk(body, "")
+
+	if len(paths) == 0 {
+		return nil
+	}
+	return paths
+}
+
+// newResource creates a resource from a declaration with a literal type.
+// If the API version is a template language expression, the resource is marked as unresolved.
+// If the type is not a literal or the API version is not a valid one, the function returns false.
+func newResource(d declaration) (types.Resource, bool) {
+	if d.resourceType == "" || d.versionNode == nil || d.versionNode.kind != nodeString {
+		return types.Resource{}, false
+	}
+
+	version := d.versionNode.str
+	unresolved := isExpression(version)
+	if !unresolved && !versionRegex.MatchString(version) {
+		return types.Resource{}, false
+	}
+
+	namespace, name, found := strings.Cut(d.resourceType, "/")
+	if !found {
+		return types.Resource{}, false
+	}
+
+	return types.Resource{
+		ID:                d.resourceType,
+		Name:              name,
+		Namespace:         namespace,
+		CurrentAPIVersion: version,
+		Properties:        bodyProperties(d.body),
+		Unresolved:        unresolved,
+	}, true
+}
+
+// ParseFile parses an ARM template and returns a pointer to a BicepFile object.
+// API versions passed to functions such as reference and listKeys are returned as resources of that function, after the declared ones.
+// If the file is not a deployment template (e.g. a parameters file), the function returns ErrNotTemplate.
+func ParseFile(filePath string) (*types.BicepFile, error) {
+	content, root, err := readTemplate(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return parseResources(filePath, content, root), nil
+}
+
+// ParseContent parses the content of an ARM template read from elsewhere than the file system (e.g. a git revision),
+// and returns a pointer to a BicepFile object whose path is the given one.
+// If the path does not have the .json extension, the function returns an error wrapping types.ErrInvalidExtension,
+// and if the content is not a deployment template, it returns ErrNotTemplate.
+func ParseContent(filePath, content string) (*types.BicepFile, error) {
+	if ext := filepath.Ext(filePath); ext != ".json" {
+		return nil, fmt.Errorf("%w %q", types.ErrInvalidExtension, ext)
+	}
+	root, err := parseTemplate(filePath, content)
+	if err != nil {
+		return nil, err
+	}
+	return parseResources(filePath, content, root), nil
+}
+
+// parseResources returns a BicepFile object with the declared resources of a parsed template, followed by its function calls.
+func parseResources(filePath, content string, root *node) *types.BicepFile {
+	results := []types.Resource{}
+	for _, d := range resourceDeclarations(root) {
+		resource, _ := newResource(d)
+		resource.Line = types.LineAt(content, d.versionNode.start)
+		results = append(results, resource)
+	}
+	for _, call := range templateCalls(content, root) {
+		resource := call.Resource()
+		resource.Line = types.LineAt(content, call.Start)
+		results = append(results, resource)
+	}
+
+	return &types.BicepFile{
+		Path:      filePath,
+		Resources: results,
+	}
+}
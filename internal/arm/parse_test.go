@@ -0,0 +1,152 @@
+package arm
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/christosgalano/bruh/internal/types"
+)
+
+func TestParseFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		filePath string
+		want     []types.Resource
+		wantErr  error
+	}{
+		{
+			name:     "nested-resources-and-deployments",
+			filePath: "testdata/parse/azuredeploy.json",
+			want: []types.Resource{
+				{
+					ID:                "Microsoft.Network/virtualNetworks",
+					Name:              "virtualNetworks",
+					Namespace:         "Microsoft.Network",
+					CurrentAPIVersion: "2020-06-01",
+					Line:              17,
+					Properties:        []string{"name", "location", "properties", "properties.addressSpace", "properties.addressSpace.addressPrefixes"},
+				},
+				{
+					ID:                "Microsoft.Network/virtualNetworks/subnets",
+					Name:              "virtualNetworks/subnets",
+					Namespace:         "Microsoft.Network",
+					CurrentAPIVersion: "2020-06-01",
+					Line:              28,
+					Properties:        []string{"name", "properties", "properties.addressPrefix"},
+				},
+				{
+					ID:                "Microsoft.Storage/storageAccounts",
+					Name:              "storageAccounts",
+					Namespace:         "Microsoft.Storage",
+					CurrentAPIVersion: "[variables('storageApiVersion')]",
+					Line:              40,
+					Properties:        []string{"name", "location"},
+					Unresolved:        true,
+				},
+				{
+					ID:                "Microsoft.Resources/deployments",
+					Name:              "deployments",
+					Namespace:         "Microsoft.Resources",
+					CurrentAPIVersion: "2021-04-01",
+					Line:              46,
+					Properties: []string{
+						"name", "properties", "properties.mode", "properties.template", "properties.template.$schema",
+						"properties.template.contentVersion", "properties.template.resources",
+						"properties.template.resources[].type", "properties.template.resources[].apiVersion",
+						"properties.template.resources[].name", "properties.template.resources[].location",
+					},
+				},
+				{
+					ID:                "Microsoft.ManagedIdentity/userAssignedIdentities",
+					Name:              "userAssignedIdentities",
+					Namespace:         "Microsoft.ManagedIdentity",
+					CurrentAPIVersion: "2018-11-30",
+					Line:              56,
+					Properties:        []string{"name", "location"},
+				},
+				{
+					ID:                "Microsoft.Network/publicIPAddresses",
+					Name:              "publicIPAddresses",
+					Namespace:         "Microsoft.Network",
+					CurrentAPIVersion: "2019-06-01",
+					Line:              68,
+					Function:          "reference",
+				},
+			},
+		},
+		{
+			name:     "symbolic-names",
+			filePath: "testdata/parse/symbolic.json",
+			want: []types.Resource{
+				{
+					ID:                "Microsoft.Web/serverfarms",
+					Name:              "serverfarms",
+					Namespace:         "Microsoft.Web",
+					CurrentAPIVersion: "2022-03-01-preview",
+					Line:              8,
+					Properties:        []string{"name", "sku", "sku.name"},
+				},
+				{
+					ID:                "Microsoft.Web/serverfarms",
+					Name:              "serverfarms",
+					Namespace:         "Microsoft.Web",
+					CurrentAPIVersion: "2022-03-01-preview",
+					Line:              18,
+					Function:          "reference",
+				},
+			},
+		},
+		{
+			name:     "parameters-file",
+			filePath: "testdata/parse/azuredeploy.parameters.json",
+			wantErr:  ErrNotTemplate,
+		},
+		{
+			name:     "invalid-json",
+			filePath: "testdata/invalid.json",
+			wantErr:  ErrNotTemplate,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseFile(tt.filePath)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if !reflect.DeepEqual(got.Resources, tt.want) {
+				t.Errorf("\nParseFile() = %v\nwant %v", got.Resources, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFileErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		filePath string
+	}{
+		{
+			name:     "non-existent-file",
+			filePath: "testdata/non-existent-file.json",
+		},
+		{
+			name:     "directory",
+			filePath: "testdata",
+		},
+		{
+			name:     "invalid-extension",
+			filePath: "../bicep/testdata/parse/azure.deploy.bicep",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseFile(tt.filePath); err == nil || errors.Is(err, ErrNotTemplate) {
+				t.Fatalf("ParseFile() error = %v, want a file error", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package arm
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/christosgalano/bruh/internal/types"
+)
+
+// edit is a replacement of the source bytes between start and end.
+type edit struct {
+	start int
+	end   int
+	text  string
+}
+
+// applyEdits applies non-overlapping edits to the content, leaving everything else (formatting, comments, line endings) untouched.
+func applyEdits(content string, edits []edit) string {
+	sort.Slice(edits, func(i, j int) bool {
+		return edits[i].start > edits[j].start
+	})
+	for _, e := range edits {
+		content = content[:e.start] + e.text + content[e.end:]
+	}
+	return content
+}
+
+// quote returns the JSON representation of a string.
+func quote(s string) string {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return `"` + s + `"`
+	}
+	return string(data)
+}
+
+// UpdateFile receives a pointer to a BicepFile object parsed from an ARM template and updates the apiVersion of each resource,
+// as well as the API versions passed to functions such as reference and listKeys.
+// Only the version strings are rewritten, so the key order, indentation, comments and line endings of the template are preserved.
+// Resources with an unknown type, an unresolved API version or excluded from the update are left untouched.
+// inPlace determines whether the function will update the file in place or create a new one with the suffix "_updated.json".
+func UpdateFile(bicepFile *types.BicepFile, inPlace bool) error {
+	content, root, err := readTemplate(bicepFile.Path)
+	if err != nil {
+		return err
+	}
+
+	declarations := resourceDeclarations(root)
+	calls := templateCalls(content, root)
+	if len(declarations)+len(calls) != len(bicepFile.Resources) {
+		return fmt.Errorf("template %q changed since it was parsed", bicepFile.Path)
+	}
+
+	edits := []edit{}
+	for i := range bicepFile.Resources {
+		resource := &bicepFile.Resources[i]
+
+		// Declarations come first, followed by function calls
+		var e edit
+		if i < len(declarations) {
+			d := declarations[i]
+			if resource.Function != "" || !strings.EqualFold(d.resourceType, resource.ID) {
+				return fmt.Errorf("template %q changed since it was parsed", bicepFile.Path)
+			}
+			e = edit{start: d.versionNode.start, end: d.versionNode.end}
+		} else {
+			call := calls[i-len(declarations)]
+			if resource.Function != call.Function || !strings.EqualFold(call.Type, resource.ID) {
+				return fmt.Errorf("template %q changed since it was parsed", bicepFile.Path)
+			}
+			e = edit{start: call.Start, end: call.End}
+		}
+
+		if resource.Unknown || resource.Unresolved || resource.Skipped {
+			continue
+		}
+		latestAPIVersion := resource.LatestAPIVersion()
+		if latestAPIVersion == "" || resource.CurrentAPIVersion == latestAPIVersion {
+			continue
+		}
+		if i < len(declarations) {
+			e.text = quote(latestAPIVersion)
+		} else {
+			e.text = latestAPIVersion
+		}
+		edits = append(edits, e)
+		resource.CurrentAPIVersion = latestAPIVersion
+	}
+	content = applyEdits(content, edits)
+
+	// Use the same permissions as the original file
+	f, err := os.Stat(bicepFile.Path)
+	if err != nil {
+		return err
+	}
+
+	// If the file is not updated in place, create a new one with the suffix "_updated.json"
+	if !inPlace {
+		bicepFile.Path = strings.TrimSuffix(bicepFile.Path, ".json") + "_updated.json"
+	}
+
+	if err := os.WriteFile(bicepFile.Path, []byte(content), f.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to update file %s", err)
+	}
+	return nil
+}
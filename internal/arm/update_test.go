@@ -0,0 +1,102 @@
+package arm
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// template is an ARM template with comments, CRLF line endings, an unresolved API version and a function call.
+const template = "{\r\n" +
+	"  \"$schema\": \"https://schema.management.azure.com/schemas/2019-04-01/deploymentTemplate.json#\",\r\n" +
+	"  \"resources\": [\r\n" +
+	"    // Virtual network\r\n" +
+	"    {\"type\": \"Microsoft.Network/virtualNetworks\", \"apiVersion\":\"2020-06-01\", \"name\": \"vnet\",\r\n" +
+	"      \"resources\": [{ \"apiVersion\": \"2020-06-01\", \"type\": \"subnets\", \"name\": \"default\" }]},\r\n" +
+	"    {\"type\": \"Microsoft.Storage/storageAccounts\", \"apiVersion\": \"[variables('apiVersion')]\", \"name\": \"storage\"},\r\n" +
+	"    {\"type\": \"Microsoft.Web/sites\", \"apiVersion\": \"2019-08-01\", \"name\": \"site\"} /* excluded */\r\n" +
+	"  ],\r\n" +
+	"  \"outputs\": {\"key\": {\"type\": \"string\", \"value\": \"[listKeys(resourceId('Microsoft.Web/sites', 'site'), '2019-08-01').key]\"}}\r\n" +
+	"}\r\n"
+
+func TestUpdateFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		inPlace  bool
+		wantPath string
+	}{
+		{
+			name:     "in-place",
+			inPlace:  true,
+			wantPath: "azuredeploy.json",
+		},
+		{
+			name:     "new-file",
+			inPlace:  false,
+			wantPath: "azuredeploy_updated.json",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "azuredeploy.json")
+			if err := os.WriteFile(path, []byte(template), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			file, err := ParseFile(path)
+			if err != nil {
+				t.Fatalf("ParseFile() error = %v", err)
+			}
+			if len(file.Resources) != 5 {
+				t.Fatalf("ParseFile() resources = %v, want 5", len(file.Resources))
+			}
+			file.Resources[0].AvailableAPIVersions = []string{"2023-04-01", "2020-06-01"}
+			file.Resources[1].AvailableAPIVersions = []string{"2023-05-01", "2020-06-01"}
+			file.Resources[3].AvailableAPIVersions = []string{"2022-03-01", "2019-08-01"}
+			file.Resources[3].Skipped = true
+			file.Resources[4].AvailableAPIVersions = []string{"2022-03-01", "2019-08-01"}
+
+			if err := UpdateFile(file, tt.inPlace); err != nil {
+				t.Fatalf("UpdateFile() error = %v", err)
+			}
+			if got := filepath.Base(file.Path); got != tt.wantPath {
+				t.Errorf("UpdateFile() path = %v, want %v", got, tt.wantPath)
+			}
+
+			data, err := os.ReadFile(file.Path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := strings.Replace(template, `"apiVersion":"2020-06-01"`, `"apiVersion":"2023-04-01"`, 1)
+			want = strings.Replace(want, `"apiVersion": "2020-06-01"`, `"apiVersion": "2023-05-01"`, 1)
+			want = strings.Replace(want, `'site'), '2019-08-01')`, `'site'), '2022-03-01')`, 1)
+			if string(data) != want {
+				t.Errorf("UpdateFile() content =\n%q\nwant\n%q", data, want)
+			}
+
+			if file.Resources[0].CurrentAPIVersion != "2023-04-01" || file.Resources[3].CurrentAPIVersion != "2019-08-01" {
+				t.Errorf("UpdateFile() resources = %v", file.Resources)
+			}
+		})
+	}
+}
+
+func TestUpdateFileChanged(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "azuredeploy.json")
+	if err := os.WriteFile(path, []byte(template), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := ParseFile(path)
+	if err != nil {
+		t.Fatalf("ParseFile() error = %v", err)
+	}
+	file.Resources = file.Resources[1:]
+
+	if err := UpdateFile(file, true); err == nil {
+		t.Errorf("UpdateFile() error = nil, want an error for a changed template")
+	}
+}
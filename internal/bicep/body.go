@@ -0,0 +1,326 @@
+package bicep
+
+import (
+	"strings"
+)
+
+// bicepOnlyProperties are the top-level properties of a resource declaration that are handled by Bicep itself,
+// and thus are not part of the resource body sent to Azure.
+var bicepOnlyProperties = map[string]bool{
+	"parent":    true,
+	"scope":     true,
+	"dependsOn": true,
+}
+
+// bodyScanner is a minimal scanner of Bicep expressions, used to walk object literals.
+type bodyScanner struct {
+	src string
+	pos int
+}
+
+// resourceProperties returns the paths of the properties set in the body of the resource declared at the given offset.
+// The offset must point right after the resource type and API version (e.g. after "Microsoft.Web/sites@2022-03-01").
+// Nested properties are joined with dots, while the items of an array are denoted by "[]" (e.g. properties.subnets[].name).
+// If the offset does not belong to a resource declaration with an object body, the function returns nil.
+func resourceProperties(content string, offset int) []string {
+	s := &bodyScanner{src: content, pos: offset}
+
+	// resource <symbol> '<type>@<version>' [existing] = [if (<condition>)] [[for <item> in <items>:] {
+	if !s.consume("'") {
+		return nil
+	}
+	s.skipSpace(true)
+	s.consume("existing")
+	s.skipSpace(true)
+	if !s.consume("=") {
+		return nil
+	}
+	s.skipSpace(true)
+	s.skipCondition()
+	if s.peek() == '[' {
+		s.pos++
+		s.skipSpace(true)
+		if !s.skipLoopHeader() {
+			return nil
+		}
+		s.skipSpace(true)
+		s.skipCondition()
+	}
+	if s.peek() != '{' {
+		return nil
+	}
+
+	paths := s.parseObject("")
+	if len(paths) == 0 {
+		return nil
+	}
+
+	// Remove duplicates (e.g. properties of multiple objects in the same array)
+	seen := map[string]bool{}
+	unique := []string{}
+	for _, path := range paths {
+		if !seen[path] {
+			seen[path] = true
+			unique = append(unique, path)
+		}
+	}
+	return unique
+}
+
+// eof returns true if the scanner has reached the end of the source.
+func (s *bodyScanner) eof() bool {
+	return s.pos >= len(s.src)
+}
+
+// peek returns the current byte or 0 at the end of the source.
+func (s *bodyScanner) peek() byte {
+	if s.eof() {
+		return 0
+	}
+	return s.src[s.pos]
+}
+
+// consume advances past the given token if the source continues with it.
+func (s *bodyScanner) consume(token string) bool {
+	if strings.HasPrefix(s.src[s.pos:], token) {
+		s.pos += len(token)
+		return true
+	}
+	return false
+}
+
+// skipSpace skips whitespace and comments. If separators is true, newlines and commas are skipped as well.
+func (s *bodyScanner) skipSpace(separators bool) {
+	for !s.eof() {
+		switch c := s.peek(); {
+		case c == ' ' || c == '\t' || c == '\r':
+			s.pos++
+		case separators && (c == '\n' || c == ','):
+			s.pos++
+		case strings.HasPrefix(s.src[s.pos:], "//"):
+			end := strings.IndexByte(s.src[s.pos:], '\n')
+			if end < 0 {
+				s.pos = len(s.src)
+			} else {
+				s.pos += end
+			}
+		case strings.HasPrefix(s.src[s.pos:], "/*"):
+			end := strings.Index(s.src[s.pos+2:], "*/")
+			if end < 0 {
+				s.pos = len(s.src)
+			} else {
+				s.pos += end + 4
+			}
+		default:
+			return
+		}
+	}
+}
+
+// skipCondition skips an "if (<condition>)" clause, if present.
+func (s *bodyScanner) skipCondition() {
+	if !strings.HasPrefix(s.src[s.pos:], "if") {
+		return
+	}
+	start := s.pos
+	s.pos += 2
+	s.skipSpace(true)
+	if s.peek() != '(' {
+		s.pos = start
+		return
+	}
+	s.skipExpression(true)
+	s.skipSpace(true)
+}
+
+// skipLoopHeader skips a "for <item> in <items>:" clause, including the colon.
+func (s *bodyScanner) skipLoopHeader() bool {
+	if !s.consume("for") {
+		return false
+	}
+	depth := 0
+	for !s.eof() {
+		switch c := s.peek(); c {
+		case '\'':
+			s.skipString()
+			continue
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+		case ':':
+			if depth == 0 {
+				s.pos++
+				return true
+			}
+		}
+		s.pos++
+	}
+	return false
+}
+
+// skipString skips a string literal, including interpolations and multi-line strings.
+func (s *bodyScanner) skipString() {
+	if s.consume("'''") {
+		end := strings.Index(s.src[s.pos:], "'''")
+		if end < 0 {
+			s.pos = len(s.src)
+		} else {
+			s.pos += end + 3
+		}
+		return
+	}
+
+	s.pos++
+	for !s.eof() {
+		switch {
+		case s.peek() == '\\':
+			s.pos += 2
+		case s.peek() == '\'':
+			s.pos++
+			return
+		case strings.HasPrefix(s.src[s.pos:], "${"):
+			s.pos++
+			s.skipExpression(true)
+		default:
+			s.pos++
+		}
+	}
+}
+
+// skipExpression skips an expression until a separator or a closing bracket at depth zero.
+// If single is true, only a single bracketed group is skipped (e.g. "(a && b)" or "${a}").
+func (s *bodyScanner) skipExpression(single bool) {
+	depth := 0
+	for !s.eof() {
+		switch c := s.peek(); {
+		case c == '\'':
+			s.skipString()
+			continue
+		case strings.HasPrefix(s.src[s.pos:], "//") || strings.HasPrefix(s.src[s.pos:], "/*"):
+			s.skipSpace(false)
+			continue
+		case c == '(' || c == '[' || c == '{':
+			depth++
+		case c == ')' || c == ']' || c == '}':
+			if depth == 0 {
+				return
+			}
+			depth--
+			if depth == 0 && single {
+				s.pos++
+				return
+			}
+		case (c == '\n' || c == ',') && depth == 0:
+			return
+		}
+		s.pos++
+	}
+}
+
+// readKey reads an object key, either an identifier or a quoted string.
+func (s *bodyScanner) readKey() string {
+	start := s.pos
+	if s.peek() == '\'' {
+		s.pos++
+		for !s.eof() && s.peek() != '\'' {
+			if s.peek() == '\\' {
+				s.pos++
+			}
+			s.pos++
+		}
+		s.pos++
+		if s.pos > len(s.src) {
+			s.pos = len(s.src)
+		}
+		return strings.ReplaceAll(s.src[start+1:s.pos-1], "\\'", "'")
+	}
+	for !s.eof() {
+		c := s.peek()
+		if c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9' && s.pos > start) {
+			s.pos++
+			continue
+		}
+		break
+	}
+	return s.src[start:s.pos]
+}
+
+// parseObject parses an object literal starting at the current position and returns the paths of its properties.
+func (s *bodyScanner) parseObject(prefix string) []string {
+	paths := []string{}
+	s.pos++
+	for {
+		s.skipSpace(true)
+		if s.eof() {
+			return paths
+		}
+		if s.peek() == '}' {
+			s.pos++
+			return paths
+		}
+
+		start := s.pos
+		key := s.readKey()
+		s.skipSpace(false)
+		if key == "" || !s.consume(":") {
+			s.skipExpression(false)
+			if s.pos == start {
+				s.pos++
+			}
+			continue
+		}
+		s.skipSpace(false)
+
+		if prefix == "" && bicepOnlyProperties[key] {
+			s.skipExpression(false)
+			continue
+		}
+
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+		paths = append(paths, path)
+		paths = append(paths, s.parseValue(path)...)
+	}
+}
+
+// parseArray parses an array literal (or a for expression) starting at the current position
+// and returns the paths of the properties of its object items.
+func (s *bodyScanner) parseArray(prefix string) []string {
+	paths := []string{}
+	path := prefix + "[]"
+	s.pos++
+	s.skipSpace(true)
+	if strings.HasPrefix(s.src[s.pos:], "for ") && s.skipLoopHeader() {
+		s.skipSpace(true)
+	}
+	for {
+		s.skipSpace(true)
+		if s.eof() {
+			return paths
+		}
+		if s.peek() == ']' {
+			s.pos++
+			return paths
+		}
+		start := s.pos
+		paths = append(paths, s.parseValue(path)...)
+		if s.pos == start {
+			s.pos++
+		}
+	}
+}
+
+// parseValue parses a property value and returns the paths of any nested properties.
+func (s *bodyScanner) parseValue(path string) []string {
+	switch s.peek() {
+	case '{':
+		return s.parseObject(path)
+	case '[':
+		return s.parseArray(path)
+	}
+	s.skipExpression(false)
+	return nil
+}
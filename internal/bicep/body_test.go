@@ -0,0 +1,94 @@
+package bicep
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_resourceProperties(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{
+			name: "nested-objects",
+			content: `resource st 'Microsoft.Storage/storageAccounts@2023-01-01' = {
+  name: 'st${suffix}'
+  location: location
+  sku: {
+    name: 'Standard_LRS'
+  }
+  properties: {
+    minimumTlsVersion: 'TLS1_2'
+  }
+}`,
+			want: []string{"name", "location", "sku", "sku.name", "properties", "properties.minimumTlsVersion"},
+		},
+		{
+			name: "arrays-and-bicep-only-properties",
+			content: `resource vnet 'Microsoft.Network/virtualNetworks@2023-04-01' = {
+  name: 'vnet'
+  parent: other
+  dependsOn: [
+    other
+  ]
+  properties: {
+    addressSpace: {
+      addressPrefixes: [ '10.0.0.0/16' ]
+    }
+    subnets: [
+      {
+        name: 'a'
+        properties: { addressPrefix: '10.0.0.0/24' }
+      }
+      {
+        name: 'b'
+      }
+    ]
+  }
+}`,
+			want: []string{
+				"name", "properties", "properties.addressSpace", "properties.addressSpace.addressPrefixes",
+				"properties.subnets", "properties.subnets[].name", "properties.subnets[].properties", "properties.subnets[].properties.addressPrefix",
+			},
+		},
+		{
+			name: "loop-condition-and-comments",
+			content: `resource sites 'Microsoft.Web/sites@2022-03-01' = [for site in sites: if (site.enabled) {
+  // name: 'commented'
+  name: site.name /* inline } comment */
+  'quoted-key': '}{'
+  properties: {
+    siteConfig: union(defaults, {
+      alwaysOn: true
+    })
+    appSettings: [for setting in settings: {
+      name: setting.key
+    }]
+  }
+}]`,
+			want: []string{"name", "quoted-key", "properties", "properties.siteConfig", "properties.appSettings", "properties.appSettings[].name"},
+		},
+		{
+			name:    "existing-resource",
+			content: `resource rg 'Microsoft.Resources/resourceGroups@2021-04-01' existing = { name: 'rg', scope: subscription() }`,
+			want:    []string{"name"},
+		},
+		{
+			name:    "no-declaration",
+			content: `output type string = 'Microsoft.Web/sites@2022-03-01'`,
+			want:    nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			offset := strings.Index(tt.content, "'") + 1
+			offset += strings.Index(tt.content[offset:], "'")
+			if got := resourceProperties(tt.content, offset); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("resourceProperties() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
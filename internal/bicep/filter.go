@@ -0,0 +1,218 @@
+package bicep
+
+import (
+	"errors"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	// ignoreFiles are the files whose patterns are honoured when Filter.IgnoreFiles is set, in order of precedence (last wins).
+	ignoreFiles = []string{".gitignore", ".bruhignore"}
+)
+
+// Filter determines which files and directories ParseDirectory walks. The zero value walks every non-hidden directory:
+//   - Include: glob patterns of the files to parse (e.g. *.bicep or modules/**), all the supported files if empty
+//   - Exclude: glob patterns of the files and directories to skip (e.g. node_modules or samples/**/*.json)
+//   - IgnoreFiles: whether to skip the files and directories matched by .gitignore and .bruhignore files
+//   - Hidden: whether to walk hidden directories (e.g. .git or .terraform), which are skipped by default
+//
+// Patterns are matched against paths relative to the walked directory, with forward slashes.
+// As in .gitignore files, patterns without a slash match the name at any depth, and ** matches any number of directories.
+type Filter struct {
+	Include     []string
+	Exclude     []string
+	IgnoreFiles bool
+	Hidden      bool
+}
+
+// ignoreRule is a pattern of an ignore file, relative to the directory of the file (base).
+type ignoreRule struct {
+	base     string
+	pattern  string
+	negate   bool
+	dirOnly  bool
+	anchored bool
+}
+
+// matchSegments reports whether the path segments match the pattern segments, where ** matches zero or more segments.
+func matchSegments(pattern, name []string) bool {
+	if len(pattern) == 0 {
+		return len(name) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(name); i++ {
+			if matchSegments(pattern[1:], name[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(name) == 0 {
+		return false
+	}
+	if ok, err := path.Match(pattern[0], name[0]); err != nil || !ok {
+		return false
+	}
+	return matchSegments(pattern[1:], name[1:])
+}
+
+// matchGlob reports whether a slash-separated relative path matches a glob pattern.
+// Patterns without a slash (other than a trailing one) match the last element of the path, while the rest match the whole path.
+func matchGlob(pattern, rel string) bool {
+	pattern = strings.TrimSuffix(pattern, "/")
+	if !strings.Contains(pattern, "/") {
+		return matchSegments([]string{pattern}, []string{path.Base(rel)})
+	}
+	return matchSegments(strings.Split(strings.TrimPrefix(pattern, "/"), "/"), strings.Split(rel, "/"))
+}
+
+// parseIgnoreFile parses the content of an ignore file located in the base directory, relative to the walked directory.
+// Blank lines and comments are skipped, and the rest of the lines follow the .gitignore syntax (e.g. !keep.bicep, build/, /samples).
+func parseIgnoreFile(content, base string) []ignoreRule {
+	rules := []ignoreRule{}
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rule := ignoreRule{base: base}
+		if rest, ok := strings.CutPrefix(line, "!"); ok {
+			rule.negate = true
+			line = rest
+		}
+		line = strings.TrimPrefix(line, `\`)
+		if rest, ok := strings.CutSuffix(line, "/"); ok {
+			rule.dirOnly = true
+			line = rest
+		}
+		rule.anchored = strings.Contains(line, "/")
+		rule.pattern = strings.TrimPrefix(line, "/")
+		if rule.pattern != "" {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// readIgnoreFiles returns the rules of the ignore files of a directory, whose path relative to the walked directory is base,
+// reading them with the given function (e.g. os.ReadFile).
+func readIgnoreFiles(dir, base string, read ReadFileFunc) ([]ignoreRule, error) {
+	rules := []ignoreRule{}
+	for _, name := range ignoreFiles {
+		data, err := read(filepath.Join(dir, name))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, parseIgnoreFile(string(data), base)...)
+	}
+	return rules, nil
+}
+
+// ignored reports whether a path relative to the walked directory is ignored by the given rules, where the last matching rule wins.
+func ignored(rules []ignoreRule, rel string, isDir bool) bool {
+	result := false
+	for _, rule := range rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		sub := rel
+		if rule.base != "" {
+			var ok bool
+			if sub, ok = strings.CutPrefix(rel, rule.base+"/"); !ok {
+				continue
+			}
+		}
+		var match bool
+		if rule.anchored {
+			match = matchSegments(strings.Split(rule.pattern, "/"), strings.Split(sub, "/"))
+		} else {
+			match = matchGlob(rule.pattern, sub)
+		}
+		if match {
+			result = !rule.negate
+		}
+	}
+	return result
+}
+
+// skipDir reports whether a directory, whose path relative to the walked directory is rel, should be skipped along with its contents.
+func (f Filter) skipDir(rel string, rules []ignoreRule) bool {
+	if !f.Hidden && strings.HasPrefix(path.Base(rel), ".") {
+		return true
+	}
+	for _, pattern := range f.Exclude {
+		if matchGlob(pattern, rel) {
+			return true
+		}
+	}
+	return ignored(rules, rel, true)
+}
+
+// SkipDir reports whether ParseDirectory skips a directory, whose path relative to the walked directory is rel (with forward slashes),
+// regardless of ignore files. It lets callers walking the same tree (e.g. a file watcher) skip the same directories.
+func (f Filter) SkipDir(rel string) bool {
+	return f.skipDir(rel, nil)
+}
+
+// skipFile reports whether a file, whose path relative to the walked directory is rel, should be skipped.
+func (f Filter) skipFile(rel string, rules []ignoreRule) bool {
+	for _, pattern := range f.Exclude {
+		if matchGlob(pattern, rel) {
+			return true
+		}
+	}
+	if ignored(rules, rel, false) {
+		return true
+	}
+	if len(f.Include) == 0 {
+		return false
+	}
+	for _, pattern := range f.Include {
+		if matchGlob(pattern, rel) {
+			return false
+		}
+	}
+	return true
+}
+
+// parentRules returns the rules of the ignore files of the walked directory and of each parent directory of a file,
+// whose path relative to the walked directory is rel, in order of precedence. The rules of each directory are read once,
+// with the given function, and kept in the cache.
+func parentRules(dirPath, rel string, read ReadFileFunc, cache map[string][]ignoreRule) ([]ignoreRule, error) {
+	bases := []string{}
+	for dir := path.Dir(rel); dir != "."; dir = path.Dir(dir) {
+		bases = append([]string{dir}, bases...)
+	}
+	bases = append([]string{""}, bases...)
+
+	rules := []ignoreRule{}
+	for _, base := range bases {
+		dirRules, ok := cache[base]
+		if !ok {
+			var err error
+			if dirRules, err = readIgnoreFiles(filepath.Join(dirPath, filepath.FromSlash(base)), base, read); err != nil {
+				return nil, err
+			}
+			cache[base] = dirRules
+		}
+		rules = append(rules, dirRules...)
+	}
+	return rules, nil
+}
+
+// skipParents reports whether any parent directory of a file, whose path relative to the walked directory is rel, is skipped.
+// It is used when files are given directly instead of being found by walking the directory.
+func (f Filter) skipParents(rel string, rules []ignoreRule) bool {
+	for dir := path.Dir(rel); dir != "."; dir = path.Dir(dir) {
+		if f.skipDir(dir, rules) {
+			return true
+		}
+	}
+	return false
+}
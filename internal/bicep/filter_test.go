@@ -0,0 +1,156 @@
+package bicep
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func Test_matchGlob(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		rel     string
+		want    bool
+	}{
+		{name: "name-at-root", pattern: "*.bicep", rel: "main.bicep", want: true},
+		{name: "name-at-any-depth", pattern: "node_modules", rel: "app/node_modules", want: true},
+		{name: "name-mismatch", pattern: "*.bicep", rel: "modules/app.json", want: false},
+		{name: "anchored-path", pattern: "modules/*.bicep", rel: "modules/app.bicep", want: true},
+		{name: "anchored-path-nested", pattern: "modules/*.bicep", rel: "app/modules/app.bicep", want: false},
+		{name: "leading-slash", pattern: "/samples/*", rel: "samples/app.bicep", want: true},
+		{name: "double-star-prefix", pattern: "**/samples/*.bicep", rel: "a/b/samples/app.bicep", want: true},
+		{name: "double-star-zero-dirs", pattern: "modules/**/app.bicep", rel: "modules/app.bicep", want: true},
+		{name: "double-star-suffix", pattern: "modules/**", rel: "modules/network/vnet.bicep", want: true},
+		{name: "trailing-slash", pattern: "build/", rel: "src/build", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchGlob(tt.pattern, tt.rel); got != tt.want {
+				t.Errorf("matchGlob(%q, %q) = %v, want %v", tt.pattern, tt.rel, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilter_SkipDir(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter Filter
+		rel    string
+		want   bool
+	}{
+		{name: "hidden", filter: Filter{}, rel: "modules/.terraform", want: true},
+		{name: "hidden-walked", filter: Filter{Hidden: true}, rel: "modules/.terraform", want: false},
+		{name: "excluded", filter: Filter{Exclude: []string{"samples"}}, rel: "app/samples", want: true},
+		{name: "walked", filter: Filter{Exclude: []string{"samples"}}, rel: "app/modules", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.SkipDir(tt.rel); got != tt.want {
+				t.Errorf("SkipDir(%q) = %v, want %v", tt.rel, got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_ignored(t *testing.T) {
+	rules := parseIgnoreFile("# comment\n\nbuild/\n*.json\n!azuredeploy.json\n/samples\n", "")
+	rules = append(rules, parseIgnoreFile("legacy/*.bicep\r\n", "modules")...)
+	tests := []struct {
+		name  string
+		rel   string
+		isDir bool
+		want  bool
+	}{
+		{name: "directory-only-pattern", rel: "app/build", isDir: true, want: true},
+		{name: "directory-only-pattern-file", rel: "app/build", isDir: false, want: false},
+		{name: "name-pattern", rel: "modules/params.json", want: true},
+		{name: "negated-pattern", rel: "modules/azuredeploy.json", want: false},
+		{name: "anchored-pattern", rel: "samples", isDir: true, want: true},
+		{name: "anchored-pattern-nested", rel: "modules/samples", isDir: true, want: false},
+		{name: "nested-ignore-file", rel: "modules/legacy/app.bicep", want: true},
+		{name: "nested-ignore-file-outside-base", rel: "legacy/app.bicep", want: false},
+		{name: "not-ignored", rel: "main.bicep", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ignored(rules, tt.rel, tt.isDir); got != tt.want {
+				t.Errorf("ignored(%q) = %v, want %v", tt.rel, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDirectoryFilter(t *testing.T) {
+	resource := "resource rg 'Microsoft.Resources/resourceGroups@2022-09-01' = {\n  name: 'rg'\n}\n"
+	files := map[string]string{
+		"main.bicep":                          resource,
+		"modules/app.bicep":                   resource,
+		"modules/legacy/old.bicep":            resource,
+		"samples/sample.bicep":                resource,
+		"node_modules/pkg/module.bicep":       resource,
+		".git/hooks/hook.bicep":               resource,
+		".gitignore":                          "node_modules/\n",
+		"modules/.bruhignore":                 "legacy/\n",
+		"modules/parameters.json":             "{}",
+		"samples/azuredeploy.parameters.json": "{}",
+	}
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   []string
+	}{
+		{
+			name:   "default",
+			filter: Filter{},
+			want:   []string{"main.bicep", "modules/app.bicep", "modules/legacy/old.bicep", "node_modules/pkg/module.bicep", "samples/sample.bicep"},
+		},
+		{
+			name:   "hidden",
+			filter: Filter{Hidden: true},
+			want:   []string{".git/hooks/hook.bicep", "main.bicep", "modules/app.bicep", "modules/legacy/old.bicep", "node_modules/pkg/module.bicep", "samples/sample.bicep"},
+		},
+		{
+			name:   "ignore-files",
+			filter: Filter{IgnoreFiles: true},
+			want:   []string{"main.bicep", "modules/app.bicep", "samples/sample.bicep"},
+		},
+		{
+			name:   "include-exclude",
+			filter: Filter{Include: []string{"modules/**", "samples/*"}, Exclude: []string{"legacy"}},
+			want:   []string{"modules/app.bicep", "samples/sample.bicep"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDirectory(dir, tt.filter)
+			if err != nil {
+				t.Fatalf("ParseDirectory() error = %v", err)
+			}
+			paths := []string{}
+			for _, file := range got.Files {
+				rel, err := filepath.Rel(dir, file.Path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				paths = append(paths, filepath.ToSlash(rel))
+			}
+			if !reflect.DeepEqual(paths, tt.want) {
+				t.Errorf("ParseDirectory() files = %v, want %v", paths, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package bicep
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/christosgalano/bruh/internal/types"
+)
+
+var (
+	// ErrModuleCycle is returned when the local module references or imports of a Bicep file form a cycle.
+	ErrModuleCycle = errors.New("module cycle detected")
+
+	// localModuleRegex is the regex used to match module declarations referencing a local file (e.g. module app './modules/app.bicep' = {).
+	// References to registries and template specs (e.g. br/public:avm/res/storage/storage-account:0.4.0) contain a colon, so they are not matched.
+	localModuleRegex = regexp.MustCompile(`module\s+[A-Za-z_][A-Za-z0-9_]*\s+'([^':]+)'`)
+
+	// importRegex is the regex used to match compile-time imports (e.g. import { tags } from './shared.bicep' or import * as shared from 'shared.bicep').
+	importRegex = regexp.MustCompile(`import\s+(?:\{[^}]*\}|\*\s+as\s+[A-Za-z_][A-Za-z0-9_]*)\s+from\s+'([^':]+)'`)
+)
+
+// localReferences returns the paths of the local files referenced by the modules and imports of a Bicep file, in order of appearance.
+// The paths are relative to the directory of the file and use forward slashes, as written in the file.
+func localReferences(content string) []string {
+	type reference struct {
+		path   string
+		offset int
+	}
+	references := []reference{}
+	for _, regex := range []*regexp.Regexp{localModuleRegex, importRegex} {
+		for _, match := range regex.FindAllStringSubmatchIndex(content, -1) {
+			references = append(references, reference{path: content[match[2]:match[3]], offset: match[0]})
+		}
+	}
+	sort.Slice(references, func(i, j int) bool {
+		return references[i].offset < references[j].offset
+	})
+
+	paths := make([]string, 0, len(references))
+	for _, ref := range references {
+		paths = append(paths, ref.path)
+	}
+	return paths
+}
+
+// fileReferences returns the paths of the local files referenced by the modules and imports of a Bicep file, relative to the working directory.
+func fileReferences(filePath, content string) []string {
+	var paths []string
+	for _, ref := range localReferences(content) {
+		paths = append(paths, filepath.Join(filepath.Dir(filePath), filepath.FromSlash(ref)))
+	}
+	return paths
+}
+
+// parseReachable parses a file and then the files it references, appending them to the directory in depth-first order.
+// chain contains the files through which the file is reached, starting with the entry file, and visited the files already parsed.
+func parseReachable(filePath string, chain []string, visited map[string]bool, bicepDir *types.BicepDirectory) error {
+	for i, path := range chain {
+		if path == filePath {
+			return fmt.Errorf("%w: %s", ErrModuleCycle, strings.Join(append(chain[i:len(chain):len(chain)], filePath), " -> "))
+		}
+	}
+	if visited[filePath] {
+		return nil
+	}
+	visited[filePath] = true
+
+	file, err := ParseFile(filePath)
+	if err != nil {
+		if len(chain) > 0 {
+			return fmt.Errorf("%w (referenced by %s)", err, chain[len(chain)-1])
+		}
+		return err
+	}
+	if len(chain) > 0 {
+		file.Chain = append([]string{}, chain...)
+	}
+	bicepDir.Files = append(bicepDir.Files, *file)
+
+	chain = append(chain[:len(chain):len(chain)], filePath)
+	for _, next := range file.References {
+		if err := parseReachable(next, chain, visited, bicepDir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ParseEntry parses an entry Bicep file (e.g. main.bicep) along with the files reachable from it through local module references
+// and compile-time imports, and returns a pointer to a BicepDirectory object whose path is the directory of the entry file.
+// The files are returned in depth-first order, each one with the chain of files through which it is first reached.
+// Files reachable through multiple chains are returned only once, while a cycle returns an error wrapping ErrModuleCycle.
+func ParseEntry(entryPath string) (*types.BicepDirectory, error) {
+	entryPath = filepath.Clean(entryPath)
+	if ext := filepath.Ext(entryPath); ext != ".bicep" {
+		return nil, fmt.Errorf("%w %q", types.ErrInvalidExtension, ext)
+	}
+
+	bicepDir := types.BicepDirectory{
+		Path: filepath.Dir(entryPath),
+	}
+	if err := parseReachable(entryPath, nil, map[string]bool{}, &bicepDir); err != nil {
+		return nil, err
+	}
+	return &bicepDir, nil
+}
@@ -0,0 +1,134 @@
+package bicep
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_localReferences(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{
+			name: "modules-and-imports",
+			content: "import { tags } from 'shared/types.bicep'\n" +
+				"module network './modules/network.bicep' = {\n  name: 'network'\n}\n" +
+				"import * as shared from '../shared.bicep'\n" +
+				"module plan 'plan.json' = [for i in range(0, 2): {\n  name: 'plan${i}'\n}]\n",
+			want: []string{"shared/types.bicep", "./modules/network.bicep", "../shared.bicep", "plan.json"},
+		},
+		{
+			name: "registry-and-template-spec",
+			content: "module storage 'br/public:avm/res/storage/storage-account:0.4.0' = {\n  name: 'storage'\n}\n" +
+				"module app 'br:contoso.azurecr.io/bicep/modules/app:1.0.0' = {\n  name: 'app'\n}\n" +
+				"module spec 'ts:00000000-0000-0000-0000-000000000000/rg/spec:1.0' = {\n  name: 'spec'\n}\n",
+			want: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := localReferences(tt.content); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("localReferences() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseEntry(t *testing.T) {
+	type file struct {
+		path      string
+		resources []string
+		chain     []string
+	}
+	main := filepath.Join("testdata", "entry", "main.bicep")
+	app := filepath.Join("testdata", "entry", "modules", "app.bicep")
+	tests := []struct {
+		name    string
+		entry   string
+		want    []file
+		wantErr error
+	}{
+		{
+			name:  "reachable-files",
+			entry: "testdata/entry/main.bicep",
+			want: []file{
+				{
+					path:      main,
+					resources: []string{"Microsoft.Resources/resourceGroups", "br/public:avm/res/storage/storage-account"},
+				},
+				{
+					path:      filepath.Join("testdata", "entry", "shared", "types.bicep"),
+					resources: []string{},
+					chain:     []string{main},
+				},
+				{
+					path:      filepath.Join("testdata", "entry", "modules", "network.bicep"),
+					resources: []string{"Microsoft.Network/virtualNetworks"},
+					chain:     []string{main},
+				},
+				{
+					path:      app,
+					resources: []string{"Microsoft.Web/sites"},
+					chain:     []string{main},
+				},
+				{
+					path:      filepath.Join("testdata", "entry", "modules", "plan.json"),
+					resources: []string{"Microsoft.Web/serverfarms"},
+					chain:     []string{main, app},
+				},
+			},
+		},
+		{
+			name:    "cycle",
+			entry:   "testdata/cycle/main.bicep",
+			wantErr: ErrModuleCycle,
+		},
+		{
+			name:    "missing-module",
+			entry:   "testdata/entry/missing.bicep",
+			wantErr: errors.New("file does not exist"),
+		},
+		{
+			name:    "invalid-extension",
+			entry:   "testdata/entry/modules/plan.json",
+			wantErr: errors.New("invalid file extension"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseEntry(tt.entry)
+			if tt.wantErr != nil {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr.Error()) {
+					t.Fatalf("ParseEntry() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				if tt.wantErr == ErrModuleCycle && !errors.Is(err, ErrModuleCycle) {
+					t.Fatalf("ParseEntry() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseEntry() error = %v", err)
+			}
+			if got.Path != filepath.Join("testdata", "entry") {
+				t.Errorf("ParseEntry() path = %v, want %v", got.Path, filepath.Join("testdata", "entry"))
+			}
+			if len(got.Files) != len(tt.want) {
+				t.Fatalf("ParseEntry() files = %v, want %v", len(got.Files), len(tt.want))
+			}
+			for i, want := range tt.want {
+				resources := []string{}
+				for _, resource := range got.Files[i].Resources {
+					resources = append(resources, resource.ID)
+				}
+				if got.Files[i].Path != want.path || !reflect.DeepEqual(resources, want.resources) || !reflect.DeepEqual(got.Files[i].Chain, want.chain) {
+					t.Errorf("ParseEntry() file = %v %v %v, want %v %v %v", got.Files[i].Path, resources, got.Files[i].Chain, want.path, want.resources, want.chain)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package bicep
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/christosgalano/bruh/internal/types"
+)
+
+const (
+	// configFile is the name of the Bicep configuration file, which defines the module aliases.
+	configFile = "bicepconfig.json"
+
+	// publicRegistry and publicModulePath are the registry and module path of the built-in "public" alias.
+	publicRegistry   = "mcr.microsoft.com"
+	publicModulePath = "bicep"
+)
+
+var (
+	// moduleRegex is the regex used to match module declarations referencing a registry,
+	// either directly (e.g. br:myacr.azurecr.io/bicep/modules/storage:1.2.0) or through an alias (e.g. br/public:avm/res/storage/storage-account:0.4.0).
+	moduleRegex = regexp.MustCompile(`module\s+[A-Za-z_][A-Za-z0-9_]*\s+'(br[:/][^'@]+):([^':@]+)'`)
+)
+
+// moduleAlias is an alias of a registry, optionally along with a path prefix of the modules in it.
+type moduleAlias struct {
+	Registry   string `json:"registry"`
+	ModulePath string `json:"modulePath"`
+}
+
+// moduleReference is a reference to a module of a registry, along with the offsets of its tag.
+type moduleReference struct {
+	reference string
+	tag       string
+	start     int
+	end       int
+}
+
+// moduleReferences returns the registry module references of a Bicep file, in order of appearance.
+func moduleReferences(content string) []moduleReference {
+	references := []moduleReference{}
+	for _, match := range moduleRegex.FindAllStringSubmatchIndex(content, -1) {
+		references = append(references, moduleReference{
+			reference: content[match[2]:match[3]],
+			tag:       content[match[4]:match[5]],
+			start:     match[4],
+			end:       match[5],
+		})
+	}
+	return references
+}
+
+// moduleAliases returns the registry module aliases defined in the bicepconfig.json file closest to the given directory,
+// including the built-in "public" alias unless it is overridden. The configuration files are read with the given function.
+func moduleAliases(dir string, read ReadFileFunc) (map[string]moduleAlias, error) {
+	aliases := map[string]moduleAlias{
+		"public": {Registry: publicRegistry, ModulePath: publicModulePath},
+	}
+
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		path := filepath.Join(dir, configFile)
+		data, err := read(filepath.Clean(path))
+		if err == nil {
+			var config struct {
+				ModuleAliases struct {
+					Br map[string]moduleAlias `json:"br"`
+				} `json:"moduleAliases"`
+			}
+			if err := json.Unmarshal(data, &config); err != nil {
+				return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+			}
+			for name, alias := range config.ModuleAliases.Br {
+				aliases[name] = alias
+			}
+			return aliases, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return aliases, nil
+		}
+		dir = parent
+	}
+}
+
+// resolveModule returns the registry and repository of a module reference without its tag
+// (e.g. mcr.microsoft.com and bicep/avm/res/storage/storage-account for br/public:avm/res/storage/storage-account).
+func resolveModule(reference string, aliases map[string]moduleAlias) (string, string, error) {
+	if rest, ok := strings.CutPrefix(reference, "br:"); ok {
+		registry, repository, found := strings.Cut(rest, "/")
+		if !found {
+			return "", "", fmt.Errorf("invalid module reference %q", reference)
+		}
+		return registry, repository, nil
+	}
+
+	name, path, found := strings.Cut(strings.TrimPrefix(reference, "br/"), ":")
+	if !found {
+		return "", "", fmt.Errorf("invalid module reference %q", reference)
+	}
+	alias, ok := aliases[name]
+	if !ok {
+		return "", "", fmt.Errorf("unknown module alias %q in module reference %q", name, reference)
+	}
+	if alias.ModulePath != "" {
+		path = strings.Trim(alias.ModulePath, "/") + "/" + path
+	}
+	return alias.Registry, path, nil
+}
+
+// moduleResources returns a resource for each registry module reference of a Bicep file,
+// resolving the aliases defined in the closest bicepconfig.json file, which is read with the given function.
+func moduleResources(filePath, content string, read ReadFileFunc) ([]types.Resource, error) {
+	references := moduleReferences(content)
+	if len(references) == 0 {
+		return nil, nil
+	}
+
+	aliases, err := moduleAliases(filepath.Dir(filePath), read)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := make([]types.Resource, 0, len(references))
+	for _, ref := range references {
+		registry, repository, err := resolveModule(ref.reference, aliases)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filePath, err)
+		}
+		resources = append(resources, types.Resource{
+			ID:                ref.reference,
+			Name:              repository,
+			Namespace:         registry,
+			CurrentAPIVersion: ref.tag,
+			Module:            true,
+			Line:              types.LineAt(content, ref.start),
+		})
+	}
+	return resources, nil
+}
@@ -0,0 +1,177 @@
+package bicep
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/christosgalano/bruh/internal/types"
+)
+
+func Test_moduleAliases(t *testing.T) {
+	tests := []struct {
+		name    string
+		dir     string
+		want    map[string]moduleAlias
+		wantErr bool
+	}{
+		{
+			name: "closest-config",
+			dir:  "testdata/registry/nested",
+			want: map[string]moduleAlias{
+				"public": {Registry: "mcr.microsoft.com", ModulePath: "bicep"},
+				"shared": {Registry: "contoso.azurecr.io", ModulePath: "bicep/modules"},
+			},
+		},
+		{
+			name: "no-config",
+			dir:  t.TempDir(),
+			want: map[string]moduleAlias{
+				"public": {Registry: "mcr.microsoft.com", ModulePath: "bicep"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := moduleAliases(tt.dir, os.ReadFile)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("moduleAliases() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("moduleAliases() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_resolveModule(t *testing.T) {
+	aliases := map[string]moduleAlias{
+		"public": {Registry: "mcr.microsoft.com", ModulePath: "bicep"},
+		"acr":    {Registry: "contoso.azurecr.io"},
+	}
+	tests := []struct {
+		name           string
+		reference      string
+		wantRegistry   string
+		wantRepository string
+		wantErr        bool
+	}{
+		{
+			name:           "direct",
+			reference:      "br:contoso.azurecr.io/bicep/modules/storage",
+			wantRegistry:   "contoso.azurecr.io",
+			wantRepository: "bicep/modules/storage",
+		},
+		{
+			name:           "alias-with-module-path",
+			reference:      "br/public:avm/res/storage/storage-account",
+			wantRegistry:   "mcr.microsoft.com",
+			wantRepository: "bicep/avm/res/storage/storage-account",
+		},
+		{
+			name:           "alias-without-module-path",
+			reference:      "br/acr:modules/storage",
+			wantRegistry:   "contoso.azurecr.io",
+			wantRepository: "modules/storage",
+		},
+		{
+			name:      "unknown-alias",
+			reference: "br/missing:modules/storage",
+			wantErr:   true,
+		},
+		{
+			name:      "missing-repository",
+			reference: "br:contoso.azurecr.io",
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry, repository, err := resolveModule(tt.reference, aliases)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolveModule() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if registry != tt.wantRegistry || repository != tt.wantRepository {
+				t.Errorf("resolveModule() = %v, %v, want %v, %v", registry, repository, tt.wantRegistry, tt.wantRepository)
+			}
+		})
+	}
+}
+
+func TestParseFileModules(t *testing.T) {
+	bicepFile, err := ParseFile("testdata/registry/nested/main.bicep")
+	if err != nil {
+		t.Fatalf("ParseFile() error = %v", err)
+	}
+	want := []types.Resource{
+		{
+			ID:                "br/public:avm/res/storage/storage-account",
+			Name:              "bicep/avm/res/storage/storage-account",
+			Namespace:         "mcr.microsoft.com",
+			CurrentAPIVersion: "0.4.0",
+			Line:              3,
+			Module:            true,
+		},
+		{
+			ID:                "br/shared:network/vnet",
+			Name:              "bicep/modules/network/vnet",
+			Namespace:         "contoso.azurecr.io",
+			CurrentAPIVersion: "1.2.0",
+			Line:              11,
+			Module:            true,
+		},
+		{
+			ID:                "br:contoso.azurecr.io/bicep/modules/identity",
+			Name:              "bicep/modules/identity",
+			Namespace:         "contoso.azurecr.io",
+			CurrentAPIVersion: "v1.0.0",
+			Line:              15,
+			Module:            true,
+		},
+	}
+	if !reflect.DeepEqual(bicepFile.Resources, want) {
+		t.Errorf("ParseFile() = %v, want %v", bicepFile.Resources, want)
+	}
+}
+
+func TestUpdateFileModules(t *testing.T) {
+	data, err := os.ReadFile("testdata/registry/nested/main.bicep")
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := string(data)
+	config, err := os.ReadFile("testdata/registry/bicepconfig.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.bicep")
+	if err := os.WriteFile(filepath.Join(dir, configFile), config, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	bicepFile, err := ParseFile(path)
+	if err != nil {
+		t.Fatalf("ParseFile() error = %v", err)
+	}
+	bicepFile.Resources[0].AvailableAPIVersions = []string{"0.9.0", "0.4.0"}
+	bicepFile.Resources[1].Unknown = true
+	bicepFile.Resources[2].AvailableAPIVersions = []string{"v1.1.0", "v1.0.0"}
+	if err := UpdateFile(bicepFile, true); err != nil {
+		t.Fatalf("UpdateFile() error = %v", err)
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := strings.NewReplacer("storage-account:0.4.0", "storage-account:0.9.0", "identity:v1.0.0", "identity:v1.1.0").Replace(content)
+	if string(got) != want {
+		t.Errorf("UpdateFile() content =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -3,6 +3,9 @@ Package bicep provides a set of functions to manipulate Bicep files and director
 
 It offers methods for parsing directories and files to extract valuable information regarding resource metadata, such as name and API version.
 The two main functions are ParseDirectory and ParseFile, which receive a directory or file path, and return a pointer to a BicepDirectory or BicepFile object.
+ARM JSON templates (.json) are parsed as well through the arm package, while other JSON files (e.g. parameters files) are ignored by ParseDirectory.
+Terraform files (.tf) are parsed through the terraform package for azapi resources, while those without any are ignored by ParseDirectory.
+ParseEntry parses only the files reachable from an entry Bicep file through local module references and compile-time imports.
 
 The package also includes functions to update the API versions of existing Bicep files in place or create new ones.
 This can be done by calling UpdateDirectory or UpdateFile, which receive a pointer to a BicepDirectory or BicepFile object.
@@ -16,9 +19,12 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
-	"sync"
 
+	"github.com/christosgalano/bruh/internal/arm"
+	"github.com/christosgalano/bruh/internal/functions"
+	"github.com/christosgalano/bruh/internal/terraform"
 	"github.com/christosgalano/bruh/internal/types"
 )
 
@@ -27,103 +33,204 @@ const (
 	pattern = `(?P<namespace>Microsoft\.[a-zA-Z]+)/(?P<resource>[a-zA-Z]+)@(?P<version>[0-9]{4}-[0-9]{2}-[0-9]{2}-preview|[0-9]{4}-[0-9]{2}-[0-9]{2})`
 )
 
+// ReadFileFunc reads the content of a file, either from the file system (os.ReadFile) or from elsewhere (e.g. a git revision).
+// If the file does not exist, the returned error must wrap fs.ErrNotExist.
+type ReadFileFunc func(path string) ([]byte, error)
+
 var (
-	// cache is a synchronized map used to store the contents of Bicep files
-	cache sync.Map
+	// declarationRegex is the regex used to match the types and API versions of resource declarations
+	declarationRegex = regexp.MustCompile(pattern)
+
+	// symbolRegex is the regex used to match the symbolic names and types of resource declarations
+	symbolRegex = regexp.MustCompile(`resource\s+([A-Za-z_][A-Za-z0-9_]*)\s+'([^'@]+)@`)
 )
 
 // readBicepFile reads a Bicep file and returns its contents as a byte slice.
-// If the file does not exist, is a directory, or does not have the .bicep extension, the function returns an error.
+// If the file does not exist, is a directory, or does not have the .bicep extension, the function returns an error
+// wrapping types.ErrFileNotExist, types.ErrIsDirectory or types.ErrInvalidExtension respectively.
 func readBicepFile(filePath string) ([]byte, error) {
 	f, err := os.Stat(filePath)
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
-			return nil, fmt.Errorf("file does not exist %q", filePath)
+			return nil, fmt.Errorf("%w %q", types.ErrFileNotExist, filePath)
 		}
 		return nil, err
 	}
 
 	if f.IsDir() {
-		return nil, fmt.Errorf("given path is a directory %q", filePath)
+		return nil, fmt.Errorf("%w %q", types.ErrIsDirectory, filePath)
 	}
 
 	if ext := filepath.Ext(filePath); ext != ".bicep" {
-		return nil, fmt.Errorf("invalid file extension %q", ext)
+		return nil, fmt.Errorf("%w %q", types.ErrInvalidExtension, ext)
 	}
 
-	// Check if the file is already cached
-	if data, ok := cache.Load(filePath); ok {
-		if bytesData, isBytes := data.([]byte); isBytes {
-			return bytesData, nil
-		}
-		// Handle the case where the type assertion fails
-		return nil, fmt.Errorf("unexpected type in cache for %s", filePath)
-	}
+	// The file is read every time, as it may have changed since it was parsed (e.g. in watch mode)
+	return os.ReadFile(filepath.Clean(filePath))
+}
 
-	// File is not cached, read it
-	filePath = filepath.Clean(filePath)
-	data, err := os.ReadFile(filePath)
-	if err != nil {
-		return nil, err
+// symbols returns the resource types of the symbolic names declared in a Bicep file.
+func symbols(content string) map[string]string {
+	result := map[string]string{}
+	for _, match := range symbolRegex.FindAllStringSubmatch(content, -1) {
+		result[match[1]] = match[2]
 	}
+	return result
+}
 
-	// Cache the file content
-	cache.Store(filePath, data)
-
-	return data, nil
+// resourceCalls returns the calls of template functions with a hard-coded API version and an inferred type (e.g. listKeys(storage.id, '2021-04-01')).
+func resourceCalls(content string) []functions.Call {
+	calls := []functions.Call{}
+	for _, call := range functions.Find(content, symbols(content)) {
+		if call.Type != "" {
+			calls = append(calls, call)
+		}
+	}
+	return calls
 }
 
 // ParseFile parses a file and returns a pointer to a BicepFile object.
+// Files with the .json extension are parsed as ARM templates, and files with the .tf extension as Terraform files.
+// API versions passed to functions such as reference and listKeys are returned as resources of that function, after the declared ones,
+// followed by the registry module references, whose versions are tags. The local files referenced by modules and imports are returned as well.
 func ParseFile(filePath string) (*types.BicepFile, error) {
+	return parseFile(filePath, os.ReadFile)
+}
+
+// parseFile parses a file like ParseFile does, using the read function to look up bicepconfig.json files.
+func parseFile(filePath string, read ReadFileFunc) (*types.BicepFile, error) {
+	switch filepath.Ext(filePath) {
+	case ".json":
+		return arm.ParseFile(filePath)
+	case ".tf":
+		return terraform.ParseFile(filePath)
+	}
 
 	data, err := readBicepFile(filePath)
 	if err != nil {
 		return nil, err
 	}
-	content := string(data)
+	return parseBicep(filePath, string(data), read)
+}
 
-	regex, err := regexp.Compile(pattern)
-	if err != nil {
-		return nil, err
+// ParseContent parses the content of a file read from elsewhere than the file system (e.g. a git revision) like ParseFile does,
+// and returns a pointer to a BicepFile object whose path is the given one.
+// The read function is used to look up the bicepconfig.json files that define the aliases of registry module references.
+// If the path does not have a supported extension, the function returns an error wrapping types.ErrInvalidExtension.
+func ParseContent(filePath string, content []byte, read ReadFileFunc) (*types.BicepFile, error) {
+	switch ext := filepath.Ext(filePath); ext {
+	case ".json":
+		return arm.ParseContent(filePath, string(content))
+	case ".tf":
+		return terraform.ParseContent(filePath, string(content))
+	case ".bicep":
+		return parseBicep(filePath, string(content), read)
+	default:
+		return nil, fmt.Errorf("%w %q", types.ErrInvalidExtension, ext)
 	}
+}
 
+// parseBicep parses the content of a Bicep file, using the read function to look up bicepconfig.json files.
+func parseBicep(filePath, content string, read ReadFileFunc) (*types.BicepFile, error) {
 	results := []types.Resource{}
 
-	matches := regex.FindAllStringSubmatch(content, -1)
+	matches := declarationRegex.FindAllStringSubmatchIndex(content, -1)
 	for _, match := range matches {
+		namespace, name, version := content[match[2]:match[3]], content[match[4]:match[5]], content[match[6]:match[7]]
 		results = append(results, types.Resource{
-			ID:                match[1] + "/" + match[2],
-			Name:              match[2],
-			Namespace:         match[1],
-			CurrentAPIVersion: match[3],
+			ID:                namespace + "/" + name,
+			Name:              name,
+			Namespace:         namespace,
+			CurrentAPIVersion: version,
+			Properties:        resourceProperties(content, match[1]),
+			Line:              types.LineAt(content, match[6]),
 		})
 	}
 
+	for _, call := range resourceCalls(content) {
+		resource := call.Resource()
+		resource.Line = types.LineAt(content, call.Start)
+		results = append(results, resource)
+	}
+
+	modules, err := moduleResources(filePath, content, read)
+	if err != nil {
+		return nil, err
+	}
+	results = append(results, modules...)
+
 	bicepFile := types.BicepFile{
-		Path:      filePath,
-		Resources: results,
+		Path:       filePath,
+		Resources:  results,
+		References: fileReferences(filePath, content),
 	}
 
 	return &bicepFile, nil
 }
 
+// supported filters the result of parsing a file found in a directory, returning nil for the files that are not scanned:
+// directories (e.g. symbolic links), files with invalid extensions, JSON files that are not ARM templates and Terraform files without azapi resources.
+func supported(file *types.BicepFile, err error) (*types.BicepFile, error) {
+	if err != nil {
+		if errors.Is(err, types.ErrIsDirectory) || errors.Is(err, types.ErrInvalidExtension) || errors.Is(err, arm.ErrNotTemplate) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if filepath.Ext(file.Path) == ".tf" && len(file.Resources) == 0 {
+		return nil, nil
+	}
+	return file, nil
+}
+
 // ParseDirectory parses a directory and returns a pointer to a BicepDirectory object.
-func ParseDirectory(dirPath string) (*types.BicepDirectory, error) {
+// The filter determines which files and directories are walked: by default, every supported file outside hidden directories is parsed.
+func ParseDirectory(dirPath string, filter Filter) (*types.BicepDirectory, error) {
+	return ParseDirectoryFunc(dirPath, filter, os.ReadFile)
+}
+
+// ParseDirectoryFunc parses a directory like ParseDirectory does, but looks up the bicepconfig.json files that define the aliases
+// of registry module references with the given read function (e.g. one refusing the files outside the directory).
+func ParseDirectoryFunc(dirPath string, filter Filter, read ReadFileFunc) (*types.BicepDirectory, error) {
 	bicepDir := types.BicepDirectory{
 		Path: dirPath,
 	}
 
-	err := filepath.WalkDir(dirPath, func(path string, _ fs.DirEntry, err error) error {
+	rules := []ignoreRule{}
+	err := filepath.WalkDir(dirPath, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
 
-		file, err := ParseFile(path)
+		rel, err := filepath.Rel(dirPath, path)
 		if err != nil {
-			// Ignore directories and files with invalid extensions
-			if strings.Contains(err.Error(), "given path is a directory") || strings.Contains(err.Error(), "invalid file extension") {
-				return nil
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if d.IsDir() {
+			if rel != "." && filter.skipDir(rel, rules) {
+				return filepath.SkipDir
 			}
+			if filter.IgnoreFiles {
+				base := rel
+				if base == "." {
+					base = ""
+				}
+				dirRules, err := readIgnoreFiles(path, base, os.ReadFile)
+				if err != nil {
+					return err
+				}
+				rules = append(rules, dirRules...)
+			}
+			return nil
+		}
+		if filter.skipFile(rel, rules) {
+			return nil
+		}
+
+		file, err := supported(parseFile(path, read))
+		if err != nil || file == nil {
 			return err
 		}
 		bicepDir.Files = append(bicepDir.Files, *file)
@@ -137,3 +244,61 @@ func ParseDirectory(dirPath string) (*types.BicepDirectory, error) {
 
 	return &bicepDir, nil
 }
+
+// ParseFiles parses the given files of a directory (e.g. the files changed in a pull request) and returns a pointer to a BicepDirectory object.
+// Files outside the directory, in directories or matching patterns skipped by the filter, and files that are not scanned by ParseDirectory are ignored.
+// The files are read with the given function, so that they can come from the file system (os.ReadFile) or from a git revision,
+// and so are the ignore files of the directory and of the parent directories of each file within it, if the filter honours them.
+func ParseFiles(dirPath string, filePaths []string, filter Filter, read ReadFileFunc) (*types.BicepDirectory, error) {
+	bicepDir := types.BicepDirectory{
+		Path: dirPath,
+	}
+
+	absDir, err := filepath.Abs(dirPath)
+	if err != nil {
+		return nil, err
+	}
+	rels := []string{}
+	for _, filePath := range filePaths {
+		absPath, err := filepath.Abs(filePath)
+		if err != nil {
+			return nil, err
+		}
+		rel, err := filepath.Rel(absDir, absPath)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		rels = append(rels, filepath.ToSlash(rel))
+	}
+	sort.Strings(rels)
+
+	cache := map[string][]ignoreRule{}
+	for _, rel := range rels {
+		var rules []ignoreRule
+		if filter.IgnoreFiles {
+			if rules, err = parentRules(dirPath, rel, read, cache); err != nil {
+				return nil, err
+			}
+		}
+		if filter.skipFile(rel, rules) || filter.skipParents(rel, rules) {
+			continue
+		}
+		filePath := filepath.Join(dirPath, filepath.FromSlash(rel))
+		if ext := filepath.Ext(filePath); ext != ".bicep" && ext != ".json" && ext != ".tf" {
+			continue
+		}
+		data, err := read(filePath)
+		if err != nil {
+			return nil, err
+		}
+		file, err := supported(ParseContent(filePath, data, read))
+		if err != nil {
+			return nil, err
+		}
+		if file != nil {
+			bicepDir.Files = append(bicepDir.Files, *file)
+		}
+	}
+
+	return &bicepDir, nil
+}
@@ -1,6 +1,8 @@
 package bicep
 
 import (
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 
@@ -60,13 +62,16 @@ func TestParseFile(t *testing.T) {
 			name: "testdata/parse/azure.deploy.bicep",
 			args: args{"testdata/parse/azure.deploy.bicep"},
 			want: types.BicepFile{
-				Path: "testdata/parse/azure.deploy.bicep",
+				Path:       "testdata/parse/azure.deploy.bicep",
+				References: []string{filepath.Join("testdata", "parse", "modules", "compute.bicep"), filepath.Join("testdata", "parse", "modules", "identity.bicep")},
 				Resources: []types.Resource{
 					{
 						ID:                "Microsoft.Resources/resourceGroups",
 						Name:              "resourceGroups",
 						Namespace:         "Microsoft.Resources",
 						CurrentAPIVersion: "2021-01-01",
+						Line:              33,
+						Properties:        []string{"name", "location", "tags"},
 					},
 				},
 			},
@@ -83,12 +88,38 @@ func TestParseFile(t *testing.T) {
 						Name:              "serverfarms",
 						Namespace:         "Microsoft.Web",
 						CurrentAPIVersion: "2021-01-15",
+						Line:              41,
+						Properties:        []string{"name", "location", "kind", "sku", "sku.tier", "sku.name", "sku.capacity", "properties", "properties.reserved"},
 					},
 					{
 						ID:                "Microsoft.Web/sites",
 						Name:              "sites",
 						Namespace:         "Microsoft.Web",
 						CurrentAPIVersion: "2019-08-01",
+						Line:              55,
+						Properties: []string{
+							"name", "location", "properties", "properties.siteConfig",
+							"properties.siteConfig.alwaysOn", "properties.siteConfig.minTlsVersion", "properties.siteConfig.linuxFxVersion", "properties.siteConfig.healthCheckPath",
+							"properties.httpsOnly", "properties.serverFarmId", "properties.publicNetworkAccess",
+						},
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "storage.json",
+			args: args{"testdata/parse/modules/storage.json"},
+			want: types.BicepFile{
+				Path: "testdata/parse/modules/storage.json",
+				Resources: []types.Resource{
+					{
+						ID:                "Microsoft.Storage/storageAccounts",
+						Name:              "storageAccounts",
+						Namespace:         "Microsoft.Storage",
+						CurrentAPIVersion: "2021-09-01",
+						Line:              7,
+						Properties:        []string{"name", "location", "kind"},
 					},
 				},
 			},
@@ -121,6 +152,9 @@ func TestParseFile(t *testing.T) {
 			if !reflect.DeepEqual(got.Resources, tt.want.Resources) {
 				t.Errorf("\nParseFile() = %v, want %v", got.Resources, tt.want.Resources)
 			}
+			if !reflect.DeepEqual(got.References, tt.want.References) {
+				t.Errorf("\nParseFile() references = %v, want %v", got.References, tt.want.References)
+			}
 		})
 	}
 }
@@ -149,6 +183,21 @@ func TestParseDirectory(t *testing.T) {
 								Name:              "resourceGroups",
 								Namespace:         "Microsoft.Resources",
 								CurrentAPIVersion: "2021-01-01",
+								Line:              33,
+								Properties:        []string{"name", "location", "tags"},
+							},
+						},
+					},
+					{
+						Path: "testdata/parse/modules/app.tf",
+						Resources: []types.Resource{
+							{
+								ID:                "Microsoft.App/containerApps",
+								Name:              "containerApps",
+								Namespace:         "Microsoft.App",
+								CurrentAPIVersion: "2023-05-01",
+								Line:              2,
+								Properties:        []string{"name", "location"},
 							},
 						},
 					},
@@ -160,12 +209,20 @@ func TestParseDirectory(t *testing.T) {
 								Name:              "serverfarms",
 								Namespace:         "Microsoft.Web",
 								CurrentAPIVersion: "2021-01-15",
+								Line:              41,
+								Properties:        []string{"name", "location", "kind", "sku", "sku.tier", "sku.name", "sku.capacity", "properties", "properties.reserved"},
 							},
 							{
 								ID:                "Microsoft.Web/sites",
 								Name:              "sites",
 								Namespace:         "Microsoft.Web",
 								CurrentAPIVersion: "2019-08-01",
+								Line:              55,
+								Properties: []string{
+									"name", "location", "properties", "properties.siteConfig",
+									"properties.siteConfig.alwaysOn", "properties.siteConfig.minTlsVersion", "properties.siteConfig.linuxFxVersion", "properties.siteConfig.healthCheckPath",
+									"properties.httpsOnly", "properties.serverFarmId", "properties.publicNetworkAccess",
+								},
 							},
 						},
 					},
@@ -177,6 +234,21 @@ func TestParseDirectory(t *testing.T) {
 								Name:              "userAssignedIdentities",
 								Namespace:         "Microsoft.ManagedIdentity",
 								CurrentAPIVersion: "2022-01-31-preview",
+								Line:              13,
+								Properties:        []string{"name", "location"},
+							},
+						},
+					},
+					{
+						Path: "testdata/parse/modules/storage.json",
+						Resources: []types.Resource{
+							{
+								ID:                "Microsoft.Storage/storageAccounts",
+								Name:              "storageAccounts",
+								Namespace:         "Microsoft.Storage",
+								CurrentAPIVersion: "2021-09-01",
+								Line:              7,
+								Properties:        []string{"name", "location", "kind"},
 							},
 						},
 					},
@@ -193,7 +265,7 @@ func TestParseDirectory(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := ParseDirectory(tt.args.dir)
+			got, err := ParseDirectory(tt.args.dir, Filter{})
 			if (err != nil) != tt.wantErr {
 				t.Fatalf("ParseDirectory() error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -202,6 +274,9 @@ func TestParseDirectory(t *testing.T) {
 				return
 			}
 
+			if len(got.Files) != len(tt.want.Files) {
+				t.Fatalf("ParseDirectory() files = %v, want %v", len(got.Files), len(tt.want.Files))
+			}
 			for i, file := range got.Files {
 				if !reflect.DeepEqual(file.Resources, tt.want.Files[i].Resources) {
 					t.Errorf("\nParseDirectory(), file %v with resources = %v, want %v", file.Path, file.Resources, tt.want.Files[i].Resources)
@@ -210,3 +285,81 @@ func TestParseDirectory(t *testing.T) {
 		})
 	}
 }
+
+func TestParseFiles(t *testing.T) {
+	filePaths := []string{
+		"testdata/parse/modules/identity.bicep",
+		"testdata/parse/modules/compute.bicep",
+		"testdata/parse/azure.deploy.parameters.json",
+		"testdata/parse/modules/variables.tf",
+		"testdata/update/azure.deploy.bicep",
+	}
+	tests := []struct {
+		name   string
+		filter Filter
+		ignore map[string]string
+		want   []string
+	}{
+		{
+			name:   "supported-files-in-directory",
+			filter: Filter{},
+			want:   []string{"testdata/parse/modules/compute.bicep", "testdata/parse/modules/identity.bicep"},
+		},
+		{
+			name:   "excluded-files",
+			filter: Filter{Exclude: []string{"identity.bicep"}},
+			want:   []string{"testdata/parse/modules/compute.bicep"},
+		},
+		{
+			name:   "excluded-parent",
+			filter: Filter{Exclude: []string{"modules"}},
+			want:   []string{},
+		},
+		{
+			name:   "ignore-files",
+			filter: Filter{IgnoreFiles: true},
+			ignore: map[string]string{"testdata/parse/.bruhignore": "identity.bicep\n"},
+			want:   []string{"testdata/parse/modules/compute.bicep"},
+		},
+		{
+			name:   "ignored-parent",
+			filter: Filter{IgnoreFiles: true},
+			ignore: map[string]string{"testdata/parse/.gitignore": "modules/\n"},
+			want:   []string{},
+		},
+		{
+			name:   "negated-in-subdirectory",
+			filter: Filter{IgnoreFiles: true},
+			ignore: map[string]string{"testdata/parse/.gitignore": "*.bicep\n", "testdata/parse/modules/.bruhignore": "!compute.bicep\n"},
+			want:   []string{"testdata/parse/modules/compute.bicep"},
+		},
+		{
+			name:   "ignore-files-not-honoured",
+			filter: Filter{},
+			ignore: map[string]string{"testdata/parse/.bruhignore": "identity.bicep\n"},
+			want:   []string{"testdata/parse/modules/compute.bicep", "testdata/parse/modules/identity.bicep"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The ignore files are only given by the read function, like the files of a git revision
+			read := func(path string) ([]byte, error) {
+				if content, ok := tt.ignore[filepath.ToSlash(path)]; ok {
+					return []byte(content), nil
+				}
+				return os.ReadFile(path)
+			}
+			got, err := ParseFiles("testdata/parse", filePaths, tt.filter, read)
+			if err != nil {
+				t.Fatalf("ParseFiles() error = %v", err)
+			}
+			paths := []string{}
+			for _, file := range got.Files {
+				paths = append(paths, filepath.ToSlash(file.Path))
+			}
+			if !reflect.DeepEqual(paths, tt.want) {
+				t.Errorf("ParseFiles() files = %v, want %v", paths, tt.want)
+			}
+		})
+	}
+}
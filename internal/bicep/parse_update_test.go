@@ -45,6 +45,8 @@ func TestParseUpdateFile(t *testing.T) {
 						Name:              "resourceGroups",
 						Namespace:         "Microsoft.Resources",
 						CurrentAPIVersion: "2021-01-01",
+						Line:              33,
+						Properties:        []string{"name", "location", "tags"},
 					},
 				},
 			},
@@ -56,6 +58,8 @@ func TestParseUpdateFile(t *testing.T) {
 						Name:              "resourceGroups",
 						Namespace:         "Microsoft.Resources",
 						CurrentAPIVersion: "2022-09-01",
+						Line:              33,
+						Properties:        []string{"name", "location", "tags"},
 					},
 				},
 			},
@@ -0,0 +1,5 @@
+resource "azapi_resource" "app" {
+  type     = "Microsoft.App/containerApps@2023-05-01"
+  name     = "app"
+  location = var.location
+}
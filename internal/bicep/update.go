@@ -3,37 +3,101 @@ package bicep
 import (
 	"fmt"
 	"os"
-	"regexp"
+	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 
+	"github.com/christosgalano/bruh/internal/arm"
+	"github.com/christosgalano/bruh/internal/terraform"
 	"github.com/christosgalano/bruh/internal/types"
 )
 
-// UpdateFile receives a pointer to a BicepFile object and updates the file with the new API versions for each resource.
-// inPlace determines whether the function will update the file in place or create a new one with the suffix "_updated.bicep".
-func UpdateFile(bicepFile *types.BicepFile, inPlace bool) error {
-	data, ok := cache.Load(bicepFile.Path)
+// edit is a replacement of the source bytes between start and end with the latest version of a resource.
+type edit struct {
+	start    int
+	end      int
+	resource *types.Resource
+}
 
-	// If the file is not cached, read it (this should never happen)
-	if !ok {
-		d, err := readBicepFile(bicepFile.Path)
-		if err != nil {
-			return err
+// offsetEdits returns the edits of the declarations, function calls and module references of a Bicep file that need to be updated.
+// The declarations, function calls and module references are matched with the resources of the file in order of appearance,
+// so that each resource is updated on its own line, even if other resources share its type and API version.
+func offsetEdits(bicepFile *types.BicepFile, content string) ([]edit, error) {
+	declarations := []*types.Resource{}
+	calls := []*types.Resource{}
+	modules := []*types.Resource{}
+	for i := range bicepFile.Resources {
+		switch {
+		case bicepFile.Resources[i].Function != "":
+			calls = append(calls, &bicepFile.Resources[i])
+		case bicepFile.Resources[i].Module:
+			modules = append(modules, &bicepFile.Resources[i])
+		default:
+			declarations = append(declarations, &bicepFile.Resources[i])
 		}
-		data = d
 	}
-	content := string(data.([]byte))
 
-	// Update the API versions for each resource - if needed
-	for i := range bicepFile.Resources {
-		latestAPIVersion := bicepFile.Resources[i].AvailableAPIVersions[0]
-		if bicepFile.Resources[i].CurrentAPIVersion != latestAPIVersion {
-			re := regexp.MustCompile(bicepFile.Resources[i].ID + "@" + bicepFile.Resources[i].CurrentAPIVersion)
-			content = re.ReplaceAllString(content, bicepFile.Resources[i].ID+"@"+latestAPIVersion)
-			bicepFile.Resources[i].CurrentAPIVersion = latestAPIVersion
+	matches := declarationRegex.FindAllStringSubmatchIndex(content, -1)
+	found := resourceCalls(content)
+	references := moduleReferences(content)
+	if len(matches) != len(declarations) || len(found) != len(calls) || len(references) != len(modules) {
+		return nil, fmt.Errorf("file %q changed since it was parsed", bicepFile.Path)
+	}
+
+	edits := []edit{}
+	for i, match := range matches {
+		edits = append(edits, edit{start: match[6], end: match[7], resource: declarations[i]})
+	}
+	for i, call := range found {
+		edits = append(edits, edit{start: call.Start, end: call.End, resource: calls[i]})
+	}
+	for i, ref := range references {
+		edits = append(edits, edit{start: ref.start, end: ref.end, resource: modules[i]})
+	}
+
+	// Keep only the resources that need to be updated
+	result := []edit{}
+	for _, e := range edits {
+		latestAPIVersion := e.resource.LatestAPIVersion()
+		if e.resource.Unknown || e.resource.Skipped || latestAPIVersion == "" || e.resource.CurrentAPIVersion == latestAPIVersion {
+			continue
 		}
+		result = append(result, e)
+	}
+	return result, nil
+}
+
+// UpdateFile receives a pointer to a BicepFile object and updates the file with the new API versions for each resource.
+// inPlace determines whether the function will update the file in place or create a new one with the suffix "_updated.bicep".
+// ARM templates (.json) and Terraform files (.tf) are updated through the arm and terraform packages,
+// which create new files with the suffixes "_updated.json" and ".updated" (e.g. main.tf.updated) instead.
+func UpdateFile(bicepFile *types.BicepFile, inPlace bool) error {
+	switch filepath.Ext(bicepFile.Path) {
+	case ".json":
+		return arm.UpdateFile(bicepFile, inPlace)
+	case ".tf":
+		return terraform.UpdateFile(bicepFile, inPlace)
+	}
+
+	data, err := readBicepFile(bicepFile.Path)
+	if err != nil {
+		return err
+	}
+	content := string(data)
 
+	// Update the API versions from the end of the file, so that the offsets of the remaining edits still refer to the original content
+	// Resources with an unknown type have no available API versions, so they are skipped along with the excluded ones
+	edits, err := offsetEdits(bicepFile, content)
+	if err != nil {
+		return err
+	}
+	sort.Slice(edits, func(i, j int) bool {
+		return edits[i].start > edits[j].start
+	})
+	for _, e := range edits {
+		content = content[:e.start] + e.resource.LatestAPIVersion() + content[e.end:]
+		e.resource.CurrentAPIVersion = e.resource.LatestAPIVersion()
 	}
 
 	// Use the same permissions as the original file
@@ -42,10 +106,8 @@ func UpdateFile(bicepFile *types.BicepFile, inPlace bool) error {
 		return err
 	}
 
-	// If the file is not updated in place, create a new one with the suffix "_updated.bicep",
-	// and remove the original file from the cache
+	// If the file is not updated in place, create a new one with the suffix "_updated.bicep"
 	if !inPlace {
-		cache.Delete(bicepFile.Path)
 		bicepFile.Path = strings.Replace(bicepFile.Path, ".bicep", "_updated.bicep", 1)
 	}
 
@@ -55,9 +117,6 @@ func UpdateFile(bicepFile *types.BicepFile, inPlace bool) error {
 		return fmt.Errorf("failed to update file %s", err)
 	}
 
-	// Cache the new content appropriately
-	cache.Store(bicepFile.Path, []byte(content))
-
 	return nil
 }
 
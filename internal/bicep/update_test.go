@@ -3,6 +3,8 @@ package bicep
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/christosgalano/bruh/internal/types"
@@ -60,6 +62,84 @@ func TestUpdateFile(t *testing.T) {
 	}
 }
 
+func TestUpdateFileFunctionCalls(t *testing.T) {
+	content := "resource storage 'Microsoft.Storage/storageAccounts@2021-04-01' = {\n  name: 'storage'\n}\n\n" +
+		"output key string = listKeys(storage.id, '2021-04-01').keys[0].value\n" +
+		"output ip string = reference(resourceId('Microsoft.Network/publicIPAddresses', 'ip'), '2019-06-01').ipAddress\n"
+	path := filepath.Join(t.TempDir(), "main.bicep")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	bicepFile, err := ParseFile(path)
+	if err != nil {
+		t.Fatalf("ParseFile() error = %v", err)
+	}
+	wantFunctions := []string{"", "listKeys", "reference"}
+	if len(bicepFile.Resources) != len(wantFunctions) {
+		t.Fatalf("ParseFile() resources = %v, want %v", len(bicepFile.Resources), len(wantFunctions))
+	}
+	for i, function := range wantFunctions {
+		if bicepFile.Resources[i].Function != function {
+			t.Errorf("ParseFile() function = %q, want %q", bicepFile.Resources[i].Function, function)
+		}
+	}
+
+	bicepFile.Resources[0].AvailableAPIVersions = []string{"2023-01-01", "2021-04-01"}
+	bicepFile.Resources[1].AvailableAPIVersions = []string{"2023-01-01", "2021-04-01"}
+	bicepFile.Resources[2].AvailableAPIVersions = []string{"2023-04-01", "2019-06-01"}
+	if err := UpdateFile(bicepFile, true); err != nil {
+		t.Fatalf("UpdateFile() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := strings.NewReplacer("2021-04-01", "2023-01-01", "2019-06-01", "2023-04-01").Replace(content)
+	if string(data) != want {
+		t.Errorf("UpdateFile() content =\n%s\nwant\n%s", data, want)
+	}
+}
+
+func TestUpdateFileRepeatedDeclarations(t *testing.T) {
+	content := "resource app 'Microsoft.Web/sites@2020-01-01' = {\n  name: 'app'\n}\n\n" +
+		"resource legacy 'Microsoft.Web/sites@2020-01-01' = {\n  name: 'legacy'\n}\n\n" +
+		"resource preview 'Microsoft.Web/sites@2020-01-01-preview' = {\n  name: 'preview'\n}\n"
+	path := filepath.Join(t.TempDir(), "main.bicep")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	bicepFile, err := ParseFile(path)
+	if err != nil {
+		t.Fatalf("ParseFile() error = %v", err)
+	}
+	if len(bicepFile.Resources) != 3 {
+		t.Fatalf("ParseFile() resources = %v, want 3", len(bicepFile.Resources))
+	}
+	for i := range bicepFile.Resources {
+		bicepFile.Resources[i].AvailableAPIVersions = []string{"2022-01-01", "2020-01-01", "2020-01-01-preview"}
+	}
+	bicepFile.Resources[1].Skipped = true
+	bicepFile.Resources[2].Skipped = true
+	if err := UpdateFile(bicepFile, true); err != nil {
+		t.Fatalf("UpdateFile() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Replace(content, "2020-01-01", "2022-01-01", 1)
+	if string(data) != want {
+		t.Errorf("UpdateFile() content =\n%s\nwant\n%s", data, want)
+	}
+	if got := bicepFile.Resources[1].CurrentAPIVersion; got != "2020-01-01" {
+		t.Errorf("UpdateFile() skipped resource version = %q, want %q", got, "2020-01-01")
+	}
+}
+
 func TestUpdateDirectory(t *testing.T) {
 	type args struct {
 		bicepDirectory *types.BicepDirectory
@@ -0,0 +1,140 @@
+/*
+Package changes compares the resources of two versions of the same files (e.g. parsed at two git revisions)
+and reports the API versions that were added, removed or changed.
+
+Resources are matched by file and by label (the resource ID, prefixed with the function whose call passes the API version, if any).
+Since a file can use the same resource type more than once, the API versions found in both versions are matched first,
+and the remaining ones are paired in order of appearance, while any leftovers are reported as added or removed.
+*/
+package changes
+
+import (
+	"path/filepath"
+	"sort"
+
+	"github.com/christosgalano/bruh/internal/types"
+)
+
+// Kind represents the kind of change of a resource between two versions of a file.
+type Kind int8
+
+const (
+	KindChanged Kind = iota // KindChanged corresponds to a resource whose API version changed
+	KindAdded               // KindAdded corresponds to a resource that did not exist before
+	KindRemoved             // KindRemoved corresponds to a resource that no longer exists
+)
+
+// String returns a string representation of a changes.Kind object.
+func (k Kind) String() string {
+	switch k {
+	case KindChanged:
+		return "changed"
+	case KindAdded:
+		return "added"
+	case KindRemoved:
+		return "removed"
+	}
+	return "unknown"
+}
+
+// Change contains information about a change of a resource between two versions of a file:
+//   - Path: the path of the file relative to its directory (e.g. modules/app.bicep)
+//   - Resource: the label of the resource (e.g. Microsoft.Web/sites or listKeys(Microsoft.Storage/storageAccounts))
+//   - From: the API version before the change, empty for added resources
+//   - To: the API version after the change, empty for removed resources
+//   - Kind: the kind of the change
+type Change struct {
+	Path     string
+	Resource string
+	From     string
+	To       string
+	Kind     Kind
+}
+
+// versions returns the API versions of the resources of each file of a directory, grouped by label in order of appearance,
+// along with the labels in order of first appearance. The files are keyed by their path relative to the directory.
+func versions(bicepDirectory *types.BicepDirectory) (map[string]map[string][]string, map[string][]string) {
+	result := map[string]map[string][]string{}
+	order := map[string][]string{}
+	for _, file := range bicepDirectory.Files {
+		path, err := filepath.Rel(bicepDirectory.Path, file.Path)
+		if err != nil {
+			path = file.Path
+		}
+		path = filepath.ToSlash(path)
+		if _, ok := result[path]; !ok {
+			result[path] = map[string][]string{}
+		}
+		for _, resource := range file.Resources {
+			l := resource.Label()
+			if _, ok := result[path][l]; !ok {
+				order[path] = append(order[path], l)
+			}
+			result[path][l] = append(result[path][l], resource.CurrentAPIVersion)
+		}
+	}
+	return result, order
+}
+
+// subtract returns the versions of a that are not in b, removing each match only once and keeping the order of a.
+func subtract(a, b []string) []string {
+	remaining := map[string]int{}
+	for _, v := range b {
+		remaining[v]++
+	}
+	result := []string{}
+	for _, v := range a {
+		if remaining[v] > 0 {
+			remaining[v]--
+			continue
+		}
+		result = append(result, v)
+	}
+	return result
+}
+
+// Compare returns the changes of the resources between two versions of the files of a directory, sorted by path.
+// Within a file, the changes follow the order in which the resources first appear, in the new version and then in the old one.
+func Compare(from, to *types.BicepDirectory) []Change {
+	fromVersions, fromOrder := versions(from)
+	toVersions, toOrder := versions(to)
+
+	paths := []string{}
+	for path := range fromVersions {
+		paths = append(paths, path)
+	}
+	for path := range toVersions {
+		if _, ok := fromVersions[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	changes := []Change{}
+	for _, path := range paths {
+		labels := append([]string{}, toOrder[path]...)
+		for _, l := range fromOrder[path] {
+			if _, ok := toVersions[path][l]; !ok {
+				labels = append(labels, l)
+			}
+		}
+
+		for _, l := range labels {
+			removed := subtract(fromVersions[path][l], toVersions[path][l])
+			added := subtract(toVersions[path][l], fromVersions[path][l])
+			for i := 0; i < len(removed) || i < len(added); i++ {
+				change := Change{Path: path, Resource: l}
+				switch {
+				case i < len(removed) && i < len(added):
+					change.From, change.To, change.Kind = removed[i], added[i], KindChanged
+				case i < len(removed):
+					change.From, change.Kind = removed[i], KindRemoved
+				default:
+					change.To, change.Kind = added[i], KindAdded
+				}
+				changes = append(changes, change)
+			}
+		}
+	}
+	return changes
+}
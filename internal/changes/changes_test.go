@@ -0,0 +1,118 @@
+package changes
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/christosgalano/bruh/internal/types"
+)
+
+func TestCompare(t *testing.T) {
+	resource := func(id, version string) types.Resource {
+		return types.Resource{ID: id, CurrentAPIVersion: version}
+	}
+	from := &types.BicepDirectory{
+		Path: "infra",
+		Files: []types.BicepFile{
+			{
+				Path: "infra/main.bicep",
+				Resources: []types.Resource{
+					resource("Microsoft.Web/sites", "2021-02-01"),
+					resource("Microsoft.Web/sites", "2022-03-01"),
+					resource("Microsoft.Storage/storageAccounts", "2021-04-01"),
+					{ID: "Microsoft.Storage/storageAccounts", CurrentAPIVersion: "2021-04-01", Function: "listKeys"},
+				},
+			},
+			{
+				Path:      "infra/removed.bicep",
+				Resources: []types.Resource{resource("Microsoft.KeyVault/vaults", "2022-07-01")},
+			},
+			{
+				Path:      "infra/unchanged.bicep",
+				Resources: []types.Resource{resource("Microsoft.Resources/resourceGroups", "2021-01-01")},
+			},
+		},
+	}
+	to := &types.BicepDirectory{
+		Path: "infra",
+		Files: []types.BicepFile{
+			{
+				Path: "infra/main.bicep",
+				Resources: []types.Resource{
+					resource("Microsoft.Web/serverfarms", "2022-09-01"),
+					resource("Microsoft.Web/sites", "2022-03-01"),
+					resource("Microsoft.Web/sites", "2023-01-01"),
+					{ID: "Microsoft.Storage/storageAccounts", CurrentAPIVersion: "2023-01-01", Function: "listKeys"},
+				},
+			},
+			{
+				Path:      "infra/unchanged.bicep",
+				Resources: []types.Resource{resource("Microsoft.Resources/resourceGroups", "2021-01-01")},
+			},
+		},
+	}
+
+	tests := []struct {
+		name string
+		from *types.BicepDirectory
+		to   *types.BicepDirectory
+		want []Change
+	}{
+		{
+			name: "changes",
+			from: from,
+			to:   to,
+			want: []Change{
+				{Path: "main.bicep", Resource: "Microsoft.Web/serverfarms", To: "2022-09-01", Kind: KindAdded},
+				{Path: "main.bicep", Resource: "Microsoft.Web/sites", From: "2021-02-01", To: "2023-01-01", Kind: KindChanged},
+				{Path: "main.bicep", Resource: "listKeys(Microsoft.Storage/storageAccounts)", From: "2021-04-01", To: "2023-01-01", Kind: KindChanged},
+				{Path: "main.bicep", Resource: "Microsoft.Storage/storageAccounts", From: "2021-04-01", Kind: KindRemoved},
+				{Path: "removed.bicep", Resource: "Microsoft.KeyVault/vaults", From: "2022-07-01", Kind: KindRemoved},
+			},
+		},
+		{
+			name: "reverse",
+			from: to,
+			to:   from,
+			want: []Change{
+				{Path: "main.bicep", Resource: "Microsoft.Web/sites", From: "2023-01-01", To: "2021-02-01", Kind: KindChanged},
+				{Path: "main.bicep", Resource: "Microsoft.Storage/storageAccounts", To: "2021-04-01", Kind: KindAdded},
+				{Path: "main.bicep", Resource: "listKeys(Microsoft.Storage/storageAccounts)", From: "2023-01-01", To: "2021-04-01", Kind: KindChanged},
+				{Path: "main.bicep", Resource: "Microsoft.Web/serverfarms", From: "2022-09-01", Kind: KindRemoved},
+				{Path: "removed.bicep", Resource: "Microsoft.KeyVault/vaults", To: "2022-07-01", Kind: KindAdded},
+			},
+		},
+		{
+			name: "no-changes",
+			from: to,
+			to:   to,
+			want: []Change{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Compare(tt.from, tt.to); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Compare() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKind_String(t *testing.T) {
+	tests := []struct {
+		kind Kind
+		want string
+	}{
+		{KindChanged, "changed"},
+		{KindAdded, "added"},
+		{KindRemoved, "removed"},
+		{Kind(-1), "unknown"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := tt.kind.String(); got != tt.want {
+				t.Errorf("String() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"github.com/christosgalano/bruh/internal/action"
+	"github.com/christosgalano/bruh/internal/bicep"
+	"github.com/christosgalano/bruh/internal/scanner"
+	"github.com/christosgalano/bruh/internal/types"
+)
+
+// actionCmd represents the action command.
+var actionCmd = &cobra.Command{
+	Use:   "action",
+	Short: "Run bruh as a GitHub Action",
+	Long: `Run bruh as a GitHub Action: the inputs of the action are read from the INPUT_* environment variables set by the runner,
+the scan or update command is run once, and the results are reported to the workflow.
+
+The result is set as the "result" step output, along with the number of "outdated", "unknown" and (for update) "updated" resources.
+Each outdated, promotable or unknown resource is annotated on the line of its API version (and each updated resource with update).
+If the summary input is true, the results are appended to the job summary in markdown.
+Invalid inputs are all reported at once, before anything is scanned.`,
+	//revive:disable:unused-parameter
+	Run: func(cmd *cobra.Command, args []string) {
+		inputs, err := action.ParseInputs(os.Getenv)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+			os.Exit(1)
+		}
+
+		if err := runAction(inputs, action.NewEnvironment(os.Getenv)); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// init initializes the action command.
+func init() {
+	// Examples
+	actionCmd.Example = `
+Scan a directory outside of GitHub Actions:
+  INPUT_COMMAND=scan INPUT_PATH=./bicep/modules INPUT_OUTPUT=table bruh action`
+}
+
+// runAction scans the path of the inputs, updates its files if the command is update,
+// and reports the results through the step outputs, the job summary and annotations.
+func runAction(inputs *action.Inputs, env action.Environment) error {
+	fs, err := os.Stat(inputs.Path)
+	if err != nil {
+		return err
+	}
+
+	session := scanner.New(scanner.Options{IncludePreview: inputs.IncludePreview}, nil, nil)
+	bicepDirectory, err := session.Scan(inputs.Path)
+	if err != nil {
+		return err
+	}
+
+	var result, summary, heading string
+	var annotations []action.Annotation
+	var counts action.Counts
+	if inputs.Command == action.CommandScan {
+		heading = "Scan results"
+		annotations = action.ScanAnnotations(env, bicepDirectory.Files)
+		counts = action.Count(bicepDirectory.Files)
+		result, summary, err = actionScan(inputs, bicepDirectory, fs.IsDir())
+	} else {
+		heading = "Update results"
+		result, annotations, counts, err = actionUpdate(inputs, env, bicepDirectory, fs.IsDir())
+		summary = result
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, annotation := range annotations {
+		fmt.Println(annotation)
+	}
+
+	outputs := [][2]string{
+		{"result", result},
+		{"outdated", strconv.Itoa(counts.Outdated)},
+		{"unknown", strconv.Itoa(counts.Unknown)},
+	}
+	if inputs.Command == action.CommandUpdate {
+		outputs = append(outputs, [2]string{"updated", strconv.Itoa(counts.Updated)})
+	}
+	for _, o := range outputs {
+		if err := env.SetOutput(o[0], o[1]); err != nil {
+			return err
+		}
+	}
+
+	if inputs.Summary {
+		return env.AppendSummary(fmt.Sprintf("## %s\n\n%s\n---\n", heading, summary))
+	}
+	return nil
+}
+
+// actionScan prints the status of the resources of a scanned directory (or its only file) in the output format of the inputs,
+// and returns the printed result along with the result in markdown for the job summary.
+func actionScan(inputs *action.Inputs, bicepDirectory *types.BicepDirectory, isDir bool) (string, string, error) {
+	output, outdated = inputs.Output, inputs.Outdated
+	printResult := func() {
+		if isDir {
+			printScanDirectory(bicepDirectory)
+		} else {
+			printScanFile(&bicepDirectory.Files[0])
+		}
+	}
+
+	result, err := captureStdout(true, printResult)
+	if err != nil || !inputs.Summary || output == "markdown" {
+		return result, result, err
+	}
+
+	output = "markdown"
+	summary, err := captureStdout(false, printResult)
+	return result, summary, err
+}
+
+// actionUpdate updates the files of a scanned directory (or its only file), capping the resources pinned in the project configuration,
+// and returns the printed result along with the annotations and counts of the update, which are computed before the files are updated.
+func actionUpdate(inputs *action.Inputs, env action.Environment, bicepDirectory *types.BicepDirectory, isDir bool) (string, []action.Annotation, action.Counts, error) {
+	bicepFiles := make([]*types.BicepFile, 0, len(bicepDirectory.Files))
+	for i := range bicepDirectory.Files {
+		bicepFiles = append(bicepFiles, &bicepDirectory.Files[i])
+	}
+	if _, err := applyPins(bicepDirectory.Path, bicepFiles...); err != nil {
+		return "", nil, action.Counts{}, err
+	}
+
+	annotations := action.UpdateAnnotations(env, bicepDirectory.Files)
+	counts := action.Count(bicepDirectory.Files)
+
+	var err error
+	if isDir {
+		err = bicep.UpdateDirectory(bicepDirectory, inputs.InPlace)
+	} else {
+		err = bicep.UpdateFile(bicepFiles[0], inputs.InPlace)
+	}
+	if err != nil {
+		return "", nil, action.Counts{}, err
+	}
+
+	result, err := captureStdout(!inputs.Silent, func() {
+		if isDir {
+			printDirectoryNormal(bicepDirectory, false, types.ModeUpdate)
+		} else {
+			printFileNormal(bicepFiles[0], bicepFiles[0].Path, false, types.ModeUpdate)
+		}
+	})
+	return result, annotations, counts, err
+}
+
+// captureStdout returns what the given function prints to standard output, which is also printed if echo is true.
+func captureStdout(echo bool, fn func()) (string, error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return "", err
+	}
+
+	original := os.Stdout
+	captured := &bytes.Buffer{}
+	var dst io.Writer = captured
+	if echo {
+		dst = io.MultiWriter(captured, original)
+	}
+	done := make(chan error, 1)
+	go func() {
+		_, err := io.Copy(dst, r)
+		done <- err
+	}()
+
+	os.Stdout = w
+	defer func() { os.Stdout = original }()
+	fn()
+
+	w.Close()
+	err = <-done
+	r.Close()
+	return captured.String(), err
+}
@@ -0,0 +1,197 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+
+	"github.com/christosgalano/bruh/internal/bicep"
+	"github.com/christosgalano/bruh/internal/changes"
+	"github.com/christosgalano/bruh/internal/git"
+	"github.com/christosgalano/bruh/internal/types"
+)
+
+var (
+	diffFrom   string
+	diffTo     string
+	diffPath   string
+	diffOutput string
+	diffFilter bicep.Filter
+)
+
+// diffCmd represents the diff command.
+var diffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Show the API versions that changed between two git revisions",
+	Long: `Show the resources whose API versions were added, removed or changed between two revisions of the local git repository
+(e.g. two release tags), reading the files of both revisions straight from the object store without checking them out.
+
+Resources are matched by file and resource type (along with the function whose call passes the API version, if any),
+so renamed files show up as removed and added. If the path exists at only one of the revisions, its resources show up as added or removed.
+No network access is needed, as only the API versions in the files are compared.`,
+	//revive:disable:unused-parameter
+	Run: func(cmd *cobra.Command, args []string) {
+		// Invalid output format
+		if diffOutput != "normal" && diffOutput != "table" {
+			fmt.Fprintf(os.Stderr, "Error: invalid output format %s\n", diffOutput)
+			cmd.Usage()
+			os.Exit(1)
+		}
+
+		if err := diffRevisions(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// init initializes the diff command.
+func init() {
+	// Local flags
+
+	// from - required
+	diffCmd.Flags().StringVar(&diffFrom, "from", "", "git revision to compare from (e.g. v2.3.0)")
+	diffCmd.MarkFlagRequired("from")
+
+	// to - optional
+	diffCmd.Flags().StringVar(&diffTo, "to", "HEAD", "git revision to compare to")
+
+	// path - optional
+	diffCmd.Flags().StringVarP(&diffPath, "path", "p", ".", "path to bicep file or directory containing bicep files")
+
+	// output - optional
+	diffCmd.Flags().StringVarP(&diffOutput, "output", "o", "normal", "output format (normal, table)")
+
+	// include, exclude, ignore-files, hidden - optional
+	addFilterFlags(diffCmd, &diffFilter)
+
+	// Examples
+	diffCmd.Example = `
+Show the API versions that changed since a release:
+  bruh diff --from v2.3.0 --to HEAD
+
+Compare two releases of a directory in table format:
+  bruh diff --from v2.3.0 --to v2.4.0 --path ./infra --output table`
+}
+
+// repositoryDir returns the closest existing directory of a path, which need not exist in the working tree,
+// so that the git repository containing it can be found.
+func repositoryDir(path string) string {
+	dir := path
+	for {
+		if fs, err := os.Stat(dir); err == nil && fs.IsDir() {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "."
+		}
+		dir = parent
+	}
+}
+
+// parseRevision parses the files of a file or directory at a git revision, read from the object store through the same parser as the working tree.
+// If the filter honours ignore files, they are read from the revision as well, while the paths of the parsed files are those of the working tree.
+// If the path does not exist at the revision, the returned error wraps fs.ErrNotExist.
+func parseRevision(rev *git.Revision, path string, filter bicep.Filter) (*types.BicepDirectory, error) {
+	files, err := rev.Files(path)
+	if err != nil {
+		return nil, err
+	}
+	dir := path
+	if len(files) == 1 && files[0] == path {
+		dir = filepath.Dir(path)
+	}
+	return bicep.ParseFiles(dir, files, filter, rev.ReadFile)
+}
+
+// diffRevisions parses the path at both revisions and prints out the resources whose API versions changed between them.
+// If the path exists at only one of the revisions, its resources are reported as added or removed.
+func diffRevisions() error {
+	directories := make([]*types.BicepDirectory, 2)
+	for i, name := range []string{diffFrom, diffTo} {
+		rev, err := git.NewRevision(repositoryDir(diffPath), name)
+		if err != nil {
+			return err
+		}
+		bicepDirectory, err := parseRevision(rev, diffPath, diffFilter)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		directories[i] = bicepDirectory
+	}
+
+	// A path missing at a revision has no files there, in the same directory as at the other revision
+	switch {
+	case directories[0] == nil && directories[1] == nil:
+		return fmt.Errorf("no such file or directory %q at %s or %s", diffPath, diffFrom, diffTo)
+	case directories[0] == nil:
+		directories[0] = &types.BicepDirectory{Path: directories[1].Path}
+	case directories[1] == nil:
+		directories[1] = &types.BicepDirectory{Path: directories[0].Path}
+	}
+
+	result := changes.Compare(directories[0], directories[1])
+	if len(result) == 0 {
+		fmt.Printf("No API version changes between %s and %s\n", diffFrom, diffTo)
+		return nil
+	}
+
+	switch diffOutput {
+	case "normal":
+		printChangesNormal(result)
+	case "table":
+		printChangesTable(result)
+	}
+	return nil
+}
+
+// printChangesNormal prints the changes grouped by file: "~" for changed API versions, "+" for added resources and "-" for removed ones.
+func printChangesNormal(result []changes.Change) {
+	path := ""
+	for _, change := range result {
+		if change.Path != path {
+			if path != "" {
+				fmt.Println()
+			}
+			path = change.Path
+			fmt.Printf("%s:\n", path)
+		}
+		switch change.Kind {
+		case changes.KindChanged:
+			fmt.Printf("  ~ %s: %s -> %s\n", change.Resource, change.From, change.To)
+		case changes.KindAdded:
+			fmt.Printf("  + %s: %s\n", change.Resource, change.To)
+		case changes.KindRemoved:
+			fmt.Printf("  - %s: %s\n", change.Resource, change.From)
+		}
+	}
+}
+
+// printChangesTable prints the changes in tabular format.
+func printChangesTable(result []changes.Change) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"File", "Resource", "Change", "From", "To"})
+	table.SetBorders(tablewriter.Border{Left: true, Top: true, Right: true, Bottom: true})
+	table.SetColumnAlignment([]int{tablewriter.ALIGN_LEFT, tablewriter.ALIGN_LEFT, tablewriter.ALIGN_CENTER, tablewriter.ALIGN_CENTER, tablewriter.ALIGN_CENTER})
+
+	for _, change := range result {
+		from, to := change.From, change.To
+		if from == "" {
+			from = "-"
+		}
+		if to == "" {
+			to = "-"
+		}
+		table.Append([]string{change.Path, change.Resource, change.Kind.String(), from, to})
+	}
+	table.Render()
+}
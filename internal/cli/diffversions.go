@@ -0,0 +1,103 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/christosgalano/bruh/internal/scanner"
+	"github.com/christosgalano/bruh/internal/schema"
+	"github.com/christosgalano/bruh/internal/types"
+)
+
+var (
+	diffTypesDir       string
+	diffIncludePreview bool
+)
+
+// diffVersionsCmd represents the diff-versions command.
+var diffVersionsCmd = &cobra.Command{
+	Use:   "diff-versions <type> <from> <to>",
+	Short: "Show the property changes of a resource type between two API versions",
+	Long: `Show the properties that were added, removed or changed in a resource type between two API versions.
+Either version can be "latest", in which case the latest API version available is used.
+The property changes are taken from a local clone of bicep-types-az.`,
+	Args: cobra.ExactArgs(3),
+	//revive:disable:unused-parameter
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := diffVersions(args[0], args[1], args[2]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// init initializes the diff-versions command.
+func init() {
+	// Local flags
+
+	// types-dir - required
+	diffVersionsCmd.Flags().StringVar(&diffTypesDir, "types-dir", "", "path to the \"generated\" directory of bicep-types-az")
+	diffVersionsCmd.MarkFlagRequired("types-dir")
+
+	// include-preview - optional
+	diffVersionsCmd.Flags().BoolVarP(&diffIncludePreview, "include-preview", "r", false, "include preview API versions when resolving \"latest\"")
+
+	// Examples
+	diffVersionsCmd.Example = `
+Show the changes between two API versions:
+  bruh diff-versions Microsoft.Web/sites 2021-02-01 2023-01-01 --types-dir ./bicep-types-az/generated
+
+Show the changes up to the latest API version:
+  bruh diff-versions Microsoft.Web/sites 2021-02-01 latest --types-dir ./bicep-types-az/generated`
+}
+
+// diffVersions prints the property changes of the given resource type between two API versions.
+// The "latest" version is resolved using the API versions available on Microsoft Learn.
+func diffVersions(resourceType, from, to string) error {
+	namespace, name, found := strings.Cut(resourceType, "/")
+	if !found || name == "" {
+		return fmt.Errorf("invalid resource type %q", resourceType)
+	}
+
+	if from == "latest" || to == "latest" {
+		resource := types.Resource{ID: resourceType, Name: name, Namespace: namespace}
+		if err := scanner.New(scanner.Options{IncludePreview: diffIncludePreview}, nil, nil).Resolve(&resource); err != nil {
+			return err
+		}
+		if resource.Unknown {
+			return fmt.Errorf("unknown resource type %q", resourceType)
+		}
+		if from == "latest" {
+			from = resource.LatestAPIVersion()
+		}
+		if to == "latest" {
+			to = resource.LatestAPIVersion()
+		}
+	}
+
+	idx, err := schema.Load(diffTypesDir)
+	if err != nil {
+		return err
+	}
+	fromType, err := idx.Lookup(resourceType, from)
+	if err != nil {
+		return err
+	}
+	toType, err := idx.Lookup(resourceType, to)
+	if err != nil {
+		return err
+	}
+
+	changes := schema.Diff(fromType, toType)
+	fmt.Printf("%s %s -> %s:\n", resourceType, from, to)
+	if len(changes) == 0 {
+		fmt.Println("  no property changes")
+	}
+	for _, change := range changes {
+		fmt.Printf("  - %s\n", change)
+	}
+	return nil
+}
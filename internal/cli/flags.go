@@ -0,0 +1,22 @@
+package cli
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/christosgalano/bruh/internal/bicep"
+)
+
+// addFilterFlags adds the flags that determine which files and directories of a directory are walked.
+func addFilterFlags(cmd *cobra.Command, filter *bicep.Filter) {
+	// include - optional
+	cmd.Flags().StringSliceVar(&filter.Include, "include", nil, "glob patterns of the files to parse when given a directory (e.g. \"modules/**\"), all supported files if not set")
+
+	// exclude - optional
+	cmd.Flags().StringSliceVar(&filter.Exclude, "exclude", nil, "glob patterns of the files and directories to skip when given a directory (e.g. node_modules or \"samples/**\")")
+
+	// ignore-files - optional
+	cmd.Flags().BoolVar(&filter.IgnoreFiles, "ignore-files", false, "skip the files and directories matched by .gitignore and .bruhignore files")
+
+	// hidden - optional
+	cmd.Flags().BoolVar(&filter.Hidden, "hidden", false, "walk hidden directories (e.g. .git), which are skipped by default")
+}
@@ -0,0 +1,130 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/christosgalano/bruh/internal/bicep"
+	"github.com/christosgalano/bruh/internal/graph"
+	"github.com/christosgalano/bruh/internal/scanner"
+	"github.com/christosgalano/bruh/internal/types"
+)
+
+var (
+	graphPath           string
+	graphEntry          string
+	graphFormat         string
+	graphIncludePreview bool
+	graphFilter         bicep.Filter
+)
+
+// graphCmd represents the graph command.
+var graphCmd = &cobra.Command{
+	Use:   "graph",
+	Short: "Print the module dependency graph of Bicep files",
+	Long: `Print the dependency graph of Bicep files, whose nodes are files and edges their local module references and imports,
+along with the resource types and API versions of each file, in DOT, Mermaid or JSON format.
+
+With --entry, the graph contains the files reachable from the entry file. With --path, it contains all the files of the directory,
+so the deployments (files that no other file references) sharing a module can be compared.
+Files with outdated resources are highlighted, and shared modules show the number of deployments that use them.`,
+	//revive:disable:unused-parameter
+	Run: func(cmd *cobra.Command, args []string) {
+		// Invalid output format
+		if graphFormat != "dot" && graphFormat != "mermaid" && graphFormat != "json" {
+			fmt.Fprintf(os.Stderr, "Error: invalid graph format %s\n", graphFormat)
+			cmd.Usage()
+			os.Exit(1)
+		}
+
+		// Invalid path
+		target := graphPath
+		if graphEntry != "" {
+			target = graphEntry
+		}
+		if _, err := os.Stat(target); err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				fmt.Fprintf(os.Stderr, "Error: no such file or directory %q\n", target)
+			} else {
+				fmt.Fprintln(os.Stderr, err)
+			}
+			os.Exit(1)
+		}
+
+		if err := printGraph(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// init initializes the graph command.
+func init() {
+	// Local flags
+
+	// path - required unless entry is set
+	graphCmd.Flags().StringVarP(&graphPath, "path", "p", "", "path to directory containing bicep files")
+
+	// entry - required unless path is set
+	graphCmd.Flags().StringVar(&graphEntry, "entry", "", "path to an entry bicep file, including only the files reachable from it")
+	graphCmd.MarkFlagsOneRequired("path", "entry")
+	graphCmd.MarkFlagsMutuallyExclusive("path", "entry")
+
+	// format - optional
+	graphCmd.Flags().StringVarP(&graphFormat, "format", "f", "dot", "graph format (dot, mermaid, json)")
+
+	// include-preview - optional
+	graphCmd.Flags().BoolVarP(&graphIncludePreview, "include-preview", "r", false, "include preview API versions (if not set: only non-preview versions will be considered for the latest version)")
+
+	// include, exclude, ignore-files, hidden - optional
+	addFilterFlags(graphCmd, &graphFilter)
+
+	// Examples
+	graphCmd.Example = `
+Render the module tree of a deployment with Graphviz:
+  bruh graph --entry ./main.bicep | dot -Tsvg -o graph.svg
+
+Print the graph of all the deployments of a directory as a Mermaid flowchart:
+  bruh graph --path ./bicep --format mermaid
+
+Print the graph in JSON format:
+  bruh graph --entry ./main.bicep --format json`
+}
+
+// printGraph parses the entry file or directory, fetches the latest API versions of Azure resources
+// and then prints out the dependency graph of the files in the given format.
+func printGraph() error {
+	var bicepDirectory *types.BicepDirectory
+	var err error
+	if graphEntry != "" {
+		bicepDirectory, err = bicep.ParseEntry(graphEntry)
+	} else {
+		bicepDirectory, err = bicep.ParseDirectory(graphPath, graphFilter)
+	}
+	if err != nil {
+		return err
+	}
+
+	session := scanner.New(scanner.Options{IncludePreview: graphIncludePreview, Filter: graphFilter}, nil, nil)
+	if err := session.ResolveFiles(bicepDirectory.Files); err != nil {
+		return err
+	}
+
+	g := graph.New(bicepDirectory)
+	switch graphFormat {
+	case "dot":
+		fmt.Print(g.DOT())
+	case "mermaid":
+		fmt.Print(g.Mermaid())
+	case "json":
+		data, err := g.JSON()
+		if err != nil {
+			return err
+		}
+		fmt.Print(data)
+	}
+	return nil
+}
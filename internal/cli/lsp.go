@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/christosgalano/bruh/internal/lsp"
+	"github.com/christosgalano/bruh/internal/scanner"
+)
+
+var (
+	lspIncludePreview bool
+	lspStdio          bool
+)
+
+// lspCmd represents the lsp command.
+var lspCmd = &cobra.Command{
+	Use:   "lsp",
+	Short: "Run a language server reporting outdated API versions in the editor",
+	Long: `Run a Language Server Protocol server over standard input and output, for editors such as VS Code and Neovim.
+
+The server parses the Bicep files, ARM templates and Terraform files opened in the editor and publishes a diagnostic on each API version
+that is outdated, a promotable preview, a preview or of an unknown resource type. Quick fixes replace an API version with the latest
+(or GA) one, and hovering over a resource shows its status along with its available API versions. The available API versions of each
+resource type are fetched once per session.`,
+	//revive:disable:unused-parameter
+	Run: func(cmd *cobra.Command, args []string) {
+		// Standard output carries the protocol messages, so the session logs to standard error
+		session := scanner.New(scanner.Options{IncludePreview: lspIncludePreview}, nil, log.New(os.Stderr, "bruh: ", log.LstdFlags))
+		if err := lsp.NewServer(session.Resolve).Serve(os.Stdin, os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// init initializes the lsp command.
+func init() {
+	// Local flags
+
+	// include-preview - optional
+	lspCmd.Flags().BoolVarP(&lspIncludePreview, "include-preview", "r", false, "include preview API versions (if not set: only non-preview versions will be considered for the latest version)")
+
+	// stdio - optional, accepted for compatibility with clients passing it by default
+	lspCmd.Flags().BoolVar(&lspStdio, "stdio", true, "communicate over standard input and output")
+	lspCmd.Flags().MarkHidden("stdio")
+
+	// Examples
+	lspCmd.Example = `
+Run the language server:
+  bruh lsp
+
+Run the language server including preview API versions:
+  bruh lsp --include-preview`
+}
@@ -0,0 +1,135 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/christosgalano/bruh/internal/apiversions"
+	"github.com/christosgalano/bruh/internal/bicep"
+	"github.com/christosgalano/bruh/internal/metrics"
+	"github.com/christosgalano/bruh/internal/scanner"
+)
+
+var (
+	metricsPaths          []string
+	metricsIncludePreview bool
+	metricsOutput         string
+	metricsFilter         bicep.Filter
+)
+
+// metricsCmd represents the metrics command.
+var metricsCmd = &cobra.Command{
+	Use:   "metrics",
+	Short: "Write the API version drift of local repositories as Prometheus metrics",
+	Long: `Scan one or more local repositories once and write their API version drift in the Prometheus text exposition format,
+to standard output or to a file (e.g. for the textfile collector of the node exporter), which is replaced atomically.
+
+Each path is given as name=path, or as a path whose base name is used as the name, and the name is the repo label of its metrics.
+The metrics are gauges of the number of resources, outdated resources and preview resources per repo, namespace and type,
+the number of versions they are behind, the age in days of the oldest API version in use, and the outcome of each scan.
+To expose the metrics of periodic scans instead, use the --metrics flag of the serve command.`,
+	//revive:disable:unused-parameter
+	Run: func(cmd *cobra.Command, args []string) {
+		targets, err := parseTargets(metricsPaths)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+			os.Exit(1)
+		}
+
+		session := scanner.New(scanner.Options{IncludePreview: metricsIncludePreview, Filter: metricsFilter}, nil, nil)
+		results := metrics.Collect(session, targets)
+		for _, result := range results {
+			if result.Err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to scan %s: %s\n", result.Target.Path, result.Err)
+			}
+		}
+
+		if metricsOutput == "" {
+			_, err = metrics.Write(os.Stdout, results)
+		} else {
+			err = writeAtomically(metricsOutput, func(w io.Writer) error {
+				_, err := metrics.Write(w, results)
+				return err
+			})
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// parseTargets parses the paths of the repositories whose metrics are collected.
+func parseTargets(paths []string) ([]metrics.Target, error) {
+	targets := make([]metrics.Target, 0, len(paths))
+	for _, path := range paths {
+		target, err := metrics.ParseTarget(path)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, target)
+	}
+	return targets, nil
+}
+
+// writeAtomically writes a file through a temporary file in the same directory, renamed once written,
+// so that readers never see a partially written file.
+func writeAtomically(path string, write func(w io.Writer) error) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	err = write(f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(f.Name(), 0o644)
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// newMetricsExporter returns an exporter of the metrics of the given repositories, fetching the available API versions
+// from the Microsoft Learn website and module registries.
+func newMetricsExporter(paths []string, includePreview bool) (*metrics.Exporter, error) {
+	targets, err := parseTargets(paths)
+	if err != nil {
+		return nil, err
+	}
+	return metrics.NewExporter(&apiversions.Client{}, scanner.Options{IncludePreview: includePreview}, targets, nil), nil
+}
+
+// init initializes the metrics command.
+func init() {
+	// Local flags
+
+	// path - required
+	metricsCmd.Flags().StringArrayVarP(&metricsPaths, "path", "p", nil, "repository to scan, as name=path or path (can be repeated)")
+	metricsCmd.MarkFlagRequired("path")
+
+	// include-preview - optional
+	metricsCmd.Flags().BoolVarP(&metricsIncludePreview, "include-preview", "r", false, "include preview API versions (if not set: only non-preview versions will be considered for the latest version)")
+
+	// output - optional
+	metricsCmd.Flags().StringVarP(&metricsOutput, "output", "o", "", "file to write the metrics to (if not set: standard output)")
+
+	// include, exclude, ignore-files, hidden - optional
+	addFilterFlags(metricsCmd, &metricsFilter)
+
+	// Examples
+	metricsCmd.Example = `
+Write the metrics of two repositories:
+  bruh metrics --path infra=./repos/infra --path ./repos/network
+
+Write the metrics for the textfile collector of the node exporter:
+  bruh metrics --path ./repos/infra --output /var/lib/node_exporter/textfile_collector/bruh.prom`
+}
@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/christosgalano/bruh/internal/types"
 	"github.com/olekukonko/tablewriter"
@@ -13,56 +16,89 @@ import (
 func printFileNormal(bicepFile *types.BicepFile, filename string, outdated bool, mode types.Mode) {
 	fmt.Printf("%s:\n", filename)
 	for _, resource := range bicepFile.Resources {
-		latestAPIVersion := resource.AvailableAPIVersions[0]
+		latestAPIVersion := resource.LatestAPIVersion()
 		if mode == types.ModeScan {
-			if resource.CurrentAPIVersion != latestAPIVersion {
-				fmt.Printf("  - %s is using %s while the latest version is %s\n", resource.ID, resource.CurrentAPIVersion, latestAPIVersion)
-			} else if !outdated {
-				fmt.Printf("  - %s is using the latest version %s\n", resource.ID, resource.CurrentAPIVersion)
+			switch resource.Status() {
+			case types.StatusUnresolved:
+				fmt.Printf("  - %s is using API version %s, which is not statically resolvable\n", resource.Label(), resource.CurrentAPIVersion)
+			case types.StatusUnknown:
+				fmt.Printf("  - %s is an %s%s\n", resource.Label(), unknownKind(resource), suggestionsHint(resource))
+			case types.StatusPromotable:
+				fmt.Printf("  - %s is using preview version %s while GA version %s is available%s\n", resource.Label(), resource.CurrentAPIVersion, resource.GAAPIVersion(), behindHint(resource))
+				printBreakingChanges(resource)
+				printResourceChangelog(resource)
+			case types.StatusOutdated:
+				fmt.Printf("  - %s is using %s while the latest version is %s%s\n", resource.Label(), resource.CurrentAPIVersion, latestAPIVersion, behindHint(resource))
+				printBreakingChanges(resource)
+				printResourceChangelog(resource)
+			default:
+				if !outdated {
+					fmt.Printf("  - %s is using the latest version %s\n", resource.Label(), resource.CurrentAPIVersion)
+				}
 			}
+		} else if resource.Unresolved {
+			fmt.Printf("  ! Skipped %s: API version %s is not statically resolvable\n", resource.Label(), resource.CurrentAPIVersion)
+		} else if resource.Unknown {
+			fmt.Printf("  ! Skipped %s: %s%s\n", resource.Label(), unknownKind(resource), suggestionsHint(resource))
+		} else if resource.Skipped && len(resource.BreakingChanges) == 0 {
+			fmt.Printf("  ! Skipped %s: kept version %s\n", resource.Label(), resource.CurrentAPIVersion)
+		} else if resource.Skipped {
+			fmt.Printf("  ! Skipped %s: version %s has %d breaking change(s)\n", resource.Label(), latestAPIVersion, len(resource.BreakingChanges))
+			printBreakingChanges(resource)
+		} else if resource.Pinned {
+			fmt.Printf("  = Pinned %s to version %s\n", resource.Label(), resource.CurrentAPIVersion)
 		} else {
-			fmt.Printf("  + Updated %s to version %s\n", resource.ID, resource.CurrentAPIVersion)
+			fmt.Printf("  + Updated %s to version %s\n", resource.Label(), resource.CurrentAPIVersion)
+			printBreakingChanges(resource)
 		}
 	}
+	if mode == types.ModeScan {
+		fmt.Printf("  Drift: %s\n", driftSummary(bicepFile.Drift(time.Now())))
+	}
 	fmt.Println()
 }
 
 // printFileTable prints the file's information in tabular format.
 func printFileTable(bicepFile *types.BicepFile, outdated bool) {
+	now := time.Now()
 	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader([]string{"Resource", "Current API Version", "Latest API Version"})
+	table.SetHeader(append([]string{"Resource", "Current API Version", "Latest API Version"}, driftHeader...))
 	table.SetBorders(tablewriter.Border{Left: true, Top: true, Right: true, Bottom: true})
-	table.SetColumnAlignment([]int{tablewriter.ALIGN_LEFT, tablewriter.ALIGN_CENTER, tablewriter.ALIGN_CENTER})
+	table.SetColumnAlignment([]int{tablewriter.ALIGN_LEFT, tablewriter.ALIGN_CENTER, tablewriter.ALIGN_CENTER, tablewriter.ALIGN_CENTER, tablewriter.ALIGN_CENTER, tablewriter.ALIGN_CENTER})
 
 	fmt.Printf("%s:\n", bicepFile.Path)
 	for _, resource := range bicepFile.Resources {
-		if outdated && (resource.CurrentAPIVersion == resource.AvailableAPIVersions[0]) {
+		if outdated && resource.Status() == types.StatusLatest {
 			continue
 		}
-		table.Append([]string{resource.ID, resource.CurrentAPIVersion, resource.AvailableAPIVersions[0]})
+		table.Append(append([]string{resource.Label(), resource.CurrentAPIVersion, latestColumn(resource)}, driftColumns(resource, now)...))
 	}
 	table.Render()
+	fmt.Printf("Drift: %s\n", driftSummary(bicepFile.Drift(now)))
 	fmt.Println()
 }
 
 // printFileTable prints the file's information in tabular Markdown format.
 func printFileMarkdown(bicepFile *types.BicepFile, outdated bool) {
+	now := time.Now()
 	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader([]string{"Resource", "Current API Version", "Latest API Version"})
-	table.SetColumnAlignment([]int{tablewriter.ALIGN_LEFT, tablewriter.ALIGN_CENTER, tablewriter.ALIGN_CENTER})
+	table.SetHeader(append([]string{"Resource", "Current API Version", "Latest API Version"}, driftHeader...))
+	table.SetColumnAlignment([]int{tablewriter.ALIGN_LEFT, tablewriter.ALIGN_CENTER, tablewriter.ALIGN_CENTER, tablewriter.ALIGN_CENTER, tablewriter.ALIGN_CENTER, tablewriter.ALIGN_CENTER})
 
 	// Markdown specific
+	table.SetAutoWrapText(false)
 	table.SetCenterSeparator("|")
 	table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
 
 	fmt.Printf("%s:\n", bicepFile.Path)
 	for _, resource := range bicepFile.Resources {
-		if outdated && (resource.CurrentAPIVersion == resource.AvailableAPIVersions[0]) {
+		if outdated && resource.Status() == types.StatusLatest {
 			continue
 		}
-		table.Append([]string{resource.ID, resource.CurrentAPIVersion, resource.AvailableAPIVersions[0]})
+		table.Append(append([]string{resource.Label(), resource.CurrentAPIVersion, latestColumn(resource)}, driftColumns(resource, now)...))
 	}
 	table.Render()
+	fmt.Printf("\n**Drift**: %s\n", driftSummary(bicepFile.Drift(now)))
 	fmt.Println()
 }
 
@@ -74,48 +110,64 @@ func printDirectoryNormal(bicepDirectory *types.BicepDirectory, outdated bool, m
 	}
 	fmt.Printf("%s:\n\n", absolutePath)
 	for i := range bicepDirectory.Files {
-		filename, err := filepath.Rel(bicepDirectory.Path, bicepDirectory.Files[i].Path)
-		if err != nil {
-			filename = bicepDirectory.Files[i].Path
+		printFileNormal(&bicepDirectory.Files[i], fileLabel(bicepDirectory.Path, bicepDirectory.Files[i]), outdated, mode)
+	}
+
+	if mode == types.ModeScan {
+		now := time.Now()
+		fmt.Print("Drift (highest score first):\n")
+		for _, drift := range bicepDirectory.Drifts(now) {
+			fmt.Printf("  - %s: %s\n", relativeDriftPath(bicepDirectory.Path, drift.Path, true), driftSummary(drift))
 		}
-		printFileNormal(&bicepDirectory.Files[i], filename, outdated, mode)
+		for _, drift := range bicepDirectory.FileDrifts(now) {
+			fmt.Printf("  - %s: %s\n", relativeDriftPath(bicepDirectory.Path, drift.Path, false), driftSummary(drift))
+		}
+		fmt.Println()
 	}
 }
 
 // printDirectoryTable prints the directory's information in tabular format.
 func printDirectoryTable(bicepDirectory *types.BicepDirectory, outdated bool) {
+	now := time.Now()
 	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader([]string{"File", "Resource", "Current API Version", "Latest API Version"})
+	table.SetHeader(append([]string{"File", "Resource", "Current API Version", "Latest API Version"}, driftHeader...))
 	table.SetBorders(tablewriter.Border{Left: true, Top: true, Right: true, Bottom: true})
-	table.SetColumnAlignment([]int{tablewriter.ALIGN_LEFT, tablewriter.ALIGN_LEFT, tablewriter.ALIGN_CENTER, tablewriter.ALIGN_CENTER})
+	table.SetColumnAlignment([]int{tablewriter.ALIGN_LEFT, tablewriter.ALIGN_LEFT, tablewriter.ALIGN_CENTER, tablewriter.ALIGN_CENTER, tablewriter.ALIGN_CENTER, tablewriter.ALIGN_CENTER, tablewriter.ALIGN_CENTER})
 	table.SetAutoMergeCellsByColumnIndex([]int{0})
 	table.SetRowLine(true)
 
 	fmt.Printf("%s:\n\n", bicepDirectory.Path)
 	for _, file := range bicepDirectory.Files {
 		for _, resource := range file.Resources {
-			filename, err := filepath.Rel(bicepDirectory.Path, file.Path)
-			if err != nil {
-				filename = file.Path
-			}
-			if outdated && (resource.CurrentAPIVersion == resource.AvailableAPIVersions[0]) {
+			filename := fileLabel(bicepDirectory.Path, file)
+			if outdated && resource.Status() == types.StatusLatest {
 				continue
 			}
-			table.Append([]string{filename, resource.ID, resource.CurrentAPIVersion, resource.AvailableAPIVersions[0]})
+			table.Append(append([]string{filename, resource.Label(), resource.CurrentAPIVersion, latestColumn(resource)}, driftColumns(resource, now)...))
 		}
 	}
 	table.Render()
 	fmt.Println()
+
+	rollup := tablewriter.NewWriter(os.Stdout)
+	rollup.SetHeader(rollupHeader)
+	rollup.SetBorders(tablewriter.Border{Left: true, Top: true, Right: true, Bottom: true})
+	rollup.SetColumnAlignment([]int{tablewriter.ALIGN_LEFT, tablewriter.ALIGN_CENTER, tablewriter.ALIGN_CENTER, tablewriter.ALIGN_CENTER, tablewriter.ALIGN_CENTER, tablewriter.ALIGN_CENTER, tablewriter.ALIGN_CENTER})
+	rollup.AppendBulk(rollupRows(bicepDirectory, now))
+	rollup.Render()
+	fmt.Println()
 }
 
 // printDirectoryMarkdown prints the directory's information in tabular Markdown format.
 func printDirectoryMarkdown(bicepDirectory *types.BicepDirectory, outdated bool) {
+	now := time.Now()
 	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader([]string{"File", "Resource", "Current API Version", "Latest API Version"})
-	table.SetColumnAlignment([]int{tablewriter.ALIGN_LEFT, tablewriter.ALIGN_LEFT, tablewriter.ALIGN_CENTER, tablewriter.ALIGN_CENTER})
+	table.SetHeader(append([]string{"File", "Resource", "Current API Version", "Latest API Version"}, driftHeader...))
+	table.SetColumnAlignment([]int{tablewriter.ALIGN_LEFT, tablewriter.ALIGN_LEFT, tablewriter.ALIGN_CENTER, tablewriter.ALIGN_CENTER, tablewriter.ALIGN_CENTER, tablewriter.ALIGN_CENTER, tablewriter.ALIGN_CENTER})
 	table.SetAutoMergeCellsByColumnIndex([]int{0})
 
 	// Markdown specific
+	table.SetAutoWrapText(false)
 	table.SetRowLine(false)
 	table.SetCenterSeparator("|")
 	table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
@@ -123,16 +175,203 @@ func printDirectoryMarkdown(bicepDirectory *types.BicepDirectory, outdated bool)
 	fmt.Printf("### %s\n\n", bicepDirectory.Path)
 	for _, file := range bicepDirectory.Files {
 		for _, resource := range file.Resources {
-			filename, err := filepath.Rel(bicepDirectory.Path, file.Path)
-			if err != nil {
-				filename = file.Path
-			}
-			if outdated && (resource.CurrentAPIVersion == resource.AvailableAPIVersions[0]) {
+			filename := fileLabel(bicepDirectory.Path, file)
+			if outdated && resource.Status() == types.StatusLatest {
 				continue
 			}
-			table.Append([]string{filename, resource.ID, resource.CurrentAPIVersion, resource.AvailableAPIVersions[0]})
+			table.Append(append([]string{filename, resource.Label(), resource.CurrentAPIVersion, latestColumn(resource)}, driftColumns(resource, now)...))
 		}
 	}
 	table.Render()
 	fmt.Println()
+
+	rollup := tablewriter.NewWriter(os.Stdout)
+	rollup.SetHeader(rollupHeader)
+	rollup.SetColumnAlignment([]int{tablewriter.ALIGN_LEFT, tablewriter.ALIGN_CENTER, tablewriter.ALIGN_CENTER, tablewriter.ALIGN_CENTER, tablewriter.ALIGN_CENTER, tablewriter.ALIGN_CENTER, tablewriter.ALIGN_CENTER})
+
+	// Markdown specific
+	rollup.SetAutoWrapText(false)
+	rollup.SetCenterSeparator("|")
+	rollup.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
+
+	fmt.Print("#### Drift\n\n")
+	rollup.AppendBulk(rollupRows(bicepDirectory, now))
+	rollup.Render()
+	fmt.Println()
+}
+
+var (
+	// driftHeader is the header of the drift columns of each resource.
+	driftHeader = []string{"Versions Behind", "Age (Days)", "Gap (Days)"}
+
+	// rollupHeader is the header of the drift roll-up of each directory and file.
+	rollupHeader = []string{"Path", "Resources", "Outdated", "Versions Behind", "Oldest (Days)", "Max Gap (Days)", "Drift Score"}
+)
+
+// driftColumns returns the values of the drift columns for the given resource at the given time.
+func driftColumns(resource types.Resource, now time.Time) []string {
+	if resource.Unresolved {
+		return []string{"-", "-", "-"}
+	}
+	if resource.Unknown {
+		return []string{"-", strconv.Itoa(resource.AgeDays(now)), "-"}
+	}
+	return []string{strconv.Itoa(resource.VersionsBehind()), strconv.Itoa(resource.AgeDays(now)), strconv.Itoa(resource.GapDays())}
+}
+
+// behindHint returns a hint with the number of versions and days the given resource is behind the latest API version.
+// Registry modules have no dates, so only the number of versions is returned for them.
+func behindHint(resource types.Resource) string {
+	if resource.Module {
+		return fmt.Sprintf(" (%d version(s) behind)", resource.VersionsBehind())
+	}
+	return fmt.Sprintf(" (%d version(s) and %d days behind)", resource.VersionsBehind(), resource.GapDays())
+}
+
+// driftSummary returns a one-line summary of the given drift.
+func driftSummary(drift types.Drift) string {
+	return fmt.Sprintf("score %.1f, %d/%d outdated, %d version(s) behind, oldest version %d days old, max gap %d days",
+		drift.Score, drift.Outdated, drift.Resources, drift.VersionsBehind, drift.MaxAgeDays, drift.MaxGapDays)
+}
+
+// relativeDriftPath returns the path of a drift relative to the given directory, with a trailing separator for directories.
+func relativeDriftPath(dirPath, path string, isDir bool) string {
+	rel, err := filepath.Rel(dirPath, path)
+	if err != nil {
+		rel = path
+	}
+	if isDir {
+		rel += string(filepath.Separator)
+	}
+	return rel
+}
+
+// rollupRows returns the rows of the drift roll-up of the given directory at the given time:
+// first each directory and then each file, both with the highest score first.
+func rollupRows(bicepDirectory *types.BicepDirectory, now time.Time) [][]string {
+	rows := [][]string{}
+	row := func(path string, drift types.Drift) []string {
+		return []string{
+			path,
+			strconv.Itoa(drift.Resources),
+			strconv.Itoa(drift.Outdated),
+			strconv.Itoa(drift.VersionsBehind),
+			strconv.Itoa(drift.MaxAgeDays),
+			strconv.Itoa(drift.MaxGapDays),
+			strconv.FormatFloat(drift.Score, 'f', 1, 64),
+		}
+	}
+	for _, drift := range bicepDirectory.Drifts(now) {
+		rows = append(rows, row(relativeDriftPath(bicepDirectory.Path, drift.Path, true), drift))
+	}
+	for _, drift := range bicepDirectory.FileDrifts(now) {
+		rows = append(rows, row(relativeDriftPath(bicepDirectory.Path, drift.Path, false), drift))
+	}
+	return rows
+}
+
+// latestColumn returns the value of the latest API version column for the given resource.
+// For resources with an unknown type, it returns a note along with any suggestions, for resources with an unresolved API version a note,
+// while for promotable resources and resources with breaking changes, it also returns the GA version and the number of changes.
+func latestColumn(resource types.Resource) string {
+	if resource.Unresolved {
+		return "not statically resolvable"
+	}
+	if resource.Unknown {
+		return unknownKind(resource) + suggestionsHint(resource)
+	}
+	column := resource.LatestAPIVersion()
+	if ga := resource.GAAPIVersion(); resource.Promotable() && ga == column {
+		column += " (GA)"
+	} else if resource.Promotable() {
+		column += fmt.Sprintf(" (GA %s available)", ga)
+	}
+	if len(resource.BreakingChanges) > 0 {
+		column += fmt.Sprintf(" (%d breaking change(s))", len(resource.BreakingChanges))
+	}
+	return column
+}
+
+// printBreakingChanges prints the breaking changes of the given resource, one per line.
+func printBreakingChanges(resource types.Resource) {
+	for _, change := range resource.BreakingChanges {
+		fmt.Printf("    ! %s\n", change)
+	}
+}
+
+// fileLabel returns the path of a file relative to the given directory,
+// followed by the module chain through which it is reached from the entry file, if any (e.g. modules/plan.json (via main.bicep > modules/app.bicep)).
+func fileLabel(dirPath string, file types.BicepFile) string {
+	relative := func(path string) string {
+		if rel, err := filepath.Rel(dirPath, path); err == nil {
+			return rel
+		}
+		return path
+	}
+	if len(file.Chain) == 0 {
+		return relative(file.Path)
+	}
+	chain := make([]string, 0, len(file.Chain))
+	for _, path := range file.Chain {
+		chain = append(chain, relative(path))
+	}
+	return fmt.Sprintf("%s (via %s)", relative(file.Path), strings.Join(chain, " > "))
+}
+
+// unknownKind returns the description of an unknown resource: an unknown module for registry modules, or an unknown resource type otherwise.
+func unknownKind(resource types.Resource) string {
+	if resource.Module {
+		return "unknown module"
+	}
+	return "unknown resource type"
+}
+
+// suggestionsHint returns a hint listing the suggested resource types of an unknown resource, if any.
+func suggestionsHint(resource types.Resource) string {
+	if len(resource.Suggestions) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (did you mean %s?)", strings.Join(resource.Suggestions, ", "))
+}
+
+// printResourceChangelog prints the property changes of the given resource, one per line.
+func printResourceChangelog(resource types.Resource) {
+	for _, change := range resource.Changelog {
+		fmt.Printf("    * %s\n", change)
+	}
+}
+
+// printChangelog prints a section with the property changes of all outdated resources in the given files.
+// File paths are printed relative to dirPath, if set. If markdown is true, the section is printed in Markdown format.
+func printChangelog(bicepFiles []types.BicepFile, dirPath string, markdown bool) {
+	if markdown {
+		fmt.Print("#### Changelog\n\n")
+	} else {
+		fmt.Print("Changelog:\n\n")
+	}
+	for _, file := range bicepFiles {
+		filename := file.Path
+		if dirPath != "" {
+			if rel, err := filepath.Rel(dirPath, file.Path); err == nil {
+				filename = rel
+			}
+		}
+		for _, resource := range file.Resources {
+			if len(resource.Changelog) == 0 {
+				continue
+			}
+			if markdown {
+				fmt.Printf("- **%s** `%s` (%s -> %s)\n", filename, resource.ID, resource.CurrentAPIVersion, resource.LatestAPIVersion())
+				for _, change := range resource.Changelog {
+					fmt.Printf("  - `%s`\n", change)
+				}
+			} else {
+				fmt.Printf("%s: %s %s -> %s\n", filename, resource.ID, resource.CurrentAPIVersion, resource.LatestAPIVersion())
+				for _, change := range resource.Changelog {
+					fmt.Printf("  * %s\n", change)
+				}
+			}
+		}
+	}
+	fmt.Println()
 }
@@ -44,6 +44,14 @@ func Execute() error {
 func addSubCommands() {
 	rootCmd.AddCommand(scanCmd)
 	rootCmd.AddCommand(updateCmd)
+	rootCmd.AddCommand(diffVersionsCmd)
+	rootCmd.AddCommand(graphCmd)
+	rootCmd.AddCommand(diffCmd)
+	rootCmd.AddCommand(tuiCmd)
+	rootCmd.AddCommand(lspCmd)
+	rootCmd.AddCommand(serveCmd)
+	rootCmd.AddCommand(metricsCmd)
+	rootCmd.AddCommand(actionCmd)
 }
 
 // init initializes the root command.
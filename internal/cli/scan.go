@@ -4,19 +4,38 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 
 	"github.com/spf13/cobra"
 
-	"github.com/christosgalano/bruh/internal/apiversions"
 	"github.com/christosgalano/bruh/internal/bicep"
+	"github.com/christosgalano/bruh/internal/git"
+	"github.com/christosgalano/bruh/internal/notify"
+	"github.com/christosgalano/bruh/internal/scanner"
+	"github.com/christosgalano/bruh/internal/schema"
 	"github.com/christosgalano/bruh/internal/types"
 )
 
+const (
+	// exitCodePromotable is the exit code of the scan command when a resource uses a preview API version with a GA one available.
+	exitCodePromotable = 2
+)
+
 var (
 	scanPath           string
+	scanEntry          string
+	scanFilter         bicep.Filter
+	scanSince          string
+	scanStaged         bool
+	scanChangedLines   bool
+	scanRev            string
+	scanWatch          bool
+	scanWebhook        webhookFlags
 	output             string
 	outdated           bool
 	scanIncludePreview bool
+	scanTypesDir       string
+	changelog          bool
 )
 
 // scanCmd represents the scan command.
@@ -24,7 +43,30 @@ var scanCmd = &cobra.Command{
 	Use:   "scan",
 	Short: "Scan a Bicep file or a directory containing Bicep files",
 	Long: `Scan a Bicep file or a directory containing Bicep files and
-print out information regarding the API versions of Azure resources.`,
+print out information regarding the API versions of Azure resources.
+
+ARM JSON templates (.json) are scanned as well, including nested resources and the inline templates of nested deployments.
+API versions passed to functions such as reference and listKeys are reported along with the function, when the resource type can be inferred.
+Terraform files (.tf) are scanned for azapi_resource, azapi_update_resource and azapi_resource_action blocks.
+Registry modules (br: and br/<alias>: references) are checked for newer semantic version tags, resolving aliases with the closest bicepconfig.json.
+
+With --entry, only the files reachable from an entry Bicep file (e.g. main.bicep) through local module references and compile-time imports
+are scanned, each one attributed to the module chain that pulls it in. Cyclic references result in an error.
+
+With --since or --staged, only the files changed in the local git repository are scanned: those changed since the merge base of a ref and HEAD
+(including uncommitted changes) or the staged ones. With --changed-lines, only the resources whose API version is on an added or modified line are reported.
+
+With --rev, the files are read from a revision of the local git repository (e.g. a release tag) straight from the object store,
+so the API versions deployed at a past release can be audited without checking it out. With --ignore-files, the ignore files are read from the revision as well.
+
+With --watch, the path (or the directory of the entry file) is watched for changes after the first scan, and the changed files are
+reparsed and reported again until interrupted, reusing the API versions already fetched.
+
+With --webhook, a summary of the scan is posted to the given URL once printed: the number of resources per status and the top outdated
+resources, as a Slack message, a Microsoft Teams Adaptive Card or a generic JSON payload (optionally rendered by a Go template).
+
+Resources using a preview API version while a GA version of the same date or newer is available are reported separately,
+and the command exits with code 2, even when preview API versions are included.`,
 	//revive:disable:unused-parameter
 	Run: func(cmd *cobra.Command, args []string) {
 		// Invalid output format
@@ -34,28 +76,80 @@ print out information regarding the API versions of Azure resources.`,
 			os.Exit(1)
 		}
 
-		// Invalid path
-		fs, err := os.Stat(scanPath)
-		if err != nil {
-			if errors.Is(err, os.ErrNotExist) {
-				fmt.Fprintf(os.Stderr, "Error: no such file or directory %q\n", scanPath)
-			} else {
-				fmt.Fprintln(os.Stderr, err)
+		// Changelog without type definitions
+		if changelog && scanTypesDir == "" {
+			fmt.Fprintln(os.Stderr, "Error: --changelog requires --types-dir")
+			cmd.Usage()
+			os.Exit(1)
+		}
+
+		// Changed lines without changed files
+		if scanChangedLines && scanSince == "" && !scanStaged {
+			fmt.Fprintln(os.Stderr, "Error: --changed-lines requires --since or --staged")
+			cmd.Usage()
+			os.Exit(1)
+		}
+
+		// Revision without a path
+		if scanRev != "" && scanPath == "" {
+			fmt.Fprintln(os.Stderr, "Error: --rev requires --path")
+			cmd.Usage()
+			os.Exit(1)
+		}
+
+		// Invalid path, unless read from a revision
+		target := scanPath
+		if scanEntry != "" {
+			target = scanEntry
+		}
+		isDir := true
+		if scanRev == "" {
+			fs, err := os.Stat(target)
+			if err != nil {
+				if errors.Is(err, os.ErrNotExist) {
+					fmt.Fprintf(os.Stderr, "Error: no such file or directory %q\n", target)
+				} else {
+					fmt.Fprintln(os.Stderr, err)
+				}
+				os.Exit(1)
 			}
+			isDir = fs.IsDir()
+		}
+
+		// Invalid webhook options
+		webhook, err := scanWebhook.webhook()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+			cmd.Usage()
 			os.Exit(1)
 		}
 
+		// Scan and watch for changes
+		if scanWatch {
+			if err := watchScan(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+
 		// Scan file or directory
-		if fs.IsDir() {
-			err = scanDirectory()
+		var promotable bool
+		if isDir || scanEntry != "" || scanSince != "" || scanStaged {
+			promotable, err = scanDirectory(webhook)
 		} else {
-			err = scanFile()
+			promotable, err = scanFile(webhook)
 		}
 
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
 			os.Exit(1)
 		}
+
+		// Preview API versions that can be promoted to GA
+		if promotable {
+			os.Exit(exitCodePromotable)
+		}
 	},
 }
 
@@ -63,9 +157,13 @@ print out information regarding the API versions of Azure resources.`,
 func init() {
 	// Local flags
 
-	// path - required
+	// path - required unless entry is set
 	scanCmd.Flags().StringVarP(&scanPath, "path", "p", "", "path to bicep file or directory containing bicep files")
-	scanCmd.MarkFlagRequired("path")
+
+	// entry - required unless path is set
+	scanCmd.Flags().StringVar(&scanEntry, "entry", "", "path to an entry bicep file, scanning only the files reachable from it through local modules and imports")
+	scanCmd.MarkFlagsOneRequired("path", "entry")
+	scanCmd.MarkFlagsMutuallyExclusive("path", "entry")
 
 	// output - optional
 	scanCmd.Flags().StringVarP(&output, "output", "o", "normal", "output format (normal, table, markdown)")
@@ -76,6 +174,41 @@ func init() {
 	// include-preview - optional
 	scanCmd.Flags().BoolVarP(&scanIncludePreview, "include-preview", "r", false, "include preview API versions (if not set: only non-preview versions will be considered for the latest version)")
 
+	// types-dir - optional
+	scanCmd.Flags().StringVar(&scanTypesDir, "types-dir", "", "path to the \"generated\" directory of bicep-types-az, used to detect breaking changes (if not set: no breaking change check)")
+
+	// changelog - optional
+	scanCmd.Flags().BoolVar(&changelog, "changelog", false, "show the property changes between the current and latest API versions, requires --types-dir")
+
+	// include, exclude, ignore-files, hidden - optional
+	addFilterFlags(scanCmd, &scanFilter)
+
+	// since - optional
+	scanCmd.Flags().StringVar(&scanSince, "since", "", "scan only the files changed since the merge base of the given git ref and HEAD (e.g. origin/main)")
+
+	// staged - optional
+	scanCmd.Flags().BoolVar(&scanStaged, "staged", false, "scan only the files with staged changes")
+	scanCmd.MarkFlagsMutuallyExclusive("since", "staged")
+
+	// changed-lines - optional
+	scanCmd.Flags().BoolVar(&scanChangedLines, "changed-lines", false, "report only the resources whose API version is on a changed line, requires --since or --staged")
+
+	// rev - optional
+	scanCmd.Flags().StringVar(&scanRev, "rev", "", "scan the files at the given revision of the local git repository (e.g. v2.3.0), read from the object store")
+	scanCmd.MarkFlagsMutuallyExclusive("rev", "entry")
+	scanCmd.MarkFlagsMutuallyExclusive("rev", "since")
+	scanCmd.MarkFlagsMutuallyExclusive("rev", "staged")
+
+	// watch - optional
+	scanCmd.Flags().BoolVarP(&scanWatch, "watch", "w", false, "watch for changes after the scan, rescanning the changed files until interrupted")
+	scanCmd.MarkFlagsMutuallyExclusive("watch", "rev")
+	scanCmd.MarkFlagsMutuallyExclusive("watch", "since")
+	scanCmd.MarkFlagsMutuallyExclusive("watch", "staged")
+
+	// webhook, webhook-format, webhook-template, webhook-top - optional
+	addWebhookFlags(scanCmd, &scanWebhook)
+	scanCmd.MarkFlagsMutuallyExclusive("watch", "webhook")
+
 	// Examples
 	scanCmd.Example = `
 Scan a bicep file:
@@ -88,23 +221,76 @@ Show only outdated resources in markdown format:
   bruh scan --path ./main.bicep --outdated --output markdown
 
 Print output in table format including preview API versions:
-  bruh scan --path ./bicep/modules --output table --include-preview`
+  bruh scan --path ./bicep/modules --output table --include-preview
+
+Scan only the files deployed by an entry file:
+  bruh scan --entry ./main.bicep
+
+Scan only the files changed in a pull request:
+  bruh scan --path . --since origin/main
+
+Scan only the staged lines in a pre-commit hook:
+  bruh scan --path . --staged --changed-lines
+
+Rescan a directory whenever a file changes:
+  bruh scan --path ./bicep --watch
+
+Audit the API versions deployed at a past release:
+  bruh scan --path ./infra --rev v2.3.0
+
+Scan a directory honouring .gitignore and .bruhignore files, skipping samples:
+  bruh scan --path ./bicep --ignore-files --exclude "samples/**"
+
+Detect breaking changes using a local clone of bicep-types-az:
+  bruh scan --path ./bicep/modules --types-dir ./bicep-types-az/generated
+
+Post a summary of a nightly scan to a Slack channel:
+  bruh scan --path ./bicep --webhook https://hooks.slack.com/services/... --webhook-format slack
+
+Include the property changelog of outdated resources:
+  bruh scan --path ./bicep/modules --types-dir ./bicep-types-az/generated --changelog`
 }
 
 // scanFile parses a file, fetches the latest API versions of Azure resources and then prints out information regarding the status of those resources.
 // If outdated is true, only outdated resources are printed.
 // If includePreview is true, preview API versions are also considered.
-func scanFile() error {
+// If typesDir is set, the breaking changes of the latest API versions are also detected.
+// If changelog is true, the property changes between the current and latest API versions are also printed.
+// If webhook is not nil, a summary of the scan is posted to it.
+// It returns true if any resource uses a preview API version while a GA one of the same date or newer is available.
+func scanFile(webhook *notify.Webhook) (bool, error) {
 	bicepFile, err := bicep.ParseFile(scanPath)
 	if err != nil {
-		return err
+		return false, err
 	}
 
-	err = apiversions.UpdateBicepFile(bicepFile, scanIncludePreview)
+	session := scanner.New(scanner.Options{IncludePreview: scanIncludePreview}, nil, nil)
+	err = session.ResolveFile(bicepFile)
 	if err != nil {
-		return err
+		return false, err
 	}
 
+	if scanTypesDir != "" {
+		idx, err := schema.Load(scanTypesDir)
+		if err != nil {
+			return false, err
+		}
+		if err := schema.CheckBicepFile(idx, bicepFile, changelog); err != nil {
+			return false, err
+		}
+	}
+
+	printScanFile(bicepFile)
+
+	if err := sendSummary(webhook, &types.BicepDirectory{Path: filepath.Dir(bicepFile.Path), Files: []types.BicepFile{*bicepFile}}); err != nil {
+		return false, err
+	}
+
+	return hasPromotable(*bicepFile), nil
+}
+
+// printScanFile prints out the status of the resources of a scanned file in the selected output format.
+func printScanFile(bicepFile *types.BicepFile) {
 	switch output {
 	case "normal":
 		printFileNormal(bicepFile, bicepFile.Path, outdated, types.ModeScan)
@@ -114,23 +300,51 @@ func scanFile() error {
 		printFileMarkdown(bicepFile, outdated)
 	}
 
-	return nil
+	if changelog && output != "normal" {
+		printChangelog([]types.BicepFile{*bicepFile}, "", output == "markdown")
+	}
 }
 
-// scanDirectory parses a directory, fetches the latest API versions of Azure resources and then prints out information regarding the status of those resources.
+// scanDirectory parses a directory (or the files reachable from an entry file, or the changed files, if set), fetches the latest API versions of Azure resources and then prints out information regarding the status of those resources.
 // If outdated is true, only outdated resources are printed.
 // If includePreview is true, preview API versions are also considered.
-func scanDirectory() error {
-	bicepDirectory, err := bicep.ParseDirectory(scanPath)
+// If typesDir is set, the breaking changes of the latest API versions are also detected.
+// If changelog is true, the property changes between the current and latest API versions are also printed.
+// If webhook is not nil, a summary of the scan is posted to it.
+// It returns true if any resource uses a preview API version while a GA one of the same date or newer is available.
+func scanDirectory(webhook *notify.Webhook) (bool, error) {
+	bicepDirectory, err := parseScanTarget()
 	if err != nil {
-		return err
+		return false, err
 	}
 
-	err = apiversions.UpdateBicepDirectory(bicepDirectory, scanIncludePreview)
+	session := scanner.New(scanner.Options{IncludePreview: scanIncludePreview, Filter: scanFilter}, nil, nil)
+	err = session.ResolveFiles(bicepDirectory.Files)
 	if err != nil {
-		return err
+		return false, err
+	}
+
+	if scanTypesDir != "" {
+		idx, err := schema.Load(scanTypesDir)
+		if err != nil {
+			return false, err
+		}
+		if err := schema.CheckBicepDirectory(idx, bicepDirectory, changelog); err != nil {
+			return false, err
+		}
 	}
 
+	printScanDirectory(bicepDirectory)
+
+	if err := sendSummary(webhook, bicepDirectory); err != nil {
+		return false, err
+	}
+
+	return hasPromotable(bicepDirectory.Files...), nil
+}
+
+// printScanDirectory prints out the status of the resources of a scanned directory in the selected output format.
+func printScanDirectory(bicepDirectory *types.BicepDirectory) {
 	switch output {
 	case "normal":
 		printDirectoryNormal(bicepDirectory, outdated, types.ModeScan)
@@ -140,5 +354,93 @@ func scanDirectory() error {
 		printDirectoryMarkdown(bicepDirectory, outdated)
 	}
 
-	return nil
+	if changelog && output != "normal" {
+		printChangelog(bicepDirectory.Files, bicepDirectory.Path, output == "markdown")
+	}
+}
+
+// parseScanTarget parses the files to scan: the files reachable from the entry file or all the files of the directory,
+// limited to the files changed in the local git repository if since or staged is set. If changedLines is true,
+// only the resources whose API version is on an added or modified line are kept. If rev is set, the files of the path at that revision are parsed instead.
+func parseScanTarget() (*types.BicepDirectory, error) {
+	if scanRev != "" {
+		rev, err := git.NewRevision(repositoryDir(scanPath), scanRev)
+		if err != nil {
+			return nil, err
+		}
+		return parseRevision(rev, scanPath, scanFilter)
+	}
+
+	if scanSince == "" && !scanStaged {
+		if scanEntry != "" {
+			return bicep.ParseEntry(scanEntry)
+		}
+		return bicep.ParseDirectory(scanPath, scanFilter)
+	}
+
+	// The git repository is the one containing the entry file or the path
+	target := scanPath
+	if scanEntry != "" {
+		target = scanEntry
+	}
+	dir := target
+	if fs, err := os.Stat(target); err == nil && !fs.IsDir() {
+		dir = filepath.Dir(target)
+	}
+	changes, err := git.Diff(dir, scanSince, scanStaged)
+	if err != nil {
+		return nil, err
+	}
+
+	var bicepDirectory *types.BicepDirectory
+	switch {
+	case scanEntry != "":
+		if bicepDirectory, err = bicep.ParseEntry(scanEntry); err != nil {
+			return nil, err
+		}
+		files := []types.BicepFile{}
+		for _, file := range bicepDirectory.Files {
+			if changes.Contains(file.Path) {
+				files = append(files, file)
+			}
+		}
+		bicepDirectory.Files = files
+	case dir != target:
+		files := []string{}
+		if changes.Contains(target) {
+			files = append(files, target)
+		}
+		if bicepDirectory, err = bicep.ParseFiles(dir, files, scanFilter, os.ReadFile); err != nil {
+			return nil, err
+		}
+	default:
+		if bicepDirectory, err = bicep.ParseFiles(dir, changes.Files(), scanFilter, os.ReadFile); err != nil {
+			return nil, err
+		}
+	}
+
+	if scanChangedLines {
+		for i := range bicepDirectory.Files {
+			resources := []types.Resource{}
+			for _, resource := range bicepDirectory.Files[i].Resources {
+				if changes.ContainsLine(bicepDirectory.Files[i].Path, resource.Line) {
+					resources = append(resources, resource)
+				}
+			}
+			bicepDirectory.Files[i].Resources = resources
+		}
+	}
+	return bicepDirectory, nil
+}
+
+// hasPromotable returns true if any resource of the given files uses a preview API version with a GA one available.
+func hasPromotable(bicepFiles ...types.BicepFile) bool {
+	for _, file := range bicepFiles {
+		for _, resource := range file.Resources {
+			if resource.Status() == types.StatusPromotable {
+				return true
+			}
+		}
+	}
+	return false
 }
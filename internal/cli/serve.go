@@ -0,0 +1,133 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/christosgalano/bruh/internal/apiversions"
+	"github.com/christosgalano/bruh/internal/server"
+)
+
+var (
+	serveAddress          string
+	serveMaxBodySize      int64
+	serveMaxFiles         int
+	serveMaxExtractedSize int64
+	serveMaxConcurrent    int
+	serveTimeout          time.Duration
+	serveCacheTTL         time.Duration
+	serveMetrics          bool
+	serveMetricsPaths     []string
+	serveMetricsInterval  time.Duration
+)
+
+// serveCmd represents the serve command.
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Run an HTTP server exposing scan and update as an API",
+	Long: `Run an HTTP server scanning and updating the files posted to it, so that other services can check API versions without the CLI.
+
+A request body is either a single file, named by the filename query parameter (main.bicep by default), or a tarball of a project
+with the Content-Type application/x-tar (or application/gzip when it is gzipped). The endpoints are:
+  - POST /v1/scan: return the findings of the resources as JSON
+  - POST /v1/update: return the updated file, or a unified diff patch of the updated files of a tarball (or with format=patch),
+    honoring the pins of a .bruh.json at the root of a tarball
+  - GET /healthz and GET /readyz: report that the server is running, and whether it accepts requests
+
+Both POST endpoints accept include-preview=true to consider preview API versions. Requests are limited in body size, number of files,
+extracted size and duration, and requests exceeding the number of concurrent scans are answered with 503.
+Registry module references are reported as unresolved, as the server never contacts the registries named by an upload.
+The available API versions are cached for the given time to live. The server shuts down gracefully on SIGINT and SIGTERM.
+
+With --metrics, GET /metrics exposes the API version drift of the repositories given by --metrics-path in the Prometheus text format,
+rescanning them at the given interval (see the metrics command for the metrics).`,
+	//revive:disable:unused-parameter
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		limits := server.Limits{
+			MaxBodySize:      serveMaxBodySize,
+			MaxFiles:         serveMaxFiles,
+			MaxExtractedSize: serveMaxExtractedSize,
+			MaxConcurrent:    serveMaxConcurrent,
+			Timeout:          serveTimeout,
+		}
+		logger := log.New(os.Stderr, "bruh: ", log.LstdFlags)
+		s := server.New(&apiversions.Client{}, limits, serveCacheTTL, logger)
+		if serveMetrics {
+			exporter, err := newMetricsExporter(serveMetricsPaths, false)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+				os.Exit(1)
+			}
+			go exporter.Run(ctx, serveMetricsInterval)
+			s.Handle("/metrics", exporter)
+		}
+		if err := s.ListenAndServe(ctx, serveAddress); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// init initializes the serve command.
+func init() {
+	// Local flags
+
+	// address - optional
+	serveCmd.Flags().StringVarP(&serveAddress, "address", "a", ":8080", "address to listen on")
+
+	// max-body-size - optional
+	serveCmd.Flags().Int64Var(&serveMaxBodySize, "max-body-size", server.DefaultLimits.MaxBodySize, "maximum size of a request body in bytes (0: no limit)")
+
+	// max-files - optional
+	serveCmd.Flags().IntVar(&serveMaxFiles, "max-files", server.DefaultLimits.MaxFiles, "maximum number of files in a tarball (0: no limit)")
+
+	// max-extracted-size - optional
+	serveCmd.Flags().Int64Var(&serveMaxExtractedSize, "max-extracted-size", server.DefaultLimits.MaxExtractedSize, "maximum total size of the extracted files of a tarball in bytes (0: no limit)")
+
+	// max-concurrent - optional
+	serveCmd.Flags().IntVar(&serveMaxConcurrent, "max-concurrent", server.DefaultLimits.MaxConcurrent, "maximum number of scans and updates running at the same time (0: no limit)")
+
+	// timeout - optional
+	serveCmd.Flags().DurationVar(&serveTimeout, "timeout", server.DefaultLimits.Timeout, "maximum duration of a scan or an update (0: no limit)")
+
+	// cache-ttl - optional
+	serveCmd.Flags().DurationVar(&serveCacheTTL, "cache-ttl", time.Hour, "time to live of the cached API versions (0: never expire)")
+
+	// metrics - optional
+	serveCmd.Flags().BoolVar(&serveMetrics, "metrics", false, "expose the API version drift of the repositories given by --metrics-path at /metrics")
+
+	// metrics-path - optional
+	serveCmd.Flags().StringArrayVar(&serveMetricsPaths, "metrics-path", nil, "repository to scan for metrics, as name=path or path (can be repeated)")
+
+	// metrics-interval - optional
+	serveCmd.Flags().DurationVar(&serveMetricsInterval, "metrics-interval", time.Hour, "interval between the scans of the repositories for metrics")
+
+	serveCmd.MarkFlagsRequiredTogether("metrics", "metrics-path")
+
+	// Examples
+	serveCmd.Example = `
+Run the server on port 8080:
+  bruh serve
+
+Run the server on port 9000 with at most 8 concurrent scans:
+  bruh serve --address :9000 --max-concurrent 8
+
+Run the server exposing the metrics of two repositories, rescanned every 15 minutes:
+  bruh serve --metrics --metrics-path infra=./repos/infra --metrics-path ./repos/network --metrics-interval 15m
+
+Scan a file:
+  curl --data-binary @main.bicep 'http://localhost:8080/v1/scan?filename=main.bicep'
+
+Update a project, returning a patch:
+  tar -czf - . | curl --data-binary @- -H 'Content-Type: application/gzip' http://localhost:8080/v1/update | git apply`
+}
@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/christosgalano/bruh/internal/bicep"
+	"github.com/christosgalano/bruh/internal/scanner"
+	"github.com/christosgalano/bruh/internal/tui"
+	"github.com/christosgalano/bruh/internal/types"
+)
+
+var (
+	tuiPath           string
+	tuiInPlace        bool
+	tuiIncludePreview bool
+	tuiFilter         bicep.Filter
+)
+
+// tuiCmd represents the tui command.
+var tuiCmd = &cobra.Command{
+	Use:   "tui",
+	Short: "Explore the drift of a Bicep file or directory in a terminal interface",
+	Long: `Explore the drift of a Bicep file or a directory containing Bicep files in a full-screen terminal interface.
+
+The directories, files and resources are shown as a tree, which can be sorted by drift score (s) and filtered by namespace (n) or status (f),
+next to a detail pane with the drift of the selected directory or file, or the full version history of the selected resource.
+Resources can be marked for update (space), marking every outdated resource of a directory or file at once, and the marked ones are then
+applied (a) in place or to new files with the "_updated" suffix, as the update command does. Resources pinned in the project configuration
+are never updated past their pinned version.`,
+	//revive:disable:unused-parameter
+	Run: func(cmd *cobra.Command, args []string) {
+		// Not a terminal
+		for _, f := range []*os.File{os.Stdin, os.Stdout} {
+			if fs, err := f.Stat(); err != nil || fs.Mode()&os.ModeCharDevice == 0 {
+				fmt.Fprintln(os.Stderr, "Error: the tui command requires a terminal")
+				os.Exit(1)
+			}
+		}
+
+		// Invalid path
+		if _, err := os.Stat(tuiPath); err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				fmt.Fprintf(os.Stderr, "Error: no such file or directory %q\n", tuiPath)
+			} else {
+				fmt.Fprintln(os.Stderr, err)
+			}
+			os.Exit(1)
+		}
+
+		if err := runTUI(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// init initializes the tui command.
+func init() {
+	// Local flags
+
+	// path - required
+	tuiCmd.Flags().StringVarP(&tuiPath, "path", "p", "", "path to bicep file or directory containing bicep files")
+	tuiCmd.MarkFlagRequired("path")
+
+	// in-place - optional
+	tuiCmd.Flags().BoolVarP(&tuiInPlace, "in-place", "i", false, "update the marked resources in place (if not set: create new files with \"_updated.bicep\" extension)")
+
+	// include-preview - optional
+	tuiCmd.Flags().BoolVarP(&tuiIncludePreview, "include-preview", "r", false, "include preview API versions (if not set: only non-preview versions will be considered for the latest version)")
+
+	// include, exclude, ignore-files, hidden - optional
+	addFilterFlags(tuiCmd, &tuiFilter)
+
+	// Examples
+	tuiCmd.Example = `
+Explore a directory and update the marked resources in place:
+  bruh tui --path ./bicep --in-place
+
+Explore a directory including preview API versions, skipping samples:
+  bruh tui --path ./bicep --include-preview --exclude "samples/**"`
+}
+
+// runTUI parses the given file or directory, fetches the latest API versions of Azure resources and then runs the terminal interface.
+func runTUI() error {
+	fs, err := os.Stat(tuiPath)
+	if err != nil {
+		return err
+	}
+	session := scanner.New(scanner.Options{IncludePreview: tuiIncludePreview, Filter: tuiFilter}, nil, nil)
+	bicepDirectory, err := session.Scan(tuiPath)
+	if err != nil {
+		return err
+	}
+
+	dir := tuiPath
+	if !fs.IsDir() {
+		dir = filepath.Dir(tuiPath)
+	}
+	bicepFiles := make([]*types.BicepFile, 0, len(bicepDirectory.Files))
+	for i := range bicepDirectory.Files {
+		bicepFiles = append(bicepFiles, &bicepDirectory.Files[i])
+	}
+	if _, err := applyPins(dir, bicepFiles...); err != nil {
+		return err
+	}
+
+	update := func(bicepFile *types.BicepFile) error {
+		return bicep.UpdateFile(bicepFile, tuiInPlace)
+	}
+	return tui.New(bicepDirectory, update, time.Now()).Run(os.Stdin, os.Stdout)
+}
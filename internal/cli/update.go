@@ -4,11 +4,16 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"time"
 
 	"github.com/spf13/cobra"
 
-	"github.com/christosgalano/bruh/internal/apiversions"
 	"github.com/christosgalano/bruh/internal/bicep"
+	"github.com/christosgalano/bruh/internal/config"
+	"github.com/christosgalano/bruh/internal/interactive"
+	"github.com/christosgalano/bruh/internal/scanner"
+	"github.com/christosgalano/bruh/internal/schema"
 	"github.com/christosgalano/bruh/internal/types"
 )
 
@@ -17,6 +22,10 @@ var (
 	inPlace              bool
 	updateIncludePreview bool
 	silent               bool
+	updateTypesDir       string
+	onBreaking           string
+	updateFilter         bicep.Filter
+	updateInteractive    bool
 )
 
 // updateCmd represents the update command.
@@ -24,10 +33,36 @@ var updateCmd = &cobra.Command{
 	Use:   "update",
 	Short: "Update a Bicep file or a directory containing Bicep files",
 	Long: `Update a Bicep file or a directory containing Bicep files so that each Azure resource uses the latest API version available.
-It is possible to update the files in place or create new files with "_updated.bicep" extension.`,
+It is possible to update the files in place or create new files with "_updated.bicep" extension.
+
+ARM JSON templates are updated as well (new files get the "_updated.json" extension): only the apiVersion values are rewritten,
+while API versions given as template language expressions are reported as not statically resolvable and left untouched.
+Terraform files with azapi resources are updated in the same way, but new files get the ".updated" suffix (e.g. "main.tf.updated"),
+so that Terraform does not load both files.
+The tags of registry module references are bumped to the latest semantic version tag.
+
+Resources pinned in the project configuration (the .bruh.json file closest to the path) are never updated past their pinned version.
+With --interactive, each outdated resource is reviewed in turn, showing the file and line of its API version and the candidate versions
+along with their age: the latest version can be accepted, the resource skipped, another candidate picked or the current version pinned.
+Picked and pinned versions can then be saved to the project configuration.`,
 
 	//revive:disable:unused-parameter
 	Run: func(cmd *cobra.Command, args []string) {
+		// Invalid breaking change action
+		if onBreaking != "warn" && onBreaking != "skip" {
+			fmt.Fprintf(os.Stderr, "Error: invalid breaking change action %s\n", onBreaking)
+			cmd.Usage()
+			os.Exit(1)
+		}
+
+		// Interactive mode without a terminal
+		if updateInteractive {
+			if stdin, err := os.Stdin.Stat(); err != nil || stdin.Mode()&os.ModeCharDevice == 0 {
+				fmt.Fprintln(os.Stderr, "Error: --interactive requires a terminal")
+				os.Exit(1)
+			}
+		}
+
 		// Invalid path
 		fs, err := os.Stat(updatePath)
 		if err != nil {
@@ -83,6 +118,19 @@ func init() {
 	// silent - optional
 	updateCmd.Flags().BoolVarP(&silent, "silent", "s", false, "silent mode (no output)")
 
+	// types-dir - optional
+	updateCmd.Flags().StringVar(&updateTypesDir, "types-dir", "", "path to the \"generated\" directory of bicep-types-az, used to detect breaking changes (if not set: no breaking change check)")
+
+	// on-breaking - optional
+	updateCmd.Flags().StringVar(&onBreaking, "on-breaking", "warn", "action for resources with breaking changes, requires --types-dir (warn, skip)")
+
+	// include, exclude, ignore-files, hidden - optional
+	addFilterFlags(updateCmd, &updateFilter)
+
+	// interactive - optional
+	updateCmd.Flags().BoolVar(&updateInteractive, "interactive", false, "review each outdated resource, accepting, skipping, picking another version or pinning the current one")
+	updateCmd.MarkFlagsMutuallyExclusive("interactive", "silent")
+
 	// Examples
 	updateCmd.Example = `
 Update a bicep file in place:
@@ -92,23 +140,56 @@ Update a directory including preview API versions:
   bruh update --path ./bicep/modules --include-preview
 
 Use silent mode:
-  bruh update --path ./main.bicep --silent`
+  bruh update --path ./main.bicep --silent
+
+Skip resources whose latest API version would break their declaration:
+  bruh update --path ./bicep/modules --types-dir ./bicep-types-az/generated --on-breaking skip
+
+Review each outdated resource before updating it:
+  bruh update --path ./bicep/modules --in-place --interactive`
 }
 
 // updateFile parses the given file, fetches the latest API versions for each Azure resource, and updates the file.
 // If inPlace is true, the file will be updated in place; otherwise, a new file with "_updated.bicep" extension will be created.
 // If includePreview is true, preview API versions will be included; otherwise, only non-preview versions will be considered.
+// If typesDir is set, resources with breaking changes are either updated with a warning or skipped, based on onBreaking.
+// Resources pinned in the project configuration are never updated past their pinned version, and if interactive is true, each outdated resource is reviewed first.
 func updateFile() error {
 	bicepFile, err := bicep.ParseFile(updatePath)
 	if err != nil {
 		return err
 	}
 
-	err = apiversions.UpdateBicepFile(bicepFile, updateIncludePreview)
+	session := scanner.New(scanner.Options{IncludePreview: updateIncludePreview}, nil, nil)
+	err = session.ResolveFile(bicepFile)
+	if err != nil {
+		return err
+	}
+
+	projectConfig, err := applyPins(filepath.Dir(updatePath), bicepFile)
 	if err != nil {
 		return err
 	}
 
+	if updateTypesDir != "" {
+		idx, err := schema.Load(updateTypesDir)
+		if err != nil {
+			return err
+		}
+		if err := schema.CheckBicepFile(idx, bicepFile, false); err != nil {
+			return err
+		}
+		if onBreaking == "skip" {
+			skipBreaking(bicepFile)
+		}
+	}
+
+	if updateInteractive {
+		if err := reviewUpdate(projectConfig, bicepFile); err != nil {
+			return err
+		}
+	}
+
 	err = bicep.UpdateFile(bicepFile, inPlace)
 	if err != nil {
 		return err
@@ -121,17 +202,50 @@ func updateFile() error {
 // updateDirectory parses the given directory, fetches the latest API versions for each Azure resource, and updates each file.
 // If inPlace is true, the files will be updated in place; otherwise, new files with "_updated.bicep" extension will be created.
 // If includePreview is true, preview API versions will be included; otherwise, only non-preview versions will be considered.
+// If typesDir is set, resources with breaking changes are either updated with a warning or skipped, based on onBreaking.
+// Resources pinned in the project configuration are never updated past their pinned version, and if interactive is true, each outdated resource is reviewed first.
 func updateDirectory() error {
-	bicepDirectory, err := bicep.ParseDirectory(updatePath)
+	bicepDirectory, err := bicep.ParseDirectory(updatePath, updateFilter)
+	if err != nil {
+		return err
+	}
+
+	session := scanner.New(scanner.Options{IncludePreview: updateIncludePreview, Filter: updateFilter}, nil, nil)
+	err = session.ResolveFiles(bicepDirectory.Files)
 	if err != nil {
 		return err
 	}
 
-	err = apiversions.UpdateBicepDirectory(bicepDirectory, updateIncludePreview)
+	bicepFiles := make([]*types.BicepFile, 0, len(bicepDirectory.Files))
+	for i := range bicepDirectory.Files {
+		bicepFiles = append(bicepFiles, &bicepDirectory.Files[i])
+	}
+	projectConfig, err := applyPins(updatePath, bicepFiles...)
 	if err != nil {
 		return err
 	}
 
+	if updateTypesDir != "" {
+		idx, err := schema.Load(updateTypesDir)
+		if err != nil {
+			return err
+		}
+		if err := schema.CheckBicepDirectory(idx, bicepDirectory, false); err != nil {
+			return err
+		}
+		if onBreaking == "skip" {
+			for i := range bicepDirectory.Files {
+				skipBreaking(&bicepDirectory.Files[i])
+			}
+		}
+	}
+
+	if updateInteractive {
+		if err := reviewUpdate(projectConfig, bicepFiles...); err != nil {
+			return err
+		}
+	}
+
 	err = bicep.UpdateDirectory(bicepDirectory, inPlace)
 	if err != nil {
 		return err
@@ -140,3 +254,53 @@ func updateDirectory() error {
 	printDirectoryNormal(bicepDirectory, outdated, types.ModeUpdate)
 	return nil
 }
+
+// skipBreaking excludes the resources with breaking changes from the update.
+func skipBreaking(bicepFile *types.BicepFile) {
+	for i := range bicepFile.Resources {
+		if len(bicepFile.Resources[i].BreakingChanges) > 0 {
+			bicepFile.Resources[i].Skipped = true
+		}
+	}
+}
+
+// applyPins caps the available API versions of the resources pinned in the project configuration closest to the given directory,
+// and returns the configuration.
+func applyPins(dir string, bicepFiles ...*types.BicepFile) (*config.Config, error) {
+	projectConfig, err := config.Find(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range bicepFiles {
+		projectConfig.Apply(file)
+	}
+	return projectConfig, nil
+}
+
+// reviewUpdate prompts for the action of each outdated resource of the given files on the terminal,
+// and offers to save the picked and pinned versions to the project configuration.
+func reviewUpdate(projectConfig *config.Config, bicepFiles ...*types.BicepFile) error {
+	prompter := interactive.New(os.Stdin, os.Stdout, time.Now())
+	decisions, err := prompter.Review(bicepFiles...)
+	if err != nil {
+		return err
+	}
+
+	pins := 0
+	for _, decision := range decisions {
+		if decision.Action == interactive.ActionPick || decision.Action == interactive.ActionPin {
+			projectConfig.SetPin(decision.Path, decision.Resource, decision.Occurrence, decision.Version)
+			pins++
+		}
+	}
+	if pins == 0 {
+		return nil
+	}
+
+	save, err := prompter.Confirm(fmt.Sprintf("Save %d pinned version(s) to %s?", pins, projectConfig.Path()))
+	if err != nil || !save {
+		return err
+	}
+	fmt.Println()
+	return projectConfig.Save()
+}
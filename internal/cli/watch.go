@@ -0,0 +1,176 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/christosgalano/bruh/internal/bicep"
+	"github.com/christosgalano/bruh/internal/scanner"
+	"github.com/christosgalano/bruh/internal/schema"
+	"github.com/christosgalano/bruh/internal/types"
+	"github.com/christosgalano/bruh/internal/watch"
+)
+
+// watchSession holds the state of a scan in watch mode: the scanner session caching the fetched API versions,
+// the loaded type definitions and the scanned files.
+type watchSession struct {
+	session *scanner.Session
+	idx     *schema.Index
+	scanned map[string]bool
+}
+
+// watchScan scans the file, the directory or the files reachable from the entry file, and then rescans the changed files
+// until interrupted, printing out the status of their resources. The directory of the entry file is watched for changes.
+func watchScan() error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	session := &watchSession{
+		session: scanner.New(scanner.Options{IncludePreview: scanIncludePreview, Filter: scanFilter}, nil, nil),
+		scanned: map[string]bool{},
+	}
+	if scanTypesDir != "" {
+		idx, err := schema.Load(scanTypesDir)
+		if err != nil {
+			return err
+		}
+		session.idx = idx
+	}
+
+	root := scanPath
+	if scanEntry != "" {
+		root = filepath.Dir(scanEntry)
+	}
+	if err := session.rescan(nil); err != nil {
+		return err
+	}
+
+	w := watch.New(root)
+	w.SkipDir = scanFilter.SkipDir
+	fmt.Fprintf(os.Stderr, "Watching %s for changes (press Ctrl+C to stop)\n", root)
+	return w.Run(ctx, func(paths []string) {
+		fmt.Printf("[%s] Changed: %s\n\n", time.Now().Format("15:04:05"), strings.Join(paths, ", "))
+		if err := session.rescan(paths); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n\n", err)
+		}
+	})
+}
+
+// rescan parses the changed paths (or everything, if paths is nil), resolves the available API versions of their resources
+// and prints out their status, along with the scanned files that were removed.
+func (s *watchSession) rescan(paths []string) error {
+	changed := map[string]bool{}
+	for _, path := range paths {
+		changed[filepath.Clean(path)] = true
+	}
+
+	// A single file
+	if fs, err := os.Stat(scanPath); scanEntry == "" && err == nil && !fs.IsDir() {
+		bicepFile, err := bicep.ParseFile(scanPath)
+		if err != nil {
+			return err
+		}
+		bicepFiles := []types.BicepFile{*bicepFile}
+		if err := s.check(bicepFiles); err != nil {
+			return err
+		}
+		printScanFile(&bicepFiles[0])
+		return nil
+	}
+
+	bicepDirectory, err := parseChanged(changed)
+	if err != nil {
+		return err
+	}
+
+	// Scanned files that were removed, or whose directory was removed
+	for path := range changed {
+		if _, err := os.Stat(path); err == nil {
+			continue
+		}
+		for file := range s.scanned {
+			if file == path || strings.HasPrefix(file, path+string(filepath.Separator)) {
+				delete(s.scanned, file)
+				fmt.Printf("Removed %s\n\n", file)
+			}
+		}
+	}
+	if paths != nil && len(bicepDirectory.Files) == 0 {
+		return nil
+	}
+
+	for _, file := range bicepDirectory.Files {
+		s.scanned[filepath.Clean(file.Path)] = true
+	}
+	if err := s.check(bicepDirectory.Files); err != nil {
+		return err
+	}
+	printScanDirectory(bicepDirectory)
+	return nil
+}
+
+// parseChanged parses the files of the directory (or reachable from the entry file) among the changed ones, or all of them if changed is empty.
+// Only the changed files are reparsed, unless a directory changed as a whole or the entry file determines the scanned files.
+func parseChanged(changed map[string]bool) (*types.BicepDirectory, error) {
+	files := []string{}
+	whole := len(changed) == 0 || scanEntry != "" || scanFilter.IgnoreFiles
+	for path := range changed {
+		fs, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		if fs.IsDir() {
+			whole = true
+		}
+		files = append(files, path)
+	}
+	if !whole {
+		return bicep.ParseFiles(scanPath, files, scanFilter, os.ReadFile)
+	}
+
+	var bicepDirectory *types.BicepDirectory
+	var err error
+	if scanEntry != "" {
+		bicepDirectory, err = bicep.ParseEntry(scanEntry)
+	} else {
+		bicepDirectory, err = bicep.ParseDirectory(scanPath, scanFilter)
+	}
+	if err != nil || len(changed) == 0 {
+		return bicepDirectory, err
+	}
+
+	// Keep the changed files, along with the files of the changed directories
+	kept := []types.BicepFile{}
+	for _, file := range bicepDirectory.Files {
+		for path := range changed {
+			if filepath.Clean(file.Path) == path || strings.HasPrefix(filepath.Clean(file.Path), path+string(filepath.Separator)) {
+				kept = append(kept, file)
+				break
+			}
+		}
+	}
+	bicepDirectory.Files = kept
+	return bicepDirectory, nil
+}
+
+// check resolves the available API versions of the resources of the given files and, if type definitions were loaded, detects their breaking changes.
+func (s *watchSession) check(bicepFiles []types.BicepFile) error {
+	if err := s.session.ResolveFiles(bicepFiles); err != nil {
+		return err
+	}
+	if s.idx == nil {
+		return nil
+	}
+	for i := range bicepFiles {
+		if err := schema.CheckBicepFile(s.idx, &bicepFiles[i], changelog); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/christosgalano/bruh/internal/notify"
+	"github.com/christosgalano/bruh/internal/types"
+)
+
+const (
+	// webhookTimeout is the maximum duration of posting a summary to a webhook.
+	webhookTimeout = 30 * time.Second
+)
+
+// webhookFlags are the flags of the webhook a summary of a scan is posted to.
+type webhookFlags struct {
+	url      string
+	format   string
+	template string
+	top      int
+}
+
+// addWebhookFlags adds the flags of the webhook a summary of a scan is posted to.
+func addWebhookFlags(cmd *cobra.Command, flags *webhookFlags) {
+	// webhook - optional
+	cmd.Flags().StringVar(&flags.url, "webhook", "", "URL to post a summary of the scan to (e.g. a Slack incoming webhook)")
+
+	// webhook-format - optional
+	cmd.Flags().StringVar(&flags.format, "webhook-format", string(notify.FormatGeneric), "format of the webhook payload (slack, teams, generic)")
+
+	// webhook-template - optional
+	cmd.Flags().StringVar(&flags.template, "webhook-template", "", "path to a Go template rendering the generic webhook payload from the summary (if not set: the summary as JSON)")
+
+	// webhook-top - optional
+	cmd.Flags().IntVar(&flags.top, "webhook-top", 5, "number of top outdated resources listed in the webhook summary")
+}
+
+// webhook returns the webhook given by the flags, or nil if no URL is set.
+func (f *webhookFlags) webhook() (*notify.Webhook, error) {
+	if f.top < 0 {
+		return nil, fmt.Errorf("invalid value %d for --webhook-top: must be 0 or greater", f.top)
+	}
+	if f.url == "" {
+		return nil, nil
+	}
+	format, err := notify.ParseFormat(f.format)
+	if err != nil {
+		return nil, err
+	}
+	webhook := &notify.Webhook{URL: f.url, Format: format, Top: f.top}
+	if f.template != "" {
+		if format != notify.FormatGeneric {
+			return nil, fmt.Errorf("--webhook-template requires --webhook-format generic")
+		}
+		data, err := os.ReadFile(f.template)
+		if err != nil {
+			return nil, err
+		}
+		if webhook.Template, err = notify.ParseTemplate(string(data)); err != nil {
+			return nil, err
+		}
+	}
+	return webhook, nil
+}
+
+// sendSummary posts a summary of a scanned directory to the webhook, if not nil.
+func sendSummary(webhook *notify.Webhook, bicepDirectory *types.BicepDirectory) error {
+	if webhook == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), webhookTimeout)
+	defer cancel()
+	if err := webhook.Send(ctx, notify.NewSummary(bicepDirectory, webhook.Top, time.Now())); err != nil {
+		return fmt.Errorf("failed to post the summary to the webhook: %w", err)
+	}
+	return nil
+}
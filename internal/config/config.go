@@ -0,0 +1,168 @@
+/*
+Package config provides the project configuration of bruh, stored in a .bruh.json file at the root of a project.
+
+The configuration holds the API versions pinned for resource types, either for a single file or for the whole project,
+so that the update command never moves them past the pinned version. Pins are usually recorded during an interactive update:
+
+	{
+	  "pins": [
+	    { "resource": "Microsoft.Web/sites", "version": "2022-09-01", "path": "modules/app.bicep" },
+	    { "resource": "Microsoft.Web/sites", "version": "2021-02-01", "path": "main.bicep", "occurrence": 2 },
+	    { "resource": "Microsoft.Storage/storageAccounts", "version": "2021-04-01" }
+	  ]
+	}
+
+Paths are relative to the directory of the configuration file, with forward slashes. A pin of a file can also apply to a single
+resource of a type declared several times in it, by its occurrence (e.g. 2 for the second declaration of the type).
+*/
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/christosgalano/bruh/internal/types"
+)
+
+const (
+	// FileName is the name of the project configuration file.
+	FileName = ".bruh.json"
+)
+
+// Pin contains information about a pinned API version:
+//   - Resource: the resource ID (e.g. Microsoft.Web/sites), or the module reference without its tag for registry modules
+//   - Version: the pinned API version or module tag (e.g. 2022-09-01)
+//   - Path: the path of the file the pin applies to, relative to the configuration file (e.g. modules/app.bicep), empty for all files
+//   - Occurrence: the occurrence of the resource among those of the file with the same ID (e.g. 2 for the second one), 0 for all of them
+type Pin struct {
+	Resource   string `json:"resource"`
+	Version    string `json:"version"`
+	Path       string `json:"path,omitempty"`
+	Occurrence int    `json:"occurrence,omitempty"`
+}
+
+// Config contains the project configuration:
+//   - Pins: the pinned API versions, with the occurrence-specific ones taking precedence over the file-specific ones,
+//     and those over the project-wide ones
+type Config struct {
+	Pins []Pin `json:"pins"`
+
+	path string
+}
+
+// Find returns the configuration in the .bruh.json file closest to the given directory, looking in its parents as well.
+// If there is none, it returns an empty configuration that would be saved in the given directory.
+func Find(dir string) (*Config, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	for current := dir; ; {
+		path := filepath.Join(current, FileName)
+		config, err := Load(path)
+		if err == nil {
+			return config, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+
+		parent := filepath.Dir(current)
+		if parent == current {
+			return &Config{path: filepath.Join(dir, FileName)}, nil
+		}
+		current = parent
+	}
+}
+
+// Load returns the configuration in the given file.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+	config := Config{path: path}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return &config, nil
+}
+
+// Path returns the path of the configuration file.
+func (c *Config) Path() string {
+	return c.path
+}
+
+// Save writes the configuration to its file.
+func (c *Config) Save() error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(c.path, append(data, '\n'), 0o644)
+}
+
+// relative returns the path of a file relative to the directory of the configuration file, with forward slashes.
+func (c *Config) relative(filePath string) string {
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		return filepath.ToSlash(filePath)
+	}
+	rel, err := filepath.Rel(filepath.Dir(c.path), absPath)
+	if err != nil {
+		return filepath.ToSlash(filePath)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// SetPin pins the API version of a resource of the given file, replacing any previous pin of the resource for that file.
+// If the occurrence is not 0, only that occurrence of the resource in the file is pinned (see types.BicepFile.Occurrence).
+func (c *Config) SetPin(filePath, resource string, occurrence int, version string) {
+	pin := Pin{Resource: resource, Version: version, Path: c.relative(filePath), Occurrence: occurrence}
+	for i := range c.Pins {
+		if c.Pins[i].Resource == pin.Resource && c.Pins[i].Path == pin.Path && c.Pins[i].Occurrence == pin.Occurrence {
+			c.Pins[i] = pin
+			return
+		}
+	}
+	c.Pins = append(c.Pins, pin)
+}
+
+// pinned returns the pinned API version of an occurrence of a resource of the given file,
+// preferring the pins specific to the occurrence and then those specific to the file.
+func (c *Config) pinned(filePath, resource string, occurrence int) (string, bool) {
+	rel := c.relative(filePath)
+	version, precedence := "", 0
+	for _, pin := range c.Pins {
+		if pin.Resource != resource {
+			continue
+		}
+		switch {
+		case pin.Path == rel && pin.Occurrence != 0 && pin.Occurrence == occurrence:
+			return pin.Version, true
+		case pin.Path == rel && pin.Occurrence == 0 && precedence < 2:
+			version, precedence = pin.Version, 2
+		case pin.Path == "" && precedence < 1:
+			version, precedence = pin.Version, 1
+		}
+	}
+	return version, precedence > 0
+}
+
+// Apply caps the available API versions of the pinned resources of a file to their pinned versions and marks them as pinned.
+// Resources with an unknown type or an unresolved API version are left untouched.
+func (c *Config) Apply(bicepFile *types.BicepFile) {
+	for i := range bicepFile.Resources {
+		resource := &bicepFile.Resources[i]
+		if resource.Unknown || resource.Unresolved {
+			continue
+		}
+		if version, ok := c.pinned(bicepFile.Path, resource.ID, bicepFile.Occurrence(i)); ok {
+			resource.Cap(version)
+			resource.Pinned = true
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/christosgalano/bruh/internal/types"
+)
+
+func TestFind(t *testing.T) {
+	dir := t.TempDir()
+	nested := filepath.Join(dir, "infra", "modules")
+	if err := os.MkdirAll(nested, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	content := `{"pins": [{"resource": "Microsoft.Web/sites", "version": "2022-09-01", "path": "infra/app.bicep"}]}`
+	if err := os.WriteFile(filepath.Join(dir, FileName), []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	empty := t.TempDir()
+
+	tests := []struct {
+		name     string
+		dir      string
+		wantPath string
+		wantPins []Pin
+	}{
+		{
+			name:     "closest-parent",
+			dir:      nested,
+			wantPath: filepath.Join(dir, FileName),
+			wantPins: []Pin{{Resource: "Microsoft.Web/sites", Version: "2022-09-01", Path: "infra/app.bicep"}},
+		},
+		{
+			name:     "no-config",
+			dir:      empty,
+			wantPath: filepath.Join(empty, FileName),
+			wantPins: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Find(tt.dir)
+			if err != nil {
+				t.Fatalf("Find() error = %v", err)
+			}
+			if got.Path() != tt.wantPath {
+				t.Errorf("Find() path = %v, want %v", got.Path(), tt.wantPath)
+			}
+			if !reflect.DeepEqual(got.Pins, tt.wantPins) {
+				t.Errorf("Find() pins = %v, want %v", got.Pins, tt.wantPins)
+			}
+		})
+	}
+
+	invalid := t.TempDir()
+	if err := os.WriteFile(filepath.Join(invalid, FileName), []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Find(invalid); err == nil {
+		t.Errorf("Find() with invalid configuration error = nil, want error")
+	}
+}
+
+func TestConfig_SetPin(t *testing.T) {
+	dir := t.TempDir()
+	config := &Config{path: filepath.Join(dir, FileName)}
+	config.SetPin(filepath.Join(dir, "main.bicep"), "Microsoft.Web/sites", 0, "2021-02-01")
+	config.SetPin(filepath.Join(dir, "modules", "app.bicep"), "Microsoft.Web/sites", 0, "2022-09-01")
+	config.SetPin(filepath.Join(dir, "main.bicep"), "Microsoft.Web/sites", 0, "2022-03-01")
+
+	want := []Pin{
+		{Resource: "Microsoft.Web/sites", Version: "2022-03-01", Path: "main.bicep"},
+		{Resource: "Microsoft.Web/sites", Version: "2022-09-01", Path: "modules/app.bicep"},
+	}
+	if !reflect.DeepEqual(config.Pins, want) {
+		t.Fatalf("SetPin() pins = %v, want %v", config.Pins, want)
+	}
+
+	if err := config.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	got, err := Load(config.Path())
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !reflect.DeepEqual(got.Pins, want) {
+		t.Errorf("Load() pins = %v, want %v", got.Pins, want)
+	}
+}
+
+func TestConfig_Apply(t *testing.T) {
+	dir := t.TempDir()
+	config := &Config{
+		path: filepath.Join(dir, FileName),
+		Pins: []Pin{
+			{Resource: "Microsoft.Web/sites", Version: "2021-02-01"},
+			{Resource: "Microsoft.Web/sites", Version: "2022-09-01", Path: "modules/app.bicep"},
+			{Resource: "Microsoft.KeyVault/vaults", Version: "2022-07-01"},
+		},
+	}
+	available := []string{"2023-01-01", "2022-09-01", "2021-02-01"}
+	newFile := func(path string) *types.BicepFile {
+		return &types.BicepFile{
+			Path: path,
+			Resources: []types.Resource{
+				{ID: "Microsoft.Web/sites", CurrentAPIVersion: "2021-02-01", AvailableAPIVersions: available},
+				{ID: "Microsoft.Storage/storageAccounts", CurrentAPIVersion: "2021-02-01", AvailableAPIVersions: available},
+				{ID: "Microsoft.KeyVault/vaults", CurrentAPIVersion: "2021-02-01", Unknown: true},
+			},
+		}
+	}
+
+	tests := []struct {
+		name string
+		path string
+		want []types.Resource
+	}{
+		{
+			name: "project-pin",
+			path: filepath.Join(dir, "main.bicep"),
+			want: []types.Resource{
+				{ID: "Microsoft.Web/sites", CurrentAPIVersion: "2021-02-01", AvailableAPIVersions: []string{"2021-02-01"}, Pinned: true},
+				{ID: "Microsoft.Storage/storageAccounts", CurrentAPIVersion: "2021-02-01", AvailableAPIVersions: available},
+				{ID: "Microsoft.KeyVault/vaults", CurrentAPIVersion: "2021-02-01", Unknown: true},
+			},
+		},
+		{
+			name: "file-pin",
+			path: filepath.Join(dir, "modules", "app.bicep"),
+			want: []types.Resource{
+				{ID: "Microsoft.Web/sites", CurrentAPIVersion: "2021-02-01", AvailableAPIVersions: []string{"2022-09-01", "2021-02-01"}, Pinned: true},
+				{ID: "Microsoft.Storage/storageAccounts", CurrentAPIVersion: "2021-02-01", AvailableAPIVersions: available},
+				{ID: "Microsoft.KeyVault/vaults", CurrentAPIVersion: "2021-02-01", Unknown: true},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := newFile(tt.path)
+			config.Apply(file)
+			if !reflect.DeepEqual(file.Resources, tt.want) {
+				t.Errorf("Apply() = %v, want %v", file.Resources, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfig_ApplyOccurrences(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.bicep")
+	config := &Config{path: filepath.Join(dir, FileName), Pins: []Pin{{Resource: "Microsoft.Web/sites", Version: "2021-02-01"}}}
+	config.SetPin(path, "Microsoft.Web/sites", 1, "2022-09-01")
+	config.SetPin(path, "Microsoft.Web/sites", 3, "2022-09-01")
+	config.SetPin(path, "Microsoft.Web/sites", 1, "2022-03-01")
+	if err := config.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	loaded, err := Load(config.Path())
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	// Each declaration keeps its own pin, and the unpinned one falls back to the project pin
+	available := []string{"2023-01-01", "2022-09-01", "2022-03-01", "2021-02-01"}
+	file := &types.BicepFile{Path: path}
+	for i := 0; i < 3; i++ {
+		file.Resources = append(file.Resources, types.Resource{ID: "Microsoft.Web/sites", CurrentAPIVersion: "2021-02-01", AvailableAPIVersions: available})
+	}
+	loaded.Apply(file)
+	got := []string{}
+	for _, resource := range file.Resources {
+		got = append(got, resource.LatestAPIVersion())
+	}
+	if want := []string{"2022-03-01", "2021-02-01", "2022-09-01"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Apply() latest versions = %v, want %v", got, want)
+	}
+	if len(loaded.Pins) != 3 {
+		t.Errorf("Load() pins = %v, want 3 pins", loaded.Pins)
+	}
+}
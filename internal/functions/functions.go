@@ -0,0 +1,308 @@
+/*
+Package functions finds the calls of template functions that receive a hard-coded API version, such as reference and listKeys.
+
+Calls are found in Bicep code and in ARM template language expressions alike, as both use the same function names and single-quoted strings.
+The resource type of a call is inferred from its first argument when possible: either a resourceId-style function call with a literal type,
+or a symbolic name (e.g. storage.id in Bicep or 'storage' in ARM templates with symbolic names) whose type is known.
+*/
+package functions
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/christosgalano/bruh/internal/types"
+)
+
+var (
+	// versionRegex is the regex used to match literal API versions.
+	versionRegex = regexp.MustCompile(`^[0-9]{4}-[0-9]{2}-[0-9]{2}(-preview)?$`)
+
+	// typeRegex is the regex used to match literal resource types (e.g. Microsoft.Storage/storageAccounts/blobServices).
+	typeRegex = regexp.MustCompile(`^[A-Za-z0-9]+(\.[A-Za-z0-9]+)+(/[A-Za-z0-9]+)+$`)
+
+	// symbolRegex is the regex used to match symbolic names, optionally followed by .id (e.g. storage.id).
+	symbolRegex = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_]*)(\.id)?$`)
+
+	// resourceIDFunctions are the functions that build a resource ID from a resource type.
+	resourceIDFunctions = map[string]bool{
+		"resourceid":                   true,
+		"subscriptionresourceid":       true,
+		"tenantresourceid":             true,
+		"extensionresourceid":          true,
+		"managementgroupresourceid":    true,
+		"az.resourceid":                true,
+		"az.subscriptionresourceid":    true,
+		"az.tenantresourceid":          true,
+		"az.extensionresourceid":       true,
+		"az.managementgroupresourceid": true,
+	}
+)
+
+// Call is a call of a template function with a hard-coded API version:
+//   - Function: the name of the function (e.g. listKeys)
+//   - Type: the inferred resource type (e.g. Microsoft.Storage/storageAccounts) or an empty string if it cannot be inferred
+//   - Version: the API version (e.g. 2021-04-01)
+//   - Start, End: the offsets of the API version in the source, excluding the quotes
+type Call struct {
+	Function string
+	Type     string
+	Version  string
+	Start    int
+	End      int
+}
+
+// argument is an argument of a function call along with its offsets in the source.
+type argument struct {
+	text  string
+	start int
+	end   int
+}
+
+// scanner is a scanner of Bicep code or ARM template language expressions.
+type scanner struct {
+	src string
+	pos int
+}
+
+// skipString skips the single-quoted string (or Bicep multi-line string) at the current position,
+// and returns the offsets of its Bicep interpolations (e.g. ${listKeys(storage.id, '2021-04-01').keys[0].value}), which may contain calls.
+func (s *scanner) skipString() [][2]int {
+	if strings.HasPrefix(s.src[s.pos:], "'''") {
+		end := strings.Index(s.src[s.pos+3:], "'''")
+		if end < 0 {
+			s.pos = len(s.src)
+		} else {
+			s.pos += end + 6
+		}
+		return nil
+	}
+
+	holes := [][2]int{}
+	s.pos++
+	for s.pos < len(s.src) && s.src[s.pos] != '\'' {
+		switch {
+		case s.src[s.pos] == '\\':
+			s.pos += 2
+		case strings.HasPrefix(s.src[s.pos:], "${"):
+			start := s.pos + 2
+			s.pos = start
+			for depth := 0; s.pos < len(s.src) && (depth > 0 || s.src[s.pos] != '}'); {
+				switch s.src[s.pos] {
+				case '\'':
+					s.skipString()
+					continue
+				case '{':
+					depth++
+				case '}':
+					depth--
+				}
+				s.pos++
+			}
+			holes = append(holes, [2]int{start, s.pos})
+			s.pos++
+		default:
+			s.pos++
+		}
+	}
+	s.pos++
+	if s.pos > len(s.src) {
+		s.pos = len(s.src)
+	}
+	return holes
+}
+
+// skipComment skips the comment at the current position and returns true, or returns false if there is none.
+func (s *scanner) skipComment() bool {
+	switch {
+	case strings.HasPrefix(s.src[s.pos:], "//"):
+		end := strings.IndexByte(s.src[s.pos:], '\n')
+		if end < 0 {
+			s.pos = len(s.src)
+		} else {
+			s.pos += end
+		}
+		return true
+	case strings.HasPrefix(s.src[s.pos:], "/*"):
+		end := strings.Index(s.src[s.pos+2:], "*/")
+		if end < 0 {
+			s.pos = len(s.src)
+		} else {
+			s.pos += end + 4
+		}
+		return true
+	}
+	return false
+}
+
+// arguments returns the top-level arguments of the call whose opening parenthesis is at the given offset.
+// If the parenthesis is not closed, the function returns false.
+func arguments(src string, open int) ([]argument, bool) {
+	s := &scanner{src: src, pos: open + 1}
+	args := []argument{}
+	start := s.pos
+	depth := 0
+
+	add := func(end int) {
+		text := src[start:end]
+		trimmed := strings.TrimSpace(text)
+		if trimmed == "" {
+			return
+		}
+		offset := start + strings.Index(text, trimmed)
+		args = append(args, argument{text: trimmed, start: offset, end: offset + len(trimmed)})
+	}
+
+	for s.pos < len(src) {
+		switch c := src[s.pos]; {
+		case c == '\'':
+			s.skipString()
+			continue
+		case s.skipComment():
+			continue
+		case c == '(' || c == '[' || c == '{':
+			depth++
+		case c == ')' || c == ']' || c == '}':
+			if depth == 0 {
+				add(s.pos)
+				return args, true
+			}
+			depth--
+		case c == ',' && depth == 0:
+			add(s.pos)
+			start = s.pos + 1
+		}
+		s.pos++
+	}
+	return nil, false
+}
+
+// literal returns the value of a single-quoted string literal argument, or false if the argument is not one.
+func literal(arg argument) (string, bool) {
+	if len(arg.text) < 2 || arg.text[0] != '\'' || arg.text[len(arg.text)-1] != '\'' {
+		return "", false
+	}
+	value := arg.text[1 : len(arg.text)-1]
+	if strings.ContainsAny(value, "'\\") {
+		return "", false
+	}
+	return value, true
+}
+
+// isTarget returns true if the function receives an API version as its second argument.
+func isTarget(name string) bool {
+	name = strings.TrimPrefix(name, "az.")
+	return name == "reference" || (strings.HasPrefix(name, "list") && len(name) > len("list"))
+}
+
+// inferType returns the resource type identified by the first argument of a call, or an empty string if it cannot be inferred.
+// symbols maps symbolic names to their resource types.
+func inferType(src string, arg argument, symbols map[string]string) string {
+	// resourceId('Microsoft.Storage/storageAccounts', name) and similar calls
+	if open := strings.IndexByte(arg.text, '('); open > 0 && strings.HasSuffix(arg.text, ")") {
+		name := strings.TrimSpace(arg.text[:open])
+		if !resourceIDFunctions[strings.ToLower(name)] {
+			return ""
+		}
+		args, ok := arguments(src, arg.start+open)
+		if !ok {
+			return ""
+		}
+		for _, a := range args {
+			if value, ok := literal(a); ok && typeRegex.MatchString(value) {
+				return value
+			}
+		}
+		return ""
+	}
+
+	// 'storage' in ARM templates with symbolic names
+	if value, ok := literal(arg); ok {
+		return symbols[value]
+	}
+
+	// storage or storage.id in Bicep
+	if match := symbolRegex.FindStringSubmatch(arg.text); match != nil {
+		return symbols[match[1]]
+	}
+	return ""
+}
+
+// Find returns the calls of template functions with a hard-coded API version in the given source, in order of appearance.
+// symbols maps symbolic names to their resource types and is used to infer the type of calls such as reference(storage.id, '2021-04-01').
+func Find(src string, symbols map[string]string) []Call {
+	calls := []Call{}
+	s := &scanner{src: src}
+	for s.pos < len(src) {
+		c := src[s.pos]
+		switch {
+		case c == '\'':
+			for _, hole := range s.skipString() {
+				for _, call := range Find(src[hole[0]:hole[1]], symbols) {
+					call.Start += hole[0]
+					call.End += hole[0]
+					calls = append(calls, call)
+				}
+			}
+			continue
+		case s.skipComment():
+			continue
+		case !isIdentifierStart(c) || (s.pos > 0 && (isIdentifierPart(src[s.pos-1]) || src[s.pos-1] == '.')):
+			s.pos++
+			continue
+		}
+
+		// Read a (possibly namespaced) function name
+		start := s.pos
+		for s.pos < len(src) && (isIdentifierPart(src[s.pos]) || src[s.pos] == '.') {
+			s.pos++
+		}
+		name := src[start:s.pos]
+		open := s.pos
+		for open < len(src) && (src[open] == ' ' || src[open] == '\t') {
+			open++
+		}
+		if open >= len(src) || src[open] != '(' || !isTarget(name) {
+			continue
+		}
+
+		args, ok := arguments(src, open)
+		if !ok || len(args) < 2 {
+			continue
+		}
+		version, ok := literal(args[1])
+		if !ok || !versionRegex.MatchString(version) {
+			continue
+		}
+		calls = append(calls, Call{
+			Function: strings.TrimPrefix(name, "az."),
+			Type:     inferType(src, args[0], symbols),
+			Version:  version,
+			Start:    args[1].start + 1,
+			End:      args[1].end - 1,
+		})
+	}
+	return calls
+}
+
+// isIdentifierStart returns true if the byte can start an identifier.
+func isIdentifierStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// isIdentifierPart returns true if the byte can be part of an identifier.
+func isIdentifierPart(c byte) bool {
+	return isIdentifierStart(c) || (c >= '0' && c <= '9')
+}
+
+// Resource returns the resource corresponding to a call with an inferred type, reported as a call of its function.
+func (c Call) Resource() types.Resource {
+	namespace, name, _ := strings.Cut(c.Type, "/")
+	return types.Resource{
+		ID:                c.Type,
+		Name:              name,
+		Namespace:         namespace,
+		CurrentAPIVersion: c.Version,
+		Function:          c.Function,
+	}
+}
@@ -0,0 +1,85 @@
+package functions
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFind(t *testing.T) {
+	symbols := map[string]string{
+		"storage": "Microsoft.Storage/storageAccounts",
+		"vault":   "Microsoft.KeyVault/vaults",
+	}
+	tests := []struct {
+		name string
+		src  string
+		want []Call
+	}{
+		{
+			name: "arm-resource-id",
+			src:  `reference(resourceId('Microsoft.Network/publicIPAddresses', parameters('name')), '2019-06-01').dnsSettings.fqdn`,
+			want: []Call{
+				{Function: "reference", Type: "Microsoft.Network/publicIPAddresses", Version: "2019-06-01", Start: 82, End: 92},
+			},
+		},
+		{
+			name: "arm-nested-call",
+			src:  `concat('Key=', listKeys(resourceId('rg', 'Microsoft.Storage/storageAccounts', 'sa'), '2021-04-01').keys[0].value)`,
+			want: []Call{
+				{Function: "listKeys", Type: "Microsoft.Storage/storageAccounts", Version: "2021-04-01", Start: 86, End: 96},
+			},
+		},
+		{
+			name: "arm-symbolic-name",
+			src:  `reference('storage', '2022-09-01-preview', 'Full')`,
+			want: []Call{
+				{Function: "reference", Type: "Microsoft.Storage/storageAccounts", Version: "2022-09-01-preview", Start: 22, End: 40},
+			},
+		},
+		{
+			name: "bicep-symbol",
+			src:  "output keys object = listKeys(storage.id, '2021-04-01')\nvar s = az.listSecrets(vault.id, '2019-09-01')",
+			want: []Call{
+				{Function: "listKeys", Type: "Microsoft.Storage/storageAccounts", Version: "2021-04-01", Start: 43, End: 53},
+				{Function: "listSecrets", Type: "Microsoft.KeyVault/vaults", Version: "2019-09-01", Start: 90, End: 100},
+			},
+		},
+		{
+			name: "bicep-interpolation",
+			src:  "var cs = 'AccountKey=${listKeys(storage.id, '2021-04-01').keys[0].value};'",
+			want: []Call{
+				{Function: "listKeys", Type: "Microsoft.Storage/storageAccounts", Version: "2021-04-01", Start: 45, End: 55},
+			},
+		},
+		{
+			name: "unknown-type",
+			src:  `reference(variables('id'), '2019-06-01')`,
+			want: []Call{
+				{Function: "reference", Version: "2019-06-01", Start: 28, End: 38},
+			},
+		},
+		{
+			name: "ignored",
+			src: "// reference(storage.id, '2019-06-01')\n" +
+				"var a = 'reference(storage.id, \\'2019-06-01\\')'\n" +
+				"var b = storage.listKeys()\n" +
+				"var c = reference(storage.id)\n" +
+				"var d = mylistKeys(storage.id, '2019-06-01')\n" +
+				"var e = listKeys(storage.id, variables('apiVersion'))",
+			want: []Call{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Find(tt.src, symbols)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Find() = %+v, want %+v", got, tt.want)
+			}
+			for _, call := range got {
+				if version := tt.src[call.Start:call.End]; version != call.Version {
+					t.Errorf("Find() offsets point to %q, want %q", version, call.Version)
+				}
+			}
+		})
+	}
+}
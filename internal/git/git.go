@@ -0,0 +1,170 @@
+/*
+Package git provides functions to find the files and lines changed in a local git repository, using the git executable.
+
+Changes are computed either against the merge base of a ref and HEAD (e.g. origin/main), including uncommitted changes of the working tree,
+or for the staged changes only. Deleted files are ignored, as there is nothing left to scan in them.
+*/
+package git
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrNotRepository is returned when the given directory is not part of a git repository.
+	ErrNotRepository = errors.New("not a git repository")
+
+	// hunkRegex is the regex used to match the header of a hunk of a unified diff (e.g. @@ -10,2 +10,3 @@).
+	hunkRegex = regexp.MustCompile(`^@@ -[0-9]+(?:,[0-9]+)? \+([0-9]+)(?:,([0-9]+))? @@`)
+)
+
+// LineRange is a range of lines of a file, 1-based and inclusive.
+type LineRange struct {
+	Start int
+	End   int
+}
+
+// Changes maps the absolute paths of the changed files to the ranges of their added or modified lines.
+// Files changed without any added line (e.g. renamed files or files with removed lines only) have no ranges.
+type Changes map[string][]LineRange
+
+// key returns the absolute path of a file with any symbolic links resolved, as reported by git.
+// For files that do not exist (e.g. files of a past revision), the symbolic links of the closest existing parent directory are resolved.
+func key(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	rest := ""
+	for dir := abs; ; dir = filepath.Dir(dir) {
+		if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+			return filepath.Join(resolved, rest)
+		}
+		if filepath.Dir(dir) == dir {
+			return abs
+		}
+		rest = filepath.Join(filepath.Base(dir), rest)
+	}
+}
+
+// Files returns the absolute paths of the changed files.
+func (c Changes) Files() []string {
+	files := make([]string, 0, len(c))
+	for path := range c {
+		files = append(files, path)
+	}
+	sort.Strings(files)
+	return files
+}
+
+// Contains returns true if the file of the given path has changed.
+func (c Changes) Contains(path string) bool {
+	_, ok := c[key(path)]
+	return ok
+}
+
+// ContainsLine returns true if the given line of the file of the given path was added or modified.
+func (c Changes) ContainsLine(path string, line int) bool {
+	for _, r := range c[key(path)] {
+		if line >= r.Start && line <= r.End {
+			return true
+		}
+	}
+	return false
+}
+
+// run runs a git command in the given directory and returns its standard output.
+func run(dir string, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		message := strings.TrimSpace(stderr.String())
+		if strings.Contains(message, "not a git repository") {
+			return "", fmt.Errorf("%w %q", ErrNotRepository, dir)
+		}
+		if message == "" {
+			return "", fmt.Errorf("git: %w", err)
+		}
+		return "", fmt.Errorf("git: %s", message)
+	}
+	return stdout.String(), nil
+}
+
+// parseDiff parses the output of git diff with no context lines and returns the changes, where root is the top-level directory of the repository.
+func parseDiff(output, root string) Changes {
+	changes := Changes{}
+	current := ""
+	for _, line := range strings.Split(output, "\n") {
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			current = ""
+		case strings.HasPrefix(line, "rename to "):
+			current = filepath.Join(root, filepath.FromSlash(strings.TrimPrefix(line, "rename to ")))
+			if _, ok := changes[current]; !ok {
+				changes[current] = nil
+			}
+		case strings.HasPrefix(line, "+++ "):
+			current = ""
+			if path, ok := strings.CutPrefix(strings.TrimSuffix(line, "\t"), "+++ b/"); ok {
+				current = filepath.Join(root, filepath.FromSlash(path))
+				if _, ok := changes[current]; !ok {
+					changes[current] = nil
+				}
+			}
+		case current != "" && strings.HasPrefix(line, "@@ "):
+			match := hunkRegex.FindStringSubmatch(line)
+			if match == nil {
+				continue
+			}
+			start, _ := strconv.Atoi(match[1])
+			count := 1
+			if match[2] != "" {
+				count, _ = strconv.Atoi(match[2])
+			}
+			if count > 0 {
+				changes[current] = append(changes[current], LineRange{Start: start, End: start + count - 1})
+			}
+		}
+	}
+	return changes
+}
+
+// Diff returns the files and lines changed in the git repository of the given directory.
+// If staged is true, only the staged changes are returned (e.g. for a pre-commit hook).
+// Otherwise, the changes since the merge base of the given ref and HEAD are returned (e.g. origin/main for a pull request),
+// including the uncommitted changes of tracked files.
+func Diff(dir, since string, staged bool) (Changes, error) {
+	root, err := run(dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	root = key(strings.TrimSpace(root))
+
+	args := []string{"-c", "core.quotePath=false", "diff", "--no-color", "--no-ext-diff", "--unified=0", "--diff-filter=ACMR", "--src-prefix=a/", "--dst-prefix=b/"}
+	if staged {
+		args = append(args, "--cached")
+	} else {
+		base, err := run(root, "merge-base", since, "HEAD")
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, strings.TrimSpace(base))
+	}
+
+	output, err := run(root, args...)
+	if err != nil {
+		return nil, err
+	}
+	return parseDiff(output, root), nil
+}
@@ -0,0 +1,152 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func Test_parseDiff(t *testing.T) {
+	output := `diff --git a/main.bicep b/main.bicep
+index 1111111..2222222 100644
+--- a/main.bicep
++++ b/main.bicep
+@@ -3 +3 @@ param location string
+-resource rg 'Microsoft.Resources/resourceGroups@2021-01-01' = {
++resource rg 'Microsoft.Resources/resourceGroups@2022-09-01' = {
+@@ -10,0 +11,3 @@ resource rg 'Microsoft.Resources/resourceGroups@2022-09-01' = {
++module app 'modules/app.bicep' = {
++  name: 'app'
++}
+@@ -20,2 +23,0 @@ output id string = rg.id
+-output name string = rg.name
+-output location string = rg.location
+diff --git a/modules/app.bicep b/modules/app.bicep
+new file mode 100644
+index 0000000..3333333
+--- /dev/null
++++ b/modules/app.bicep
+@@ -0,0 +1,4 @@
++resource site 'Microsoft.Web/sites@2022-03-01' = {
++  name: 'app'
++  location: resourceGroup().location
++}
+diff --git a/old.bicep b/new name.bicep
+similarity index 100%
+rename from old.bicep
+rename to new name.bicep
+`
+	root := filepath.Join("/", "repo")
+	want := Changes{
+		filepath.Join(root, "main.bicep"):           {{Start: 3, End: 3}, {Start: 11, End: 13}},
+		filepath.Join(root, "modules", "app.bicep"): {{Start: 1, End: 4}},
+		filepath.Join(root, "new name.bicep"):       nil,
+	}
+	if got := parseDiff(output, root); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseDiff() = %v, want %v", got, want)
+	}
+}
+
+func TestChanges_ContainsLine(t *testing.T) {
+	dir := t.TempDir()
+	changes := Changes{key(filepath.Join(dir, "main.bicep")): {{Start: 3, End: 3}, {Start: 11, End: 13}}}
+	tests := []struct {
+		name string
+		path string
+		line int
+		want bool
+	}{
+		{name: "single-line-range", path: filepath.Join(dir, "main.bicep"), line: 3, want: true},
+		{name: "multi-line-range", path: filepath.Join(dir, "main.bicep"), line: 12, want: true},
+		{name: "unchanged-line", path: filepath.Join(dir, "main.bicep"), line: 5, want: false},
+		{name: "unchanged-file", path: filepath.Join(dir, "other.bicep"), line: 3, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := changes.ContainsLine(tt.path, tt.line); got != tt.want {
+				t.Errorf("ContainsLine() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiff(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir := t.TempDir()
+	gitCmd := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=bruh", "-c", "user.email=bruh@example.com", "-c", "commit.gpgsign=false"}, args...)...)
+		cmd.Dir = dir
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, output)
+		}
+	}
+	writeFile := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	gitCmd("init", "-q", "-b", "main")
+	writeFile("main.bicep", "param location string\n\nresource rg 'Microsoft.Resources/resourceGroups@2021-01-01' = {\n  name: 'rg'\n}\n")
+	writeFile("unchanged.bicep", "param name string\n")
+	gitCmd("add", ".")
+	gitCmd("commit", "-q", "-m", "initial")
+	gitCmd("checkout", "-q", "-b", "feature")
+
+	// Committed, staged and unstaged changes
+	writeFile("main.bicep", "param location string\n\nresource rg 'Microsoft.Resources/resourceGroups@2022-09-01' = {\n  name: 'rg'\n}\n")
+	gitCmd("commit", "-q", "-am", "bump")
+	writeFile("staged.bicep", "param tags object\n")
+	gitCmd("add", "staged.bicep")
+	writeFile("unchanged.bicep", "param name string\nparam location string\n")
+
+	tests := []struct {
+		name   string
+		since  string
+		staged bool
+		want   map[string][]LineRange
+	}{
+		{
+			name:  "since",
+			since: "main",
+			want: map[string][]LineRange{
+				"main.bicep":      {{Start: 3, End: 3}},
+				"staged.bicep":    {{Start: 1, End: 1}},
+				"unchanged.bicep": {{Start: 2, End: 2}},
+			},
+		},
+		{
+			name:   "staged",
+			staged: true,
+			want: map[string][]LineRange{
+				"staged.bicep": {{Start: 1, End: 1}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Diff(dir, tt.since, tt.staged)
+			if err != nil {
+				t.Fatalf("Diff() error = %v", err)
+			}
+			want := Changes{}
+			for name, ranges := range tt.want {
+				want[key(filepath.Join(dir, name))] = ranges
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Diff() = %v, want %v", got, want)
+			}
+		})
+	}
+
+	if _, err := Diff(t.TempDir(), "main", false); err == nil {
+		t.Errorf("Diff() outside a repository error = nil, want %v", ErrNotRepository)
+	}
+}
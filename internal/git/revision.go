@@ -0,0 +1,110 @@
+package git
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Revision gives access to the files of a git repository at a given revision (e.g. a release tag),
+// reading them from the object store without checking the revision out.
+type Revision struct {
+	root  string
+	rev   string
+	blobs map[string]string
+}
+
+// NewRevision returns the revision of the git repository of the given directory (e.g. v2.3.0, HEAD~1 or a commit hash).
+func NewRevision(dir, rev string) (*Revision, error) {
+	root, err := run(dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	root = key(strings.TrimSpace(root))
+
+	commit, err := run(root, "rev-parse", "--verify", "--quiet", rev+"^{commit}")
+	if err != nil {
+		return nil, fmt.Errorf("unknown revision %q", rev)
+	}
+	commit = strings.TrimSpace(commit)
+
+	// Each entry is "<mode> <type> <object>\t<path>", separated by NUL characters
+	output, err := run(root, "ls-tree", "-r", "-z", "--full-tree", commit)
+	if err != nil {
+		return nil, err
+	}
+	blobs := map[string]string{}
+	for _, entry := range strings.Split(output, "\x00") {
+		info, path, found := strings.Cut(entry, "\t")
+		fields := strings.Fields(info)
+		if !found || len(fields) != 3 || fields[1] != "blob" {
+			continue
+		}
+		blobs[path] = fields[2]
+	}
+	return &Revision{root: root, rev: rev, blobs: blobs}, nil
+}
+
+// String returns the name of the revision.
+func (r *Revision) String() string {
+	return r.rev
+}
+
+// relative returns the path of a file or directory relative to the root of the repository, with forward slashes.
+// If the path is outside the repository, the function returns false.
+func (r *Revision) relative(path string) (string, bool) {
+	rel, err := filepath.Rel(r.root, key(path))
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
+
+// Files returns the paths of the files of a directory at the revision, including those of its subdirectories, sorted in lexical order.
+// The paths are joined with the given directory, like the ones returned by filepath.WalkDir.
+// If the path is a file at the revision, the function returns the path itself.
+func (r *Revision) Files(dir string) ([]string, error) {
+	rel, ok := r.relative(dir)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q is outside the repository", fs.ErrNotExist, dir)
+	}
+	if _, ok := r.blobs[rel]; ok {
+		return []string{dir}, nil
+	}
+
+	prefix := ""
+	if rel != "." {
+		prefix = rel + "/"
+	}
+	files := []string{}
+	for path := range r.blobs {
+		if sub, ok := strings.CutPrefix(path, prefix); ok {
+			files = append(files, filepath.Join(dir, filepath.FromSlash(sub)))
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("%w: %q at revision %s", fs.ErrNotExist, dir, r.rev)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// ReadFile returns the content of a file at the revision.
+// If the file does not exist at the revision, the returned error wraps fs.ErrNotExist.
+func (r *Revision) ReadFile(path string) ([]byte, error) {
+	rel, ok := r.relative(path)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q is outside the repository", fs.ErrNotExist, path)
+	}
+	object, ok := r.blobs[rel]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q at revision %s", fs.ErrNotExist, path, r.rev)
+	}
+	content, err := run(r.root, "cat-file", "blob", object)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(content), nil
+}
@@ -0,0 +1,118 @@
+package git
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRevision(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir := t.TempDir()
+	gitCmd := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=bruh", "-c", "user.email=bruh@example.com", "-c", "commit.gpgsign=false"}, args...)...)
+		cmd.Dir = dir
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, output)
+		}
+	}
+	writeFile := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	gitCmd("init", "-q", "-b", "main")
+	writeFile("main.bicep", "resource rg 'Microsoft.Resources/resourceGroups@2021-01-01' = {}\n")
+	writeFile("infra/app.bicep", "resource app 'Microsoft.Web/sites@2021-02-01' = {}\n")
+	gitCmd("add", ".")
+	gitCmd("commit", "-q", "-m", "initial")
+	gitCmd("tag", "v1.0.0")
+
+	// Changes after the tag, including uncommitted ones, are not part of the revision
+	writeFile("infra/app.bicep", "resource app 'Microsoft.Web/sites@2022-03-01' = {}\n")
+	writeFile("infra/new.bicep", "param name string\n")
+	gitCmd("add", ".")
+	gitCmd("commit", "-q", "-m", "bump")
+	writeFile("infra/app.bicep", "resource app 'Microsoft.Web/sites@2023-01-01' = {}\n")
+
+	rev, err := NewRevision(dir, "v1.0.0")
+	if err != nil {
+		t.Fatalf("NewRevision() error = %v", err)
+	}
+	if rev.String() != "v1.0.0" {
+		t.Errorf("String() = %v, want %v", rev.String(), "v1.0.0")
+	}
+
+	filesTests := []struct {
+		name    string
+		dir     string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "repository",
+			dir:  dir,
+			want: []string{filepath.Join(dir, "infra", "app.bicep"), filepath.Join(dir, "main.bicep")},
+		},
+		{
+			name: "subdirectory",
+			dir:  filepath.Join(dir, "infra"),
+			want: []string{filepath.Join(dir, "infra", "app.bicep")},
+		},
+		{
+			name: "file",
+			dir:  filepath.Join(dir, "main.bicep"),
+			want: []string{filepath.Join(dir, "main.bicep")},
+		},
+		{
+			name:    "missing-directory",
+			dir:     filepath.Join(dir, "missing"),
+			wantErr: true,
+		},
+		{
+			name:    "outside-repository",
+			dir:     t.TempDir(),
+			wantErr: true,
+		},
+	}
+	for _, tt := range filesTests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := rev.Files(tt.dir)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Files() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Files() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	content, err := rev.ReadFile(filepath.Join(dir, "infra", "app.bicep"))
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if want := "resource app 'Microsoft.Web/sites@2021-02-01' = {}\n"; string(content) != want {
+		t.Errorf("ReadFile() = %q, want %q", content, want)
+	}
+	if _, err := rev.ReadFile(filepath.Join(dir, "infra", "new.bicep")); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("ReadFile() error = %v, want %v", err, fs.ErrNotExist)
+	}
+
+	if _, err := NewRevision(dir, "v9.9.9"); err == nil {
+		t.Errorf("NewRevision() with unknown revision error = nil, want error")
+	}
+}
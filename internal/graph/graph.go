@@ -0,0 +1,134 @@
+/*
+Package graph provides the dependency graph of Bicep files, whose nodes are files and edges their local module references and imports.
+
+The graph is built from a BicepDirectory object whose resources have already been updated with the available API versions,
+either parsed from an entry file (bicep.ParseEntry) or from a whole directory (bicep.ParseDirectory).
+Each node holds the resource types and API versions of its file, and nodes with outdated resources are flagged,
+along with the number of deployments (files that no other file references) that reach them, so that shared modules spreading stale versions stand out.
+
+The graph can be rendered as Graphviz DOT, Mermaid or JSON.
+*/
+package graph
+
+import (
+	"path/filepath"
+	"sort"
+
+	"github.com/christosgalano/bruh/internal/types"
+)
+
+// Resource contains information about a resource of a node:
+//   - ID: the resource ID, prefixed with the function whose call passes the API version if any (e.g. listKeys(Microsoft.Storage/storageAccounts))
+//   - CurrentVersion: the used API version or module tag (e.g. 2021-02-01)
+//   - LatestVersion: the latest available API version or module tag (e.g. 2023-01-01)
+//   - Status: the status of the resource (e.g. outdated)
+type Resource struct {
+	ID             string `json:"id"`
+	CurrentVersion string `json:"currentVersion"`
+	LatestVersion  string `json:"latestVersion,omitempty"`
+	Status         string `json:"status"`
+}
+
+// Node contains information about a file of the graph:
+//   - Path: the path of the file relative to the graph path (e.g. modules/app.bicep)
+//   - Resources: the resources of the file
+//   - Outdated: whether any resource of the file is outdated or can be promoted to a GA API version
+//   - References: the paths of the files referenced by the file
+//   - ReferencedBy: the paths of the files referencing the file
+//   - Deployments: the number of deployments (files that no other file references) that reach the file
+type Node struct {
+	Path         string     `json:"path"`
+	Resources    []Resource `json:"resources"`
+	Outdated     bool       `json:"outdated"`
+	References   []string   `json:"references"`
+	ReferencedBy []string   `json:"referencedBy"`
+	Deployments  int        `json:"deployments"`
+}
+
+// Graph contains the nodes of a dependency graph, in the order of the files they were built from:
+//   - Path: the directory the paths of the nodes are relative to (e.g. ./bicep)
+//   - Nodes: the nodes of the graph
+type Graph struct {
+	Path  string `json:"path"`
+	Nodes []Node `json:"nodes"`
+}
+
+// New builds the dependency graph of the files of a directory.
+// References to files that are not part of the directory (e.g. ignored JSON files) are left out.
+func New(bicepDirectory *types.BicepDirectory) *Graph {
+	relative := func(path string) string {
+		if rel, err := filepath.Rel(bicepDirectory.Path, path); err == nil {
+			return filepath.ToSlash(rel)
+		}
+		return filepath.ToSlash(path)
+	}
+
+	g := &Graph{Path: bicepDirectory.Path, Nodes: []Node{}}
+	index := map[string]int{}
+	for _, file := range bicepDirectory.Files {
+		node := Node{Path: relative(file.Path), Resources: []Resource{}, References: []string{}, ReferencedBy: []string{}}
+		for _, resource := range file.Resources {
+			status := resource.Status()
+			node.Resources = append(node.Resources, Resource{
+				ID:             resource.Label(),
+				CurrentVersion: resource.CurrentAPIVersion,
+				LatestVersion:  resource.LatestAPIVersion(),
+				Status:         status.String(),
+			})
+			if status == types.StatusOutdated || status == types.StatusPromotable {
+				node.Outdated = true
+			}
+		}
+		index[node.Path] = len(g.Nodes)
+		g.Nodes = append(g.Nodes, node)
+	}
+
+	for i, file := range bicepDirectory.Files {
+		for _, ref := range file.References {
+			path := relative(ref)
+			j, ok := index[path]
+			if !ok || contains(g.Nodes[i].References, path) {
+				continue
+			}
+			g.Nodes[i].References = append(g.Nodes[i].References, path)
+			g.Nodes[j].ReferencedBy = append(g.Nodes[j].ReferencedBy, g.Nodes[i].Path)
+		}
+	}
+
+	// Count the deployments reaching each node
+	for i := range g.Nodes {
+		if len(g.Nodes[i].ReferencedBy) > 0 {
+			continue
+		}
+		reached := map[string]bool{}
+		g.reach(g.Nodes[i].Path, index, reached)
+		for path := range reached {
+			g.Nodes[index[path]].Deployments++
+		}
+	}
+	for i := range g.Nodes {
+		sort.Strings(g.Nodes[i].ReferencedBy)
+	}
+	return g
+}
+
+// reach marks the node of the given path and all the nodes reachable from it, ignoring those already marked.
+func (g *Graph) reach(path string, index map[string]int, reached map[string]bool) {
+	if reached[path] {
+		return
+	}
+	reached[path] = true
+	for _, ref := range g.Nodes[index[path]].References {
+		g.reach(ref, index, reached)
+	}
+}
+
+// contains returns true if the given slice contains the given string.
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
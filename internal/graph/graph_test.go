@@ -0,0 +1,139 @@
+package graph
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/christosgalano/bruh/internal/types"
+)
+
+// directory returns two deployments sharing an outdated network module, one of them using an up-to-date app module.
+func directory() *types.BicepDirectory {
+	path := func(elem ...string) string {
+		return filepath.Join(append([]string{"bicep"}, elem...)...)
+	}
+	return &types.BicepDirectory{
+		Path: "bicep",
+		Files: []types.BicepFile{
+			{
+				Path:       path("dev.bicep"),
+				References: []string{path("modules", "network.bicep"), path("modules", "app.bicep"), path("params.json")},
+			},
+			{
+				Path: path("modules", "app.bicep"),
+				Resources: []types.Resource{
+					{ID: "Microsoft.Web/sites", CurrentAPIVersion: "2023-01-01", AvailableAPIVersions: []string{"2023-01-01"}},
+					{ID: "Microsoft.Storage/storageAccounts", Function: "listKeys", CurrentAPIVersion: "2019-06-01", Unknown: true},
+				},
+				References: []string{path("modules", "network.bicep")},
+			},
+			{
+				Path: path("modules", "network.bicep"),
+				Resources: []types.Resource{
+					{ID: "Microsoft.Network/virtualNetworks", CurrentAPIVersion: "2021-02-01", AvailableAPIVersions: []string{"2023-04-01", "2021-02-01"}},
+				},
+			},
+			{
+				Path:       path("prod.bicep"),
+				References: []string{path("modules", "network.bicep")},
+			},
+		},
+	}
+}
+
+func TestNew(t *testing.T) {
+	g := New(directory())
+	want := []Node{
+		{
+			Path:         "dev.bicep",
+			Resources:    []Resource{},
+			References:   []string{"modules/network.bicep", "modules/app.bicep"},
+			ReferencedBy: []string{},
+			Deployments:  1,
+		},
+		{
+			Path: "modules/app.bicep",
+			Resources: []Resource{
+				{ID: "Microsoft.Web/sites", CurrentVersion: "2023-01-01", LatestVersion: "2023-01-01", Status: "latest"},
+				{ID: "listKeys(Microsoft.Storage/storageAccounts)", CurrentVersion: "2019-06-01", Status: "unknown"},
+			},
+			References:   []string{"modules/network.bicep"},
+			ReferencedBy: []string{"dev.bicep"},
+			Deployments:  1,
+		},
+		{
+			Path: "modules/network.bicep",
+			Resources: []Resource{
+				{ID: "Microsoft.Network/virtualNetworks", CurrentVersion: "2021-02-01", LatestVersion: "2023-04-01", Status: "outdated"},
+			},
+			Outdated:     true,
+			References:   []string{},
+			ReferencedBy: []string{"dev.bicep", "modules/app.bicep", "prod.bicep"},
+			Deployments:  2,
+		},
+		{
+			Path:         "prod.bicep",
+			Resources:    []Resource{},
+			References:   []string{"modules/network.bicep"},
+			ReferencedBy: []string{},
+			Deployments:  1,
+		},
+	}
+	if !reflect.DeepEqual(g.Nodes, want) {
+		t.Errorf("New() = %+v, want %+v", g.Nodes, want)
+	}
+}
+
+func TestGraph_DOT(t *testing.T) {
+	want := `digraph bruh {
+  rankdir=LR;
+  node [shape=box, style="rounded,filled", fillcolor=white, fontname="Helvetica"];
+  "dev.bicep" [label="dev.bicep\l"];
+  "modules/app.bicep" [label="modules/app.bicep\lMicrosoft.Web/sites@2023-01-01\llistKeys(Microsoft.Storage/storageAccounts)@2019-06-01 (unknown)\l"];
+  "modules/network.bicep" [label="modules/network.bicep\lused by 2 deployments\lMicrosoft.Network/virtualNetworks@2021-02-01 -> 2023-04-01\l", fillcolor="#f8d7da", color="#dc3545"];
+  "prod.bicep" [label="prod.bicep\l"];
+  "dev.bicep" -> "modules/network.bicep";
+  "dev.bicep" -> "modules/app.bicep";
+  "modules/app.bicep" -> "modules/network.bicep";
+  "prod.bicep" -> "modules/network.bicep";
+}
+`
+	if got := New(directory()).DOT(); got != want {
+		t.Errorf("DOT() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestGraph_Mermaid(t *testing.T) {
+	want := `flowchart LR
+  n0["dev.bicep"]
+  n1["modules/app.bicep<br/>Microsoft.Web/sites@2023-01-01<br/>listKeys(Microsoft.Storage/storageAccounts)@2019-06-01 (unknown)"]
+  n2["modules/network.bicep<br/>used by 2 deployments<br/>Microsoft.Network/virtualNetworks@2021-02-01 -#gt; 2023-04-01"]
+  n3["prod.bicep"]
+  n0 --> n2
+  n0 --> n1
+  n1 --> n2
+  n3 --> n2
+  classDef outdated fill:#f8d7da,stroke:#dc3545
+  class n2 outdated
+`
+	if got := New(directory()).Mermaid(); got != want {
+		t.Errorf("Mermaid() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestGraph_JSON(t *testing.T) {
+	g := New(directory())
+	data, err := g.JSON()
+	if err != nil {
+		t.Fatalf("JSON() error = %v", err)
+	}
+	var got Graph
+	if err := json.Unmarshal([]byte(data), &got); err != nil {
+		t.Fatalf("JSON() returned invalid JSON: %v", err)
+	}
+	if !reflect.DeepEqual(&got, g) {
+		t.Errorf("JSON() = %+v, want %+v", got, g)
+	}
+}
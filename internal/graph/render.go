@@ -0,0 +1,104 @@
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const (
+	// outdatedFill and outdatedStroke are the colors used to highlight the nodes with outdated resources.
+	outdatedFill   = "#f8d7da"
+	outdatedStroke = "#dc3545"
+)
+
+// resourceLine returns a line describing a resource of a node (e.g. Microsoft.Web/sites@2021-02-01 -> 2023-01-01).
+func resourceLine(resource Resource) string {
+	line := resource.ID + "@" + resource.CurrentVersion
+	switch resource.Status {
+	case "outdated", "promotable":
+		line += " -> " + resource.LatestVersion
+	case "unknown", "unresolved":
+		line += " (" + resource.Status + ")"
+	}
+	return line
+}
+
+// nodeLines returns the lines of the label of a node: its path, the number of deployments reaching it if shared, and its resources.
+func nodeLines(node Node) []string {
+	lines := []string{node.Path}
+	if node.Deployments > 1 {
+		lines = append(lines, fmt.Sprintf("used by %d deployments", node.Deployments))
+	}
+	for _, resource := range node.Resources {
+		lines = append(lines, resourceLine(resource))
+	}
+	return lines
+}
+
+// DOT returns the graph in Graphviz DOT format, with the nodes with outdated resources filled in red.
+func (g *Graph) DOT() string {
+	escape := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+	var sb strings.Builder
+	sb.WriteString("digraph bruh {\n")
+	sb.WriteString("  rankdir=LR;\n")
+	sb.WriteString("  node [shape=box, style=\"rounded,filled\", fillcolor=white, fontname=\"Helvetica\"];\n")
+	for _, node := range g.Nodes {
+		lines := nodeLines(node)
+		for i := range lines {
+			lines[i] = escape.Replace(lines[i])
+		}
+		attributes := fmt.Sprintf("label=\"%s\\l\"", strings.Join(lines, "\\l"))
+		if node.Outdated {
+			attributes += fmt.Sprintf(", fillcolor=\"%s\", color=\"%s\"", outdatedFill, outdatedStroke)
+		}
+		fmt.Fprintf(&sb, "  \"%s\" [%s];\n", escape.Replace(node.Path), attributes)
+	}
+	for _, node := range g.Nodes {
+		for _, ref := range node.References {
+			fmt.Fprintf(&sb, "  \"%s\" -> \"%s\";\n", escape.Replace(node.Path), escape.Replace(ref))
+		}
+	}
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+// Mermaid returns the graph as a Mermaid flowchart, with the nodes with outdated resources assigned to the "outdated" class.
+func (g *Graph) Mermaid() string {
+	escape := strings.NewReplacer(`"`, "#quot;", "<", "#lt;", ">", "#gt;")
+	ids := map[string]string{}
+	for i, node := range g.Nodes {
+		ids[node.Path] = fmt.Sprintf("n%d", i)
+	}
+
+	var sb strings.Builder
+	sb.WriteString("flowchart LR\n")
+	for _, node := range g.Nodes {
+		lines := nodeLines(node)
+		for i := range lines {
+			lines[i] = escape.Replace(lines[i])
+		}
+		fmt.Fprintf(&sb, "  %s[\"%s\"]\n", ids[node.Path], strings.Join(lines, "<br/>"))
+	}
+	for _, node := range g.Nodes {
+		for _, ref := range node.References {
+			fmt.Fprintf(&sb, "  %s --> %s\n", ids[node.Path], ids[ref])
+		}
+	}
+	fmt.Fprintf(&sb, "  classDef outdated fill:%s,stroke:%s\n", outdatedFill, outdatedStroke)
+	for _, node := range g.Nodes {
+		if node.Outdated {
+			fmt.Fprintf(&sb, "  class %s outdated\n", ids[node.Path])
+		}
+	}
+	return sb.String()
+}
+
+// JSON returns the graph in indented JSON format.
+func (g *Graph) JSON() (string, error) {
+	data, err := json.MarshalIndent(g, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data) + "\n", nil
+}
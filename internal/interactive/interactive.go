@@ -0,0 +1,224 @@
+/*
+Package interactive provides the prompts of the interactive update, which reviews the outdated resources one at a time.
+
+For each resource, the file and line of its API version are shown along with the current version and the candidate versions,
+and the age of each one. The resource can then be updated to the latest version (accept), left untouched (skip),
+updated to another candidate version (pick) or kept at its current version (pin). Picked and pinned versions can be saved
+to the project configuration, so that later updates do not move the resources past them.
+*/
+package interactive
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/christosgalano/bruh/internal/types"
+)
+
+const (
+	// maxCandidates is the maximum number of candidate versions shown for a resource, newest first.
+	maxCandidates = 10
+)
+
+// Action represents the action chosen for a resource during an interactive update.
+type Action int8
+
+const (
+	ActionAccept Action = iota // ActionAccept corresponds to updating the resource to the latest version
+	ActionSkip                 // ActionSkip corresponds to leaving the resource untouched
+	ActionPick                 // ActionPick corresponds to updating the resource to another candidate version
+	ActionPin                  // ActionPin corresponds to keeping the resource at its current version
+)
+
+// String returns a string representation of an interactive.Action object.
+func (a Action) String() string {
+	switch a {
+	case ActionAccept:
+		return "accept"
+	case ActionSkip:
+		return "skip"
+	case ActionPick:
+		return "pick"
+	case ActionPin:
+		return "pin"
+	}
+	return "unknown"
+}
+
+// Decision contains the action chosen for a resource:
+//   - Path: the path of the file of the resource
+//   - Resource: the resource ID (e.g. Microsoft.Web/sites)
+//   - Occurrence: the occurrence of the resource among those of the file with the same ID, 0 if it is the only one (see types.BicepFile.Occurrence)
+//   - Version: the version the resource is updated to or kept at, empty for skipped resources
+//   - Action: the chosen action
+type Decision struct {
+	Path       string
+	Resource   string
+	Occurrence int
+	Version    string
+	Action     Action
+}
+
+// Prompter reads the choices of the user from an input (e.g. the terminal) and writes the prompts to an output.
+type Prompter struct {
+	in  *bufio.Reader
+	out io.Writer
+	now time.Time
+}
+
+// New returns a Prompter reading from in and writing to out. The ages of the API versions are computed at the given time.
+func New(in io.Reader, out io.Writer, now time.Time) *Prompter {
+	return &Prompter{in: bufio.NewReader(in), out: out, now: now}
+}
+
+// readLine reads a trimmed line of input. At the end of the input, it returns io.EOF unless the last line is not empty.
+func (p *Prompter) readLine() (string, error) {
+	line, err := p.in.ReadString('\n')
+	if err != nil && !(errors.Is(err, io.EOF) && line != "") {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// Confirm asks a yes/no question, returning false unless the answer is yes (y or yes) or if the input ends.
+func (p *Prompter) Confirm(question string) (bool, error) {
+	fmt.Fprintf(p.out, "%s [y/N]: ", question)
+	answer, err := p.readLine()
+	if errors.Is(err, io.EOF) {
+		fmt.Fprintln(p.out)
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	answer = strings.ToLower(answer)
+	return answer == "y" || answer == "yes", nil
+}
+
+// reviewable returns true if a resource can be updated, i.e. it has a known type, a resolved API version and a newer version available.
+func reviewable(resource types.Resource) bool {
+	latest := resource.LatestAPIVersion()
+	return !resource.Unknown && !resource.Unresolved && !resource.Skipped && latest != "" && resource.CurrentAPIVersion != latest
+}
+
+// candidates returns the available versions newer than the current one, newest first and at most maxCandidates of them.
+func candidates(resource types.Resource) []string {
+	versions := resource.AvailableAPIVersions
+	for i, version := range versions {
+		if version == resource.CurrentAPIVersion {
+			versions = versions[:i]
+			break
+		}
+	}
+	if len(versions) > maxCandidates {
+		versions = versions[:maxCandidates]
+	}
+	return versions
+}
+
+// age returns a hint with the age of a version of a resource, or an empty string for registry modules, whose tags have no dates.
+func (p *Prompter) age(resource types.Resource, version string) string {
+	if resource.Module {
+		return ""
+	}
+	return fmt.Sprintf(" (%d days old)", types.Resource{CurrentAPIVersion: version}.AgeDays(p.now))
+}
+
+// ask prompts for the action of a resource until a valid one is given, returning the action and the picked version.
+// At the end of the input, it returns io.EOF.
+func (p *Prompter) ask(path string, resource types.Resource) (Action, string, error) {
+	versions := candidates(resource)
+
+	fmt.Fprintf(p.out, "%s:%d %s\n", path, resource.Line, resource.Label())
+	fmt.Fprintf(p.out, "  current: %s%s\n", resource.CurrentAPIVersion, p.age(resource, resource.CurrentAPIVersion))
+	fmt.Fprintln(p.out, "  candidates:")
+	for i, version := range versions {
+		hint := ""
+		if i == 0 && len(resource.BreakingChanges) > 0 {
+			hint = fmt.Sprintf(", %d breaking change(s)", len(resource.BreakingChanges))
+		}
+		fmt.Fprintf(p.out, "    %d) %s%s%s\n", i+1, version, p.age(resource, version), hint)
+	}
+
+	for {
+		fmt.Fprintf(p.out, "  accept %s (a), skip (s), pin %s (n), quit (q) or pick a candidate (1-%d): ", versions[0], resource.CurrentAPIVersion, len(versions))
+		answer, err := p.readLine()
+		if err != nil {
+			fmt.Fprintln(p.out)
+			return ActionSkip, "", err
+		}
+
+		switch strings.ToLower(answer) {
+		case "a", "accept":
+			return ActionAccept, versions[0], nil
+		case "s", "skip":
+			return ActionSkip, "", nil
+		case "n", "pin":
+			return ActionPin, resource.CurrentAPIVersion, nil
+		case "q", "quit":
+			return ActionSkip, "", io.EOF
+		}
+		if n, err := strconv.Atoi(strings.TrimPrefix(strings.TrimPrefix(answer, "p"), " ")); err == nil && n >= 1 && n <= len(versions) {
+			if n == 1 {
+				return ActionAccept, versions[0], nil
+			}
+			return ActionPick, versions[n-1], nil
+		}
+		fmt.Fprintf(p.out, "  invalid choice %q\n", answer)
+	}
+}
+
+// apply applies an action to a resource: skipped resources are excluded from the update, picked versions become the latest ones,
+// and pinned resources keep their current version.
+func apply(resource *types.Resource, action Action, version string) {
+	switch action {
+	case ActionSkip:
+		resource.Skipped = true
+	case ActionPick:
+		// The breaking changes and changelog refer to the latest version, which is no longer the target
+		resource.Cap(version)
+		resource.BreakingChanges = nil
+		resource.Changelog = nil
+	case ActionPin:
+		resource.Cap(version)
+		resource.Pinned = true
+	}
+}
+
+// Review prompts for the action of each resource of the given files that can be updated, in order, and applies it to the resource.
+// Quitting (or the end of the input) skips the remaining resources. It returns the decisions made, in order.
+func (p *Prompter) Review(bicepFiles ...*types.BicepFile) ([]Decision, error) {
+	decisions := []Decision{}
+	quit := false
+	for _, file := range bicepFiles {
+		for i := range file.Resources {
+			resource := &file.Resources[i]
+			if !reviewable(*resource) {
+				continue
+			}
+			if quit {
+				resource.Skipped = true
+				continue
+			}
+
+			action, version, err := p.ask(file.Path, *resource)
+			if errors.Is(err, io.EOF) {
+				quit = true
+				resource.Skipped = true
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			apply(resource, action, version)
+			decisions = append(decisions, Decision{Path: file.Path, Resource: resource.ID, Occurrence: file.Occurrence(i), Version: version, Action: action})
+			fmt.Fprintln(p.out)
+		}
+	}
+	return decisions, nil
+}
@@ -0,0 +1,172 @@
+package interactive
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/christosgalano/bruh/internal/bicep"
+	"github.com/christosgalano/bruh/internal/types"
+)
+
+func testFile() *types.BicepFile {
+	available := []string{"2023-01-01", "2022-09-01", "2022-03-01", "2021-02-01"}
+	return &types.BicepFile{
+		Path: "main.bicep",
+		Resources: []types.Resource{
+			{ID: "Microsoft.Web/sites", CurrentAPIVersion: "2021-02-01", AvailableAPIVersions: available, Line: 3},
+			{ID: "Microsoft.Web/serverfarms", CurrentAPIVersion: "2023-01-01", AvailableAPIVersions: available, Line: 8},
+			{ID: "Microsoft.Storage/storageAccounts", CurrentAPIVersion: "2021-02-01", AvailableAPIVersions: available, Line: 12},
+			{ID: "Microsoft.KeyVault/vaults", CurrentAPIVersion: "2021-02-01", Unknown: true, Line: 15},
+			{ID: "Microsoft.Network/virtualNetworks", CurrentAPIVersion: "2021-02-01", AvailableAPIVersions: available, Line: 20},
+		},
+	}
+}
+
+func TestPrompter_Review(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name         string
+		input        string
+		want         []Decision
+		wantLatest   []string
+		wantSkipped  []bool
+		wantPinned   []bool
+		wantInOutput string
+	}{
+		{
+			name:  "accept-pick-pin",
+			input: "a\n3\nn\n",
+			want: []Decision{
+				{Path: "main.bicep", Resource: "Microsoft.Web/sites", Version: "2023-01-01", Action: ActionAccept},
+				{Path: "main.bicep", Resource: "Microsoft.Storage/storageAccounts", Version: "2022-03-01", Action: ActionPick},
+				{Path: "main.bicep", Resource: "Microsoft.Network/virtualNetworks", Version: "2021-02-01", Action: ActionPin},
+			},
+			wantLatest:   []string{"2023-01-01", "2023-01-01", "2022-03-01", "", "2021-02-01"},
+			wantSkipped:  []bool{false, false, false, false, false},
+			wantPinned:   []bool{false, false, false, false, true},
+			wantInOutput: "main.bicep:3 Microsoft.Web/sites\n  current: 2021-02-01 (1064 days old)\n  candidates:\n    1) 2023-01-01 (365 days old)\n",
+		},
+		{
+			name:  "invalid-choice-and-skip",
+			input: "x\n9\ns\nq\n",
+			want: []Decision{
+				{Path: "main.bicep", Resource: "Microsoft.Web/sites", Action: ActionSkip},
+			},
+			wantLatest:   []string{"2023-01-01", "2023-01-01", "2023-01-01", "", "2023-01-01"},
+			wantSkipped:  []bool{true, false, true, false, true},
+			wantPinned:   []bool{false, false, false, false, false},
+			wantInOutput: "invalid choice \"9\"",
+		},
+		{
+			name:  "end-of-input",
+			input: "1",
+			want: []Decision{
+				{Path: "main.bicep", Resource: "Microsoft.Web/sites", Version: "2023-01-01", Action: ActionAccept},
+			},
+			wantLatest:   []string{"2023-01-01", "2023-01-01", "2023-01-01", "", "2023-01-01"},
+			wantSkipped:  []bool{false, false, true, false, true},
+			wantPinned:   []bool{false, false, false, false, false},
+			wantInOutput: "pick a candidate (1-3)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := testFile()
+			out := &bytes.Buffer{}
+			got, err := New(strings.NewReader(tt.input), out, now).Review(file)
+			if err != nil {
+				t.Fatalf("Review() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Review() = %v, want %v", got, tt.want)
+			}
+			for i, resource := range file.Resources {
+				if resource.LatestAPIVersion() != tt.wantLatest[i] || resource.Skipped != tt.wantSkipped[i] || resource.Pinned != tt.wantPinned[i] {
+					t.Errorf("Review() resource %s latest = %v, skipped = %v, pinned = %v, want %v, %v, %v", resource.ID,
+						resource.LatestAPIVersion(), resource.Skipped, resource.Pinned, tt.wantLatest[i], tt.wantSkipped[i], tt.wantPinned[i])
+				}
+			}
+			if !strings.Contains(out.String(), tt.wantInOutput) {
+				t.Errorf("Review() output = %q, want it to contain %q", out.String(), tt.wantInOutput)
+			}
+		})
+	}
+}
+
+func TestPrompter_Confirm(t *testing.T) {
+	tests := []struct {
+		input string
+		want  bool
+	}{
+		{input: "y\n", want: true},
+		{input: "Yes\n", want: true},
+		{input: "\n", want: false},
+		{input: "no\n", want: false},
+		{input: "", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := New(strings.NewReader(tt.input), &bytes.Buffer{}, time.Now()).Confirm("Save?")
+			if err != nil {
+				t.Fatalf("Confirm() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Confirm() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrompter_ReviewRepeatedDeclarations(t *testing.T) {
+	declaration := "resource %s 'Microsoft.Web/sites@2020-01-01' = {\n  name: '%s'\n}\n\n"
+	content := ""
+	for _, name := range []string{"picked", "accepted", "skipped", "pinned"} {
+		content += fmt.Sprintf(declaration, name, name)
+	}
+	path := filepath.Join(t.TempDir(), "main.bicep")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := bicep.ParseFile(path)
+	if err != nil {
+		t.Fatalf("ParseFile() error = %v", err)
+	}
+	for i := range file.Resources {
+		file.Resources[i].AvailableAPIVersions = []string{"2023-01-01", "2022-01-01", "2020-01-01"}
+	}
+	decisions, err := New(strings.NewReader("2\na\ns\nn\n"), &bytes.Buffer{}, time.Now()).Review(file)
+	if err != nil {
+		t.Fatalf("Review() error = %v", err)
+	}
+	occurrences := []int{}
+	for _, decision := range decisions {
+		occurrences = append(occurrences, decision.Occurrence)
+	}
+	if want := []int{1, 2, 3, 4}; !reflect.DeepEqual(occurrences, want) {
+		t.Errorf("Review() occurrences = %v, want %v", occurrences, want)
+	}
+	if err := bicep.UpdateFile(file, true); err != nil {
+		t.Fatalf("UpdateFile() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	versions := regexp.MustCompile(`@(\d{4}-\d{2}-\d{2})`).FindAllStringSubmatch(string(data), -1)
+	got := []string{}
+	for _, version := range versions {
+		got = append(got, version[1])
+	}
+	if want := []string{"2022-01-01", "2023-01-01", "2020-01-01", "2020-01-01"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("updated versions = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,244 @@
+package lsp
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/christosgalano/bruh/internal/arm"
+	"github.com/christosgalano/bruh/internal/bicep"
+	"github.com/christosgalano/bruh/internal/types"
+)
+
+const (
+	// source is the source of the diagnostics published by the server.
+	source = "bruh"
+
+	// maxHoverVersions is the maximum number of available versions listed on hover, newest first.
+	maxHoverVersions = 20
+)
+
+// located is a resource of a document along with the ranges of its API version and of its whole reference (e.g. Microsoft.Web/sites@2021-02-01).
+type located struct {
+	resource types.Resource
+	version  textRange
+	span     textRange
+}
+
+// utf16Len returns the length of a string in UTF-16 code units, the unit of the characters of LSP positions.
+func utf16Len(s string) int {
+	return len(utf16.Encode([]rune(s)))
+}
+
+// locate returns the ranges of the API version of a resource and of its whole reference on the line of the resource.
+// The version is looked up after the resource type (e.g. Microsoft.Web/sites@2021-02-01 or br/public:avm/res/...:0.4.0) first,
+// and on its own otherwise (e.g. in ARM templates and function calls). If it is not found, the function returns false.
+func locate(lines []string, resource types.Resource) (located, bool) {
+	if resource.Line < 1 || resource.Line > len(lines) || resource.CurrentAPIVersion == "" {
+		return located{}, false
+	}
+	line := lines[resource.Line-1]
+
+	start, end := -1, -1
+	for _, separator := range []string{"@", ":"} {
+		if i := strings.Index(line, resource.ID+separator+resource.CurrentAPIVersion); i >= 0 {
+			start, end = i, i+len(resource.ID)+1
+			break
+		}
+	}
+	if start < 0 {
+		i := strings.Index(line, resource.CurrentAPIVersion)
+		if i < 0 {
+			return located{}, false
+		}
+		start, end = i, i
+	}
+	versionEnd := end + len(resource.CurrentAPIVersion)
+
+	at := func(offset int) position {
+		return position{Line: resource.Line - 1, Character: utf16Len(line[:offset])}
+	}
+	return located{
+		resource: resource,
+		version:  textRange{Start: at(end), End: at(versionEnd)},
+		span:     textRange{Start: at(start), End: at(versionEnd)},
+	}, true
+}
+
+// resources parses an open document and returns its located resources, resolved with the cached available versions.
+// Documents that are not scanned (e.g. unsupported extensions or JSON files that are not ARM templates) have no resources.
+func (s *Server) resources(uri string) ([]located, error) {
+	s.mu.Lock()
+	doc, ok := s.documents[uri]
+	s.mu.Unlock()
+	if !ok {
+		return nil, nil
+	}
+
+	bicepFile, err := bicep.ParseContent(path(uri), []byte(doc.text), readFile)
+	if errors.Is(err, types.ErrInvalidExtension) || errors.Is(err, arm.ErrNotTemplate) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := s.resolveAll(bicepFile); err != nil {
+		return nil, err
+	}
+
+	lines := strings.Split(doc.text, "\n")
+	for i := range lines {
+		lines[i] = strings.TrimSuffix(lines[i], "\r")
+	}
+	result := []located{}
+	for _, resource := range bicepFile.Resources {
+		if l, ok := locate(lines, resource); ok {
+			result = append(result, l)
+		}
+	}
+	return result, nil
+}
+
+// behind returns a hint with the number of versions and days a resource is behind the latest version.
+// Registry modules have no dates, so only the number of versions is returned for them.
+func behind(resource types.Resource) string {
+	if resource.Module {
+		return fmt.Sprintf("%d version(s) behind", resource.VersionsBehind())
+	}
+	return fmt.Sprintf("%d version(s) and %d days behind", resource.VersionsBehind(), resource.GapDays())
+}
+
+// unknownMessage returns the description of an unknown resource, along with the suggested resource types if any.
+func unknownMessage(resource types.Resource) string {
+	kind := "unknown resource type"
+	if resource.Module {
+		kind = "unknown module"
+	}
+	message := fmt.Sprintf("%s is an %s", resource.Label(), kind)
+	if len(resource.Suggestions) > 0 {
+		message += fmt.Sprintf(" (did you mean %s?)", strings.Join(resource.Suggestions, ", "))
+	}
+	return message
+}
+
+// newDiagnostic returns the diagnostic of a resource: an error for unknown types, a warning for outdated and promotable API versions,
+// and an information for other preview API versions. Resources using the latest version or an unresolved one have no diagnostic.
+func newDiagnostic(l located) (diagnostic, bool) {
+	resource := l.resource
+	d := diagnostic{Range: l.version, Source: source}
+	switch resource.Status() {
+	case types.StatusUnknown:
+		d.Severity, d.Code, d.Message = severityError, "unknown", unknownMessage(resource)
+	case types.StatusPromotable:
+		d.Severity, d.Code = severityWarning, "promotable"
+		d.Message = fmt.Sprintf("%s is using preview version %s while GA version %s is available", resource.Label(), resource.CurrentAPIVersion, resource.GAAPIVersion())
+	case types.StatusOutdated:
+		d.Severity, d.Code = severityWarning, "outdated"
+		d.Message = fmt.Sprintf("%s is using %s while the latest version is %s (%s)", resource.Label(), resource.CurrentAPIVersion, resource.LatestAPIVersion(), behind(resource))
+	case types.StatusLatest:
+		if !strings.HasSuffix(resource.CurrentAPIVersion, "-preview") {
+			return diagnostic{}, false
+		}
+		d.Severity, d.Code = severityInformation, "preview"
+		d.Message = fmt.Sprintf("%s is using preview version %s", resource.Label(), resource.CurrentAPIVersion)
+	default:
+		return diagnostic{}, false
+	}
+	return d, true
+}
+
+// diagnostics returns the diagnostics of an open document.
+func (s *Server) diagnostics(uri string) ([]diagnostic, error) {
+	resources, err := s.resources(uri)
+	diagnostics := []diagnostic{}
+	for _, l := range resources {
+		if d, ok := newDiagnostic(l); ok {
+			diagnostics = append(diagnostics, d)
+		}
+	}
+	return diagnostics, err
+}
+
+// codeActions returns the quick fixes of the resources whose API version overlaps the given range of an open document:
+// updating an outdated resource to the latest version, and a promotable one to the GA version (preferred) or the latest one.
+func (s *Server) codeActions(uri string, r textRange) ([]codeAction, error) {
+	resources, err := s.resources(uri)
+	actions := []codeAction{}
+	for _, l := range resources {
+		d, ok := newDiagnostic(l)
+		if !ok || !l.version.overlaps(r) || (d.Code != "outdated" && d.Code != "promotable") {
+			continue
+		}
+
+		targets := []string{l.resource.LatestAPIVersion()}
+		if ga := l.resource.GAAPIVersion(); d.Code == "promotable" && ga != targets[0] {
+			targets = []string{ga, targets[0]}
+		}
+		for i, target := range targets {
+			title := fmt.Sprintf("Update %s to %s", l.resource.Label(), target)
+			if d.Code == "promotable" && i == 0 {
+				title = fmt.Sprintf("Update %s to GA version %s", l.resource.Label(), target)
+			}
+			actions = append(actions, codeAction{
+				Title:       title,
+				Kind:        "quickfix",
+				Diagnostics: []diagnostic{d},
+				IsPreferred: i == 0,
+				Edit:        workspaceEdit{Changes: map[string][]textEdit{uri: {{Range: l.version, NewText: target}}}},
+			})
+		}
+	}
+	return actions, err
+}
+
+// hover returns the status and available versions of the resource at the given position of an open document, or nil if there is none.
+func (s *Server) hover(uri string, p position) (*hover, error) {
+	resources, err := s.resources(uri)
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range resources {
+		if !l.span.contains(p) {
+			continue
+		}
+
+		resource := l.resource
+		var b strings.Builder
+		fmt.Fprintf(&b, "**%s**: ", resource.Label())
+		switch resource.Status() {
+		case types.StatusUnresolved:
+			fmt.Fprintf(&b, "API version `%s` is not statically resolvable", resource.CurrentAPIVersion)
+		case types.StatusUnknown:
+			b.WriteString(strings.TrimPrefix(unknownMessage(resource), resource.Label()+" is an "))
+		case types.StatusLatest:
+			b.WriteString("using the latest version")
+		default:
+			fmt.Fprintf(&b, "%s, %s", resource.Status(), behind(resource))
+		}
+
+		if len(resource.AvailableAPIVersions) > 0 {
+			b.WriteString("\n\nAvailable versions:\n")
+			for i, version := range resource.AvailableAPIVersions {
+				if i == maxHoverVersions {
+					fmt.Fprintf(&b, "- … %d more\n", len(resource.AvailableAPIVersions)-maxHoverVersions)
+					break
+				}
+				notes := []string{}
+				if i == 0 {
+					notes = append(notes, "latest")
+				}
+				if version == resource.CurrentAPIVersion {
+					notes = append(notes, "current")
+				}
+				fmt.Fprintf(&b, "- `%s`", version)
+				if len(notes) > 0 {
+					fmt.Fprintf(&b, " (%s)", strings.Join(notes, ", "))
+				}
+				b.WriteString("\n")
+			}
+		}
+		return &hover{Contents: markupContent{Kind: "markdown", Value: strings.TrimRight(b.String(), "\n ")}, Range: l.span}, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,139 @@
+package lsp
+
+// The types below are the subset of the Language Server Protocol used by the server.
+// Positions are zero-based, and characters are counted in UTF-16 code units as the protocol requires.
+
+// Diagnostic severities.
+const (
+	severityError       = 1
+	severityWarning     = 2
+	severityInformation = 3
+)
+
+// textDocumentSyncFull is the synchronization kind of clients sending the full content of documents on every change.
+const textDocumentSyncFull = 1
+
+// position is a position in a document.
+type position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// textRange is a range in a document, whose end is exclusive.
+type textRange struct {
+	Start position `json:"start"`
+	End   position `json:"end"`
+}
+
+// contains returns true if the range contains or touches the given position.
+func (r textRange) contains(p position) bool {
+	if p.Line < r.Start.Line || p.Line > r.End.Line {
+		return false
+	}
+	if p.Line == r.Start.Line && p.Character < r.Start.Character {
+		return false
+	}
+	return p.Line != r.End.Line || p.Character <= r.End.Character
+}
+
+// overlaps returns true if two ranges overlap or touch.
+func (r textRange) overlaps(other textRange) bool {
+	return r.contains(other.Start) || r.contains(other.End) || other.contains(r.Start)
+}
+
+// diagnostic is a problem reported for a range of a document.
+type diagnostic struct {
+	Range    textRange `json:"range"`
+	Severity int       `json:"severity"`
+	Code     string    `json:"code"`
+	Source   string    `json:"source"`
+	Message  string    `json:"message"`
+}
+
+// textEdit replaces a range of a document with new text.
+type textEdit struct {
+	Range   textRange `json:"range"`
+	NewText string    `json:"newText"`
+}
+
+// workspaceEdit contains the edits of a code action, per document URI.
+type workspaceEdit struct {
+	Changes map[string][]textEdit `json:"changes"`
+}
+
+// codeAction is a quick fix offered for the diagnostics of a range.
+type codeAction struct {
+	Title       string        `json:"title"`
+	Kind        string        `json:"kind"`
+	Diagnostics []diagnostic  `json:"diagnostics,omitempty"`
+	IsPreferred bool          `json:"isPreferred,omitempty"`
+	Edit        workspaceEdit `json:"edit"`
+}
+
+// markupContent is the Markdown content of a hover.
+type markupContent struct {
+	Kind  string `json:"kind"`
+	Value string `json:"value"`
+}
+
+// hover is the information shown when hovering over a range of a document.
+type hover struct {
+	Contents markupContent `json:"contents"`
+	Range    textRange     `json:"range"`
+}
+
+// textDocumentItem is a document opened by the client.
+type textDocumentItem struct {
+	URI     string `json:"uri"`
+	Version int    `json:"version"`
+	Text    string `json:"text"`
+}
+
+// textDocumentIdentifier identifies a document, optionally along with its version.
+type textDocumentIdentifier struct {
+	URI     string `json:"uri"`
+	Version int    `json:"version,omitempty"`
+}
+
+// didOpenParams are the parameters of the textDocument/didOpen notification.
+type didOpenParams struct {
+	TextDocument textDocumentItem `json:"textDocument"`
+}
+
+// didChangeParams are the parameters of the textDocument/didChange notification, whose last change holds the full content.
+type didChangeParams struct {
+	TextDocument   textDocumentIdentifier `json:"textDocument"`
+	ContentChanges []struct {
+		Text string `json:"text"`
+	} `json:"contentChanges"`
+}
+
+// didCloseParams are the parameters of the textDocument/didClose notification.
+type didCloseParams struct {
+	TextDocument textDocumentIdentifier `json:"textDocument"`
+}
+
+// positionParams are the parameters of the textDocument/hover request.
+type positionParams struct {
+	TextDocument textDocumentIdentifier `json:"textDocument"`
+	Position     position               `json:"position"`
+}
+
+// codeActionParams are the parameters of the textDocument/codeAction request.
+type codeActionParams struct {
+	TextDocument textDocumentIdentifier `json:"textDocument"`
+	Range        textRange              `json:"range"`
+}
+
+// publishDiagnosticsParams are the parameters of the textDocument/publishDiagnostics notification.
+type publishDiagnosticsParams struct {
+	URI         string       `json:"uri"`
+	Version     int          `json:"version,omitempty"`
+	Diagnostics []diagnostic `json:"diagnostics"`
+}
+
+// logMessageParams are the parameters of the window/logMessage notification.
+type logMessageParams struct {
+	Type    int    `json:"type"`
+	Message string `json:"message"`
+}
@@ -0,0 +1,294 @@
+/*
+Package lsp provides a language server, speaking the Language Server Protocol over stdio, that reports the drift of API versions in editors.
+
+Open Bicep files (as well as ARM templates and Terraform files) are parsed with the bicep package on every change, and their resources
+are resolved with a Resolver (e.g. apiversions.UpdateResource), whose results are cached per resource type for the lifetime of the server.
+The server publishes diagnostics for outdated, preview and unknown API versions, offers quick fixes rewriting an API version to the latest
+(or GA) one, and shows the available versions of a resource on hover.
+*/
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/christosgalano/bruh/internal/types"
+)
+
+// ErrExitWithoutShutdown is returned by Serve when the client sends the exit notification without a prior shutdown request.
+var ErrExitWithoutShutdown = errors.New("exit without shutdown")
+
+// Resolver updates the available API versions of a resource (e.g. apiversions.UpdateResource with or without preview versions),
+// marking it as unknown if its type does not exist.
+type Resolver func(resource *types.Resource) error
+
+// resolved is the cached result of resolving a resource type.
+type resolved struct {
+	versions    []string
+	unknown     bool
+	suggestions []string
+}
+
+// document is an open document along with its version.
+type document struct {
+	version int
+	text    string
+}
+
+// Server is a language server reporting the drift of API versions in the open documents of a client.
+type Server struct {
+	resolve Resolver
+
+	mu        sync.Mutex
+	documents map[string]document
+	cache     map[string]resolved
+
+	writeMu sync.Mutex
+	out     io.Writer
+	pending sync.WaitGroup
+}
+
+// NewServer returns a language server resolving the resources of the open documents with the given function.
+func NewServer(resolve Resolver) *Server {
+	return &Server{
+		resolve:   resolve,
+		documents: map[string]document{},
+		cache:     map[string]resolved{},
+	}
+}
+
+// send writes a message to the client. Messages can be sent concurrently (e.g. diagnostics published in the background).
+func (s *Server) send(msg message) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	_ = writeMessage(s.out, msg)
+}
+
+// reply sends the response of a request, with either a result or an error.
+func (s *Server) reply(id json.RawMessage, result any, err *responseError) {
+	if err == nil && result == nil {
+		// A null result must still be sent, so it is represented as a raw JSON null
+		result = json.RawMessage("null")
+	}
+	s.send(message{ID: id, Result: result, Error: err})
+}
+
+// notify sends a notification to the client.
+func (s *Server) notify(method string, params any) {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return
+	}
+	s.send(message{Method: method, Params: data})
+}
+
+// log sends a message to the log of the client.
+func (s *Server) log(format string, args ...any) {
+	s.notify("window/logMessage", logMessageParams{Type: 1, Message: fmt.Sprintf(format, args...)})
+}
+
+// Serve reads the messages of a client from in and writes the responses and notifications to out, until the client exits.
+// It returns nil after a shutdown request followed by the exit notification, and ErrExitWithoutShutdown if the client exits without shutting down.
+func (s *Server) Serve(in io.Reader, out io.Writer) error {
+	s.out = out
+	defer s.pending.Wait()
+
+	reader := bufio.NewReader(in)
+	shutdown := false
+	for {
+		body, err := readMessage(reader)
+		if errors.Is(err, io.EOF) {
+			return ErrExitWithoutShutdown
+		}
+		if err != nil {
+			return err
+		}
+
+		var msg message
+		if err := json.Unmarshal(body, &msg); err != nil {
+			s.reply(json.RawMessage("null"), nil, &responseError{Code: codeParseError, Message: err.Error()})
+			continue
+		}
+
+		switch msg.Method {
+		case "exit":
+			if !shutdown {
+				return ErrExitWithoutShutdown
+			}
+			return nil
+		case "shutdown":
+			shutdown = true
+			s.reply(msg.ID, nil, nil)
+		default:
+			s.handle(msg)
+		}
+	}
+}
+
+// handle handles a request or notification other than shutdown and exit.
+// Unknown requests are answered with an error, while unknown notifications are ignored.
+func (s *Server) handle(msg message) {
+	invalid := func(err error) {
+		if msg.ID != nil {
+			s.reply(msg.ID, nil, &responseError{Code: codeInvalidParams, Message: err.Error()})
+		}
+	}
+
+	switch msg.Method {
+	case "initialize":
+		s.reply(msg.ID, map[string]any{
+			"capabilities": map[string]any{
+				"textDocumentSync":   textDocumentSyncFull,
+				"hoverProvider":      true,
+				"codeActionProvider": map[string]any{"codeActionKinds": []string{"quickfix"}},
+			},
+			"serverInfo": map[string]string{"name": "bruh"},
+		}, nil)
+	case "textDocument/didOpen":
+		var params didOpenParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return
+		}
+		s.open(params.TextDocument.URI, params.TextDocument.Version, params.TextDocument.Text)
+	case "textDocument/didChange":
+		var params didChangeParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil || len(params.ContentChanges) == 0 {
+			return
+		}
+		s.open(params.TextDocument.URI, params.TextDocument.Version, params.ContentChanges[len(params.ContentChanges)-1].Text)
+	case "textDocument/didClose":
+		var params didCloseParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return
+		}
+		s.mu.Lock()
+		delete(s.documents, params.TextDocument.URI)
+		s.mu.Unlock()
+		s.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{URI: params.TextDocument.URI, Diagnostics: []diagnostic{}})
+	case "textDocument/hover":
+		var params positionParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			invalid(err)
+			return
+		}
+		result, err := s.hover(params.TextDocument.URI, params.Position)
+		if err != nil {
+			s.log("%s", err)
+		}
+		if result == nil {
+			s.reply(msg.ID, nil, nil)
+			return
+		}
+		s.reply(msg.ID, result, nil)
+	case "textDocument/codeAction":
+		var params codeActionParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			invalid(err)
+			return
+		}
+		actions, err := s.codeActions(params.TextDocument.URI, params.Range)
+		if err != nil {
+			s.log("%s", err)
+		}
+		s.reply(msg.ID, actions, nil)
+	default:
+		if msg.ID != nil {
+			s.reply(msg.ID, nil, &responseError{Code: codeMethodNotFound, Message: "method not found: " + msg.Method})
+		}
+	}
+}
+
+// open stores the content of a document and publishes its diagnostics in the background,
+// as resolving new resource types may take a while.
+func (s *Server) open(uri string, version int, text string) {
+	s.mu.Lock()
+	s.documents[uri] = document{version: version, text: text}
+	s.mu.Unlock()
+
+	s.pending.Add(1)
+	go func() {
+		defer s.pending.Done()
+		s.publish(uri, version)
+	}()
+}
+
+// publish publishes the diagnostics of a document, unless it changed or was closed in the meantime.
+func (s *Server) publish(uri string, version int) {
+	diagnostics, err := s.diagnostics(uri)
+	if err != nil {
+		s.log("%s", err)
+	}
+
+	s.mu.Lock()
+	doc, ok := s.documents[uri]
+	s.mu.Unlock()
+	if !ok || doc.version != version {
+		return
+	}
+	s.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{URI: uri, Version: version, Diagnostics: diagnostics})
+}
+
+// path returns the file path of a document URI (e.g. /src/main.bicep for file:///src/main.bicep).
+func path(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+	p := u.Path
+	// Windows paths (e.g. file:///c:/src/main.bicep)
+	if len(p) > 2 && p[0] == '/' && p[2] == ':' {
+		p = p[1:]
+	}
+	return filepath.FromSlash(p)
+}
+
+// key returns the cache key of a resource: its registry and repository for registry modules, or its type otherwise.
+func key(resource types.Resource) string {
+	if resource.Module {
+		return "br:" + resource.Namespace + "/" + resource.Name
+	}
+	return strings.ToLower(resource.ID)
+}
+
+// resolveAll updates the available API versions of the resources of a file, resolving each resource type only once per server.
+// Resources with an unresolved API version are left untouched.
+func (s *Server) resolveAll(bicepFile *types.BicepFile) error {
+	for i := range bicepFile.Resources {
+		resource := &bicepFile.Resources[i]
+		if resource.Unresolved {
+			continue
+		}
+
+		k := key(*resource)
+		s.mu.Lock()
+		r, ok := s.cache[k]
+		s.mu.Unlock()
+		if !ok {
+			if err := s.resolve(resource); err != nil {
+				return err
+			}
+			r = resolved{versions: resource.AvailableAPIVersions, unknown: resource.Unknown, suggestions: resource.Suggestions}
+			s.mu.Lock()
+			s.cache[k] = r
+			s.mu.Unlock()
+		}
+
+		resource.AvailableAPIVersions = r.versions
+		resource.Unknown = r.unknown
+		resource.Suggestions = r.suggestions
+	}
+	return nil
+}
+
+// readFile reads the files referenced while parsing an open document (e.g. bicepconfig.json) from the file system.
+func readFile(name string) ([]byte, error) {
+	return os.ReadFile(filepath.Clean(name))
+}
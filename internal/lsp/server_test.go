@@ -0,0 +1,327 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/christosgalano/bruh/internal/types"
+)
+
+const testDocument = `resource site 'Microsoft.Web/sites@2021-02-01' = {
+  name: 'app'
+}
+
+resource vault 'Microsoft.KeyVault/vaults@2023-02-01-preview' = {
+  name: 'kv'
+}
+
+resource app 'Microsoft.App/containerApps@2024-02-02-preview' = {
+  name: 'app'
+}
+
+resource fake 'Microsoft.Fake/things@2021-01-01' = {
+  name: 'fake'
+}
+
+resource plan 'Microsoft.Web/serverfarms@2022-09-01' = {
+  name: 'plan'
+}
+`
+
+// fakeResolver resolves resource types from a map, marking the other ones as unknown, and counts the calls per type.
+type fakeResolver struct {
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+func (f *fakeResolver) resolve(resource *types.Resource) error {
+	f.mu.Lock()
+	f.calls[resource.ID]++
+	f.mu.Unlock()
+
+	versions := map[string][]string{
+		"Microsoft.Web/sites":         {"2023-01-01", "2022-09-01", "2021-02-01"},
+		"Microsoft.KeyVault/vaults":   {"2023-07-01", "2023-02-01-preview", "2022-07-01"},
+		"Microsoft.App/containerApps": {"2024-02-02-preview", "2023-05-01"},
+		"Microsoft.Web/serverfarms":   {"2022-09-01", "2021-02-01"},
+	}
+	if v, ok := versions[resource.ID]; ok {
+		resource.AvailableAPIVersions = v
+		return nil
+	}
+	resource.Unknown = true
+	resource.Suggestions = []string{"Microsoft.Fake/thingies"}
+	return nil
+}
+
+// frame returns the messages framed by Content-Length headers.
+func frame(t *testing.T, messages ...string) io.Reader {
+	t.Helper()
+	var b bytes.Buffer
+	for _, msg := range messages {
+		fmt.Fprintf(&b, "Content-Length: %d\r\n\r\n%s", len(msg), msg)
+	}
+	return &b
+}
+
+// decode returns the messages written by the server.
+func decode(t *testing.T, out *bytes.Buffer) []map[string]json.RawMessage {
+	t.Helper()
+	reader := bufio.NewReader(out)
+	messages := []map[string]json.RawMessage{}
+	for {
+		body, err := readMessage(reader)
+		if errors.Is(err, io.EOF) {
+			return messages
+		}
+		if err != nil {
+			t.Fatalf("readMessage() error = %v", err)
+		}
+		var msg map[string]json.RawMessage
+		if err := json.Unmarshal(body, &msg); err != nil {
+			t.Fatalf("invalid message %s: %v", body, err)
+		}
+		messages = append(messages, msg)
+	}
+}
+
+// response returns the response with the given ID.
+func response(t *testing.T, messages []map[string]json.RawMessage, id string) map[string]json.RawMessage {
+	t.Helper()
+	for _, msg := range messages {
+		if string(msg["id"]) == id && msg["method"] == nil {
+			return msg
+		}
+	}
+	t.Fatalf("no response with id %s", id)
+	return nil
+}
+
+func TestServer_Serve(t *testing.T) {
+	uri := "file:///src/main.bicep"
+	open, err := json.Marshal(didOpenParams{TextDocument: textDocumentItem{URI: uri, Version: 1, Text: testDocument}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	change, err := json.Marshal(map[string]any{
+		"textDocument":   map[string]any{"uri": uri, "version": 2},
+		"contentChanges": []map[string]string{{"text": testDocument}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resolver := &fakeResolver{calls: map[string]int{}}
+	in := frame(t,
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`,
+		`{"jsonrpc":"2.0","method":"initialized","params":{}}`,
+		`{"jsonrpc":"2.0","method":"textDocument/didOpen","params":`+string(open)+`}`,
+		`{"jsonrpc":"2.0","method":"textDocument/didChange","params":`+string(change)+`}`,
+		`{"jsonrpc":"2.0","id":2,"method":"textDocument/hover","params":{"textDocument":{"uri":"`+uri+`"},"position":{"line":0,"character":20}}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"textDocument/codeAction","params":{"textDocument":{"uri":"`+uri+`"},"range":{"start":{"line":0,"character":40},"end":{"line":0,"character":40}},"context":{"diagnostics":[]}}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"textDocument/codeAction","params":{"textDocument":{"uri":"`+uri+`"},"range":{"start":{"line":4,"character":45},"end":{"line":4,"character":45}},"context":{"diagnostics":[]}}}`,
+		`{"jsonrpc":"2.0","id":5,"method":"textDocument/hover","params":{"textDocument":{"uri":"`+uri+`"},"position":{"line":1,"character":2}}}`,
+		`{"jsonrpc":"2.0","id":6,"method":"workspace/symbol","params":{}}`,
+		`{"jsonrpc":"2.0","id":7,"method":"shutdown"}`,
+		`{"jsonrpc":"2.0","method":"exit"}`,
+	)
+	out := &bytes.Buffer{}
+	if err := NewServer(resolver.resolve).Serve(in, out); err != nil {
+		t.Fatalf("Serve() error = %v", err)
+	}
+	messages := decode(t, out)
+
+	t.Run("initialize", func(t *testing.T) {
+		var result struct {
+			Capabilities struct {
+				TextDocumentSync int  `json:"textDocumentSync"`
+				HoverProvider    bool `json:"hoverProvider"`
+			} `json:"capabilities"`
+		}
+		if err := json.Unmarshal(response(t, messages, "1")["result"], &result); err != nil {
+			t.Fatal(err)
+		}
+		if result.Capabilities.TextDocumentSync != textDocumentSyncFull || !result.Capabilities.HoverProvider {
+			t.Errorf("initialize result = %+v", result)
+		}
+	})
+
+	t.Run("diagnostics", func(t *testing.T) {
+		var published []publishDiagnosticsParams
+		for _, msg := range messages {
+			if string(msg["method"]) == `"textDocument/publishDiagnostics"` {
+				var params publishDiagnosticsParams
+				if err := json.Unmarshal(msg["params"], &params); err != nil {
+					t.Fatal(err)
+				}
+				published = append(published, params)
+			}
+		}
+		if len(published) == 0 {
+			t.Fatal("no diagnostics published")
+		}
+		last := published[len(published)-1]
+		if last.Version != 2 {
+			t.Errorf("published version = %d, want %d", last.Version, 2)
+		}
+		want := []diagnostic{
+			{
+				Range:    textRange{Start: position{Line: 0, Character: 35}, End: position{Line: 0, Character: 45}},
+				Severity: severityWarning, Code: "outdated", Source: source,
+				Message: "Microsoft.Web/sites is using 2021-02-01 while the latest version is 2023-01-01 (2 version(s) and 699 days behind)",
+			},
+			{
+				Range:    textRange{Start: position{Line: 4, Character: 42}, End: position{Line: 4, Character: 60}},
+				Severity: severityWarning, Code: "promotable", Source: source,
+				Message: "Microsoft.KeyVault/vaults is using preview version 2023-02-01-preview while GA version 2023-07-01 is available",
+			},
+			{
+				Range:    textRange{Start: position{Line: 8, Character: 42}, End: position{Line: 8, Character: 60}},
+				Severity: severityInformation, Code: "preview", Source: source,
+				Message: "Microsoft.App/containerApps is using preview version 2024-02-02-preview",
+			},
+			{
+				Range:    textRange{Start: position{Line: 12, Character: 37}, End: position{Line: 12, Character: 47}},
+				Severity: severityError, Code: "unknown", Source: source,
+				Message: "Microsoft.Fake/things is an unknown resource type (did you mean Microsoft.Fake/thingies?)",
+			},
+		}
+		if !reflect.DeepEqual(last.Diagnostics, want) {
+			t.Errorf("diagnostics = %+v, want %+v", last.Diagnostics, want)
+		}
+	})
+
+	t.Run("resolved-once", func(t *testing.T) {
+		for id, calls := range resolver.calls {
+			if calls != 1 {
+				t.Errorf("resolver calls for %s = %d, want 1", id, calls)
+			}
+		}
+	})
+
+	t.Run("hover", func(t *testing.T) {
+		var result hover
+		if err := json.Unmarshal(response(t, messages, "2")["result"], &result); err != nil {
+			t.Fatal(err)
+		}
+		want := "**Microsoft.Web/sites**: outdated, 2 version(s) and 699 days behind\n\nAvailable versions:\n- `2023-01-01` (latest)\n- `2022-09-01`\n- `2021-02-01` (current)"
+		if result.Contents.Value != want {
+			t.Errorf("hover = %q, want %q", result.Contents.Value, want)
+		}
+		if result.Range.Start.Character != 15 || result.Range.End.Character != 45 {
+			t.Errorf("hover range = %+v", result.Range)
+		}
+		if got := string(response(t, messages, "5")["result"]); got != "null" {
+			t.Errorf("hover outside a resource = %s, want null", got)
+		}
+	})
+
+	t.Run("code-actions", func(t *testing.T) {
+		titles := func(id string) []string {
+			var actions []codeAction
+			if err := json.Unmarshal(response(t, messages, id)["result"], &actions); err != nil {
+				t.Fatal(err)
+			}
+			result := []string{}
+			for _, action := range actions {
+				for _, edit := range action.Edit.Changes[uri] {
+					result = append(result, fmt.Sprintf("%s: %d:%d-%d %s", action.Title, edit.Range.Start.Line, edit.Range.Start.Character, edit.Range.End.Character, edit.NewText))
+				}
+			}
+			return result
+		}
+		if got, want := titles("3"), []string{"Update Microsoft.Web/sites to 2023-01-01: 0:35-45 2023-01-01"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("code actions = %v, want %v", got, want)
+		}
+		if got, want := titles("4"), []string{"Update Microsoft.KeyVault/vaults to GA version 2023-07-01: 4:42-60 2023-07-01"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("code actions = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("method-not-found", func(t *testing.T) {
+		var e responseError
+		if err := json.Unmarshal(response(t, messages, "6")["error"], &e); err != nil {
+			t.Fatal(err)
+		}
+		if e.Code != codeMethodNotFound {
+			t.Errorf("error code = %d, want %d", e.Code, codeMethodNotFound)
+		}
+	})
+
+	t.Run("shutdown", func(t *testing.T) {
+		if got := string(response(t, messages, "7")["result"]); got != "null" {
+			t.Errorf("shutdown result = %s, want null", got)
+		}
+	})
+}
+
+func TestServer_ServeExitWithoutShutdown(t *testing.T) {
+	resolver := &fakeResolver{calls: map[string]int{}}
+	in := frame(t, `{"jsonrpc":"2.0","method":"exit"}`)
+	if err := NewServer(resolver.resolve).Serve(in, &bytes.Buffer{}); !errors.Is(err, ErrExitWithoutShutdown) {
+		t.Errorf("Serve() error = %v, want %v", err, ErrExitWithoutShutdown)
+	}
+}
+
+func Test_locate(t *testing.T) {
+	tests := []struct {
+		name     string
+		lines    []string
+		resource types.Resource
+		want     textRange
+		wantOk   bool
+	}{
+		{
+			name:     "arm-template",
+			lines:    []string{`{`, `      "apiVersion": "2021-04-01",`},
+			resource: types.Resource{ID: "Microsoft.Storage/storageAccounts", CurrentAPIVersion: "2021-04-01", Line: 2},
+			want:     textRange{Start: position{Line: 1, Character: 21}, End: position{Line: 1, Character: 31}},
+			wantOk:   true,
+		},
+		{
+			name:     "utf16-characters",
+			lines:    []string{`// 😀 ü listKeys(storage.id, '2021-04-01')`},
+			resource: types.Resource{ID: "Microsoft.Storage/storageAccounts", CurrentAPIVersion: "2021-04-01", Function: "listKeys", Line: 1},
+			want:     textRange{Start: position{Line: 0, Character: 30}, End: position{Line: 0, Character: 40}},
+			wantOk:   true,
+		},
+		{
+			name:     "module",
+			lines:    []string{`module st 'br/public:avm/res/storage/storage-account:0.4.0' = {`},
+			resource: types.Resource{ID: "br/public:avm/res/storage/storage-account", CurrentAPIVersion: "0.4.0", Module: true, Line: 1},
+			want:     textRange{Start: position{Line: 0, Character: 53}, End: position{Line: 0, Character: 58}},
+			wantOk:   true,
+		},
+		{
+			name:     "line-out-of-range",
+			lines:    []string{``},
+			resource: types.Resource{ID: "Microsoft.Web/sites", CurrentAPIVersion: "2021-02-01", Line: 3},
+			wantOk:   false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := locate(tt.lines, tt.resource)
+			if ok != tt.wantOk {
+				t.Fatalf("locate() ok = %v, want %v", ok, tt.wantOk)
+			}
+			if ok && !reflect.DeepEqual(got.version, tt.want) {
+				t.Errorf("locate() = %+v, want %+v", got.version, tt.want)
+			}
+		})
+	}
+}
+
+func Test_path(t *testing.T) {
+	if got, want := path("file:///src/infra/main%20app.bicep"), strings.Join([]string{"", "src", "infra", "main app.bicep"}, "/"); got != want {
+		t.Errorf("path() = %v, want %v", got, want)
+	}
+}
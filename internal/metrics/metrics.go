@@ -0,0 +1,150 @@
+/*
+Package metrics provides a Prometheus exporter of the drift of API versions across several local repositories.
+
+Each target (a repository, or any directory) is scanned with a scanner.Session, and its resources are aggregated per namespace
+and type into gauges written in the Prometheus text exposition format: the number of resources, outdated resources and preview
+resources, the number of versions they are behind, and the age of the oldest API version in use. The exporter rescans its targets
+periodically with a new session, so that newly published API versions are taken into account, and serves the latest metrics over HTTP.
+*/
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/christosgalano/bruh/internal/scanner"
+	"github.com/christosgalano/bruh/internal/types"
+)
+
+// Target is a scanned path along with its name, used as the repo label of its metrics.
+type Target struct {
+	Name string
+	Path string
+}
+
+// ParseTarget parses a target given as name=path, or as a path whose base name is the name of the target.
+func ParseTarget(value string) (Target, error) {
+	name, path, ok := strings.Cut(value, "=")
+	if !ok {
+		path = value
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return Target{}, err
+		}
+		name = filepath.Base(abs)
+	}
+	if name == "" || path == "" {
+		return Target{}, fmt.Errorf("invalid target %q: expected name=path or path", value)
+	}
+	return Target{Name: name, Path: path}, nil
+}
+
+// Result is the result of scanning a target:
+//   - Time: the time of the scan, and Duration its duration
+//   - Directory: the scanned files, from the latest successful scan if the scan failed
+//   - Err: the error of the scan, if it failed
+type Result struct {
+	Target    Target
+	Time      time.Time
+	Duration  time.Duration
+	Directory *types.BicepDirectory
+	Err       error
+}
+
+// Collect scans the targets with the given session, in order.
+func Collect(session *scanner.Session, targets []Target) []Result {
+	results := make([]Result, 0, len(targets))
+	for _, target := range targets {
+		start := time.Now()
+		bicepDirectory, err := session.Scan(target.Path)
+		results = append(results, Result{Target: target, Time: start, Duration: time.Since(start), Directory: bicepDirectory, Err: err})
+	}
+	return results
+}
+
+// Exporter periodically scans its targets and serves their metrics in the Prometheus text exposition format.
+type Exporter struct {
+	provider scanner.Provider
+	options  scanner.Options
+	targets  []Target
+	logger   *log.Logger
+
+	mu      sync.Mutex
+	results []Result
+}
+
+// NewExporter returns an exporter scanning the targets with the given options, fetching the available versions with the given
+// provider (e.g. apiversions.Client), and logging to the given logger (nowhere if nil).
+func NewExporter(provider scanner.Provider, options scanner.Options, targets []Target, logger *log.Logger) *Exporter {
+	if logger == nil {
+		logger = log.New(io.Discard, "", 0)
+	}
+	return &Exporter{provider: provider, options: options, targets: targets, logger: logger}
+}
+
+// Refresh scans the targets with a new session, keeping the files of the latest successful scan of the targets whose scan fails.
+func (e *Exporter) Refresh() {
+	results := Collect(scanner.NewWithProvider(e.options, e.provider, e.logger), e.targets)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for i := range results {
+		if results[i].Err == nil {
+			continue
+		}
+		e.logger.Printf("failed to scan %s: %s", results[i].Target.Path, results[i].Err)
+		for _, previous := range e.results {
+			if previous.Target == results[i].Target {
+				results[i].Directory = previous.Directory
+			}
+		}
+	}
+	e.results = results
+}
+
+// Run refreshes the metrics immediately and then at the given interval, until the context is canceled.
+func (e *Exporter) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		e.Refresh()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ErrNotCollected is returned by WriteTo when the targets were not scanned yet.
+var ErrNotCollected = errors.New("metrics not collected yet")
+
+// WriteTo writes the metrics of the latest scan of the targets.
+func (e *Exporter) WriteTo(w io.Writer) (int64, error) {
+	e.mu.Lock()
+	results := e.results
+	e.mu.Unlock()
+	if results == nil {
+		return 0, ErrNotCollected
+	}
+	return Write(w, results)
+}
+
+// ServeHTTP serves the metrics of the latest scan of the targets, or 503 if they were not scanned yet.
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	var buf strings.Builder
+	if _, err := e.WriteTo(&buf); err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", ContentType)
+	io.WriteString(w, buf.String())
+}
@@ -0,0 +1,158 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/christosgalano/bruh/internal/scanner"
+	"github.com/christosgalano/bruh/internal/types"
+)
+
+// fakeProvider returns fixed versions per resource type, marking the other types as unknown.
+type fakeProvider struct{}
+
+func (fakeProvider) UpdateResource(resource *types.Resource, _ bool) error {
+	switch resource.ID {
+	case "Microsoft.Web/sites":
+		resource.AvailableAPIVersions = []string{"2023-01-01", "2022-09-01", "2021-02-01"}
+	case "Microsoft.KeyVault/vaults":
+		resource.AvailableAPIVersions = []string{"2023-07-01"}
+	default:
+		resource.Unknown = true
+	}
+	return nil
+}
+
+// project writes the files of a test repository to a temporary directory and returns its path.
+func project(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		"main.bicep": "resource site 'Microsoft.Web/sites@2021-02-01' = {\n  name: 'app'\n}\n\n" +
+			"resource other 'Microsoft.Web/sites@2023-01-01' = {\n  name: 'other'\n}\n",
+		"vault.bicep": "resource vault 'Microsoft.KeyVault/vaults@2023-02-01-preview' = {\n  name: 'kv'\n}\n\n" +
+			"resource fake 'Microsoft.Fake/things@2021-01-01' = {\n  name: 'fake'\n}\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestParseTarget(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    Target
+		wantErr bool
+	}{
+		{name: "name-and-path", value: "infra=./repos/infra", want: Target{Name: "infra", Path: "./repos/infra"}},
+		{name: "path", value: "/srv/repos/network", want: Target{Name: "network", Path: "/srv/repos/network"}},
+		{name: "empty-name", value: "=./repos/infra", wantErr: true},
+		{name: "empty-path", value: "infra=", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTarget(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseTarget() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("ParseTarget() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	session := scanner.NewWithProvider(scanner.Options{}, fakeProvider{}, nil)
+	results := Collect(session, []Target{{Name: "infra", Path: project(t)}, {Name: "missing", Path: filepath.Join(t.TempDir(), "missing")}})
+	for i := range results {
+		results[i].Time = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		results[i].Duration = 1500 * time.Millisecond
+	}
+
+	var b strings.Builder
+	if _, err := Write(&b, results); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	got := b.String()
+
+	want := []string{
+		"# HELP bruh_resources Number of resources with a known type and API version.\n# TYPE bruh_resources gauge\n",
+		`bruh_resources{repo="infra",namespace="Microsoft.KeyVault",type="Microsoft.KeyVault/vaults"} 1`,
+		`bruh_resources{repo="infra",namespace="Microsoft.Web",type="Microsoft.Web/sites"} 2`,
+		`bruh_outdated_resources{repo="infra",namespace="Microsoft.Web",type="Microsoft.Web/sites"} 1`,
+		`bruh_versions_behind{repo="infra",namespace="Microsoft.Web",type="Microsoft.Web/sites"} 2`,
+		`bruh_oldest_api_version_age_days{repo="infra",namespace="Microsoft.Web",type="Microsoft.Web/sites"} 1064`,
+		`bruh_preview_resources{repo="infra",namespace="Microsoft.KeyVault",type="Microsoft.KeyVault/vaults"} 1`,
+		`bruh_preview_resources{repo="infra",namespace="Microsoft.Web",type="Microsoft.Web/sites"} 0`,
+		`bruh_unknown_resources{repo="infra"} 1`,
+		`bruh_files{repo="infra"} 2`,
+		`bruh_scan_success{repo="infra"} 1`,
+		`bruh_scan_success{repo="missing"} 0`,
+		`bruh_scan_timestamp_seconds{repo="infra"} 1.7040672e+09`,
+		`bruh_scan_duration_seconds{repo="missing"} 1.5`,
+	}
+	for _, w := range want {
+		if !strings.Contains(got, w) {
+			t.Errorf("Write() = %s\nwant it to contain %q", got, w)
+		}
+	}
+	if strings.Contains(got, "Microsoft.Fake") || strings.Contains(got, `bruh_files{repo="missing"}`) {
+		t.Errorf("Write() = %s\nwant no metrics of unknown types and failed scans", got)
+	}
+}
+
+func Test_labelEscaper(t *testing.T) {
+	if got, want := labelEscaper.Replace("a\"b\\c\nd"), `a\"b\\c\nd`; got != want {
+		t.Errorf("labelEscaper.Replace() = %q, want %q", got, want)
+	}
+}
+
+func TestExporter(t *testing.T) {
+	dir := project(t)
+	exporter := NewExporter(fakeProvider{}, scanner.Options{}, []Target{{Name: "infra", Path: dir}}, nil)
+
+	w := httptest.NewRecorder()
+	exporter.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("ServeHTTP() status = %d, want %d before the first scan", w.Code, http.StatusServiceUnavailable)
+	}
+	if _, err := exporter.WriteTo(&strings.Builder{}); !errors.Is(err, ErrNotCollected) {
+		t.Errorf("WriteTo() error = %v, want %v", err, ErrNotCollected)
+	}
+
+	exporter.Refresh()
+	w = httptest.NewRecorder()
+	exporter.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != ContentType {
+		t.Fatalf("ServeHTTP() status = %d, Content-Type = %q, want %d and %q", w.Code, w.Header().Get("Content-Type"), http.StatusOK, ContentType)
+	}
+	if !strings.Contains(w.Body.String(), `bruh_scan_success{repo="infra"} 1`) {
+		t.Errorf("ServeHTTP() = %s, want a successful scan", w.Body)
+	}
+
+	// A failed scan keeps the files of the previous one
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+	exporter.Refresh()
+	var b strings.Builder
+	if _, err := exporter.WriteTo(&b); err != nil {
+		t.Fatalf("WriteTo() error = %v", err)
+	}
+	for _, want := range []string{`bruh_scan_success{repo="infra"} 0`, `bruh_files{repo="infra"} 2`} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("WriteTo() = %s\nwant it to contain %q after a failed scan", b.String(), want)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/christosgalano/bruh/internal/types"
+)
+
+// ContentType is the content type of the Prometheus text exposition format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// metric is a gauge along with its samples.
+type metric struct {
+	name    string
+	help    string
+	samples []sample
+}
+
+// sample is a value of a metric along with its labels, given as name and value pairs.
+type sample struct {
+	labels []string
+	value  float64
+}
+
+// group is the resources of a target with the same namespace and type.
+type group struct {
+	namespace    string
+	resourceType string
+	resources    []types.Resource
+}
+
+// groups returns the resources of a scanned directory grouped by namespace and type, sorted by namespace and then type.
+func groups(bicepDirectory *types.BicepDirectory) []group {
+	index := map[string]int{}
+	result := []group{}
+	for _, bicepFile := range bicepDirectory.Files {
+		for _, resource := range bicepFile.Resources {
+			key := resource.Namespace + "\x00" + resource.ID
+			i, ok := index[key]
+			if !ok {
+				i = len(result)
+				index[key] = i
+				result = append(result, group{namespace: resource.Namespace, resourceType: resource.ID})
+			}
+			result[i].resources = append(result[i].resources, resource)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].namespace != result[j].namespace {
+			return result[i].namespace < result[j].namespace
+		}
+		return result[i].resourceType < result[j].resourceType
+	})
+	return result
+}
+
+// metrics returns the metrics of the given results.
+func metrics(results []Result) []metric {
+	resources := metric{name: "bruh_resources", help: "Number of resources with a known type and API version."}
+	outdated := metric{name: "bruh_outdated_resources", help: "Number of resources not using the latest API version."}
+	behind := metric{name: "bruh_versions_behind", help: "Total number of API versions the resources are behind."}
+	oldest := metric{name: "bruh_oldest_api_version_age_days", help: "Age in days of the oldest API version in use."}
+	preview := metric{name: "bruh_preview_resources", help: "Number of resources using a preview API version."}
+	unknown := metric{name: "bruh_unknown_resources", help: "Number of resources whose type does not exist."}
+	score := metric{name: "bruh_drift_score", help: "Drift score of the repository, i.e. the total gap in months between the current and latest API versions."}
+	files := metric{name: "bruh_files", help: "Number of scanned files."}
+	success := metric{name: "bruh_scan_success", help: "Whether the latest scan of the repository succeeded."}
+	timestamp := metric{name: "bruh_scan_timestamp_seconds", help: "Unix time of the latest scan of the repository."}
+	duration := metric{name: "bruh_scan_duration_seconds", help: "Duration of the latest scan of the repository in seconds."}
+
+	for _, result := range results {
+		repo := []string{"repo", result.Target.Name}
+		ok := 1.0
+		if result.Err != nil {
+			ok = 0
+		}
+		success.samples = append(success.samples, sample{labels: repo, value: ok})
+		timestamp.samples = append(timestamp.samples, sample{labels: repo, value: float64(result.Time.Unix())})
+		duration.samples = append(duration.samples, sample{labels: repo, value: result.Duration.Seconds()})
+		if result.Directory == nil {
+			continue
+		}
+
+		files.samples = append(files.samples, sample{labels: repo, value: float64(len(result.Directory.Files))})
+		score.samples = append(score.samples, sample{labels: repo, value: result.Directory.Drift(result.Time).Score})
+		unknownCount := 0
+		for _, g := range groups(result.Directory) {
+			labels := []string{"repo", result.Target.Name, "namespace", g.namespace, "type", g.resourceType}
+			drift := types.BicepFile{Resources: g.resources}.Drift(result.Time)
+			previewCount := 0
+			for _, resource := range g.resources {
+				if resource.Unknown {
+					unknownCount++
+				} else if strings.HasSuffix(resource.CurrentAPIVersion, "-preview") {
+					previewCount++
+				}
+			}
+			if drift.Resources == 0 {
+				continue
+			}
+			resources.samples = append(resources.samples, sample{labels: labels, value: float64(drift.Resources)})
+			outdated.samples = append(outdated.samples, sample{labels: labels, value: float64(drift.Outdated)})
+			behind.samples = append(behind.samples, sample{labels: labels, value: float64(drift.VersionsBehind)})
+			oldest.samples = append(oldest.samples, sample{labels: labels, value: float64(drift.MaxAgeDays)})
+			preview.samples = append(preview.samples, sample{labels: labels, value: float64(previewCount)})
+		}
+		unknown.samples = append(unknown.samples, sample{labels: repo, value: float64(unknownCount)})
+	}
+	return []metric{resources, outdated, behind, oldest, preview, unknown, score, files, success, timestamp, duration}
+}
+
+// labelEscaper escapes the values of labels.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// Write writes the metrics of the given results in the Prometheus text exposition format.
+func Write(w io.Writer, results []Result) (int64, error) {
+	var b strings.Builder
+	for _, m := range metrics(results) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s gauge\n", m.name, m.help, m.name)
+		for _, s := range m.samples {
+			b.WriteString(m.name)
+			if len(s.labels) > 0 {
+				b.WriteString("{")
+				for i := 0; i < len(s.labels); i += 2 {
+					if i > 0 {
+						b.WriteString(",")
+					}
+					fmt.Fprintf(&b, "%s=\"%s\"", s.labels[i], labelEscaper.Replace(s.labels[i+1]))
+				}
+				b.WriteString("}")
+			}
+			fmt.Fprintf(&b, " %s\n", strconv.FormatFloat(s.value, 'g', -1, 64))
+		}
+	}
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
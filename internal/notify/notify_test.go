@@ -0,0 +1,174 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/christosgalano/bruh/internal/types"
+)
+
+var now = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// directory returns a scanned directory with resources of every status.
+func directory() *types.BicepDirectory {
+	dir := "infra"
+	return &types.BicepDirectory{
+		Path: dir,
+		Files: []types.BicepFile{
+			{
+				Path: filepath.Join(dir, "main.bicep"),
+				Resources: []types.Resource{
+					{ID: "Microsoft.Web/sites", Line: 1, CurrentAPIVersion: "2022-09-01", AvailableAPIVersions: []string{"2023-01-01", "2022-09-01"}},
+					{ID: "Microsoft.Web/serverfarms", Line: 5, CurrentAPIVersion: "2022-09-01", AvailableAPIVersions: []string{"2022-09-01"}},
+					{ID: "Microsoft.Fake/things", Line: 9, CurrentAPIVersion: "2021-01-01", Unknown: true},
+				},
+			},
+			{
+				Path: filepath.Join(dir, "modules", "data.bicep"),
+				Resources: []types.Resource{
+					{ID: "Microsoft.Storage/storageAccounts", Line: 3, CurrentAPIVersion: "2021-02-01", AvailableAPIVersions: []string{"2023-01-01", "2022-09-01", "2021-02-01"}},
+					{ID: "Microsoft.KeyVault/vaults", Line: 12, CurrentAPIVersion: "2022-02-01-preview", AvailableAPIVersions: []string{"2022-07-01"}},
+					{ID: "Microsoft.Storage/storageAccounts", Line: 20, Function: "listKeys", CurrentAPIVersion: "[variables('v')]", Unresolved: true},
+				},
+			},
+		},
+	}
+}
+
+func TestNewSummary(t *testing.T) {
+	tests := []struct {
+		name    string
+		top     int
+		wantTop []string
+	}{
+		{name: "all", top: 5, wantTop: []string{"modules/data.bicep:3", "modules/data.bicep:12", "main.bicep:1"}},
+		{name: "limited", top: 2, wantTop: []string{"modules/data.bicep:3", "modules/data.bicep:12"}},
+		{name: "none", top: 0, wantTop: []string{}},
+		{name: "negative", top: -1, wantTop: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			summary := NewSummary(directory(), tt.top, now)
+			counts := []int{summary.Files, summary.Resources, summary.Latest, summary.Outdated, summary.Promotable, summary.Unknown, summary.Unresolved}
+			if want := []int{2, 6, 1, 2, 1, 1, 1}; !reflect.DeepEqual(counts, want) {
+				t.Errorf("NewSummary() counts = %v, want %v", counts, want)
+			}
+			top := []string{}
+			for _, entry := range summary.Top {
+				top = append(top, entry.File+":"+strconv.Itoa(entry.Line))
+			}
+			if !reflect.DeepEqual(top, tt.wantTop) {
+				t.Errorf("NewSummary() top = %v, want %v", top, tt.wantTop)
+			}
+		})
+	}
+}
+
+func TestWebhook_Send(t *testing.T) {
+	template, err := ParseTemplate(`{"message": {{ json .Path }}, "outdated": {{ .Outdated }}{{ range .Top }}, "first": {{ json .Type }}{{ break }}{{ end }}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		webhook Webhook
+		status  int
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:    "slack",
+			webhook: Webhook{Format: FormatSlack},
+			status:  http.StatusOK,
+			want: []string{
+				`"text":"*bruh scan of infra*\n2 outdated, 1 promotable and 1 unknown out of 6 resources in 2 files`,
+				"• `Microsoft.Storage/storageAccounts` in modules/data.bicep:3: 2021-02-01 → 2023-01-01 (2 versions behind, 1064 days old)",
+			},
+		},
+		{
+			name:    "teams",
+			webhook: Webhook{Format: FormatTeams},
+			status:  http.StatusAccepted,
+			want: []string{
+				`"contentType":"application/vnd.microsoft.card.adaptive"`,
+				`{"title":"Outdated","value":"2"}`,
+				`- Microsoft.KeyVault/vaults in modules/data.bicep:12: 2022-02-01-preview → 2022-07-01`,
+			},
+		},
+		{
+			name:    "generic",
+			webhook: Webhook{Format: FormatGeneric},
+			status:  http.StatusNoContent,
+			want:    []string{`"path":"infra"`, `"outdated":2`, `"top":[{"file":"modules/data.bicep","line":3`},
+		},
+		{
+			name:    "generic-template",
+			webhook: Webhook{Format: FormatGeneric, Template: template},
+			status:  http.StatusOK,
+			want:    []string{`{"message": "infra", "outdated": 2, "first": "Microsoft.Storage/storageAccounts"}`},
+		},
+		{
+			name:    "error-status",
+			webhook: Webhook{Format: FormatSlack},
+			status:  http.StatusBadRequest,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body, contentType string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				data, _ := io.ReadAll(r.Body)
+				body, contentType = string(data), r.Header.Get("Content-Type")
+				w.WriteHeader(tt.status)
+				io.WriteString(w, "invalid_payload")
+			}))
+			defer server.Close()
+
+			webhook := tt.webhook
+			webhook.URL = server.URL
+			err := webhook.Send(context.Background(), NewSummary(directory(), 5, now))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Send() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if !strings.Contains(err.Error(), "400") || !strings.Contains(err.Error(), "invalid_payload") {
+					t.Errorf("Send() error = %v, want the status and body of the response", err)
+				}
+				return
+			}
+			if contentType != "application/json" {
+				t.Errorf("Send() Content-Type = %q, want %q", contentType, "application/json")
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(body, want) {
+					t.Errorf("Send() body = %s\nwant it to contain %s", body, want)
+				}
+			}
+			if tt.webhook.Template == nil && !json.Valid([]byte(body)) {
+				t.Errorf("Send() body = %s, want valid JSON", body)
+			}
+		})
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for _, format := range []string{"slack", "teams", "generic"} {
+		if got, err := ParseFormat(format); err != nil || string(got) != format {
+			t.Errorf("ParseFormat(%q) = %q, %v", format, got, err)
+		}
+	}
+	if _, err := ParseFormat("discord"); err == nil {
+		t.Errorf("ParseFormat(%q) error = nil, want an error", "discord")
+	}
+}
@@ -0,0 +1,108 @@
+/*
+Package notify provides webhook notifications summarizing the results of a scan, e.g. to report the drift of API versions after nightly scans.
+
+A Summary counts the resources of a scanned directory per status and lists its top outdated resources, those furthest behind
+their latest API versions. A Webhook posts it as a Slack message, a Microsoft Teams Adaptive Card (as accepted by Teams workflows),
+or a generic JSON payload, which a text/template can replace with any body.
+*/
+package notify
+
+import (
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/christosgalano/bruh/internal/types"
+)
+
+// Entry is an outdated (or promotable) resource of a summary.
+type Entry struct {
+	File           string `json:"file"`
+	Line           int    `json:"line"`
+	Type           string `json:"type"`
+	Function       string `json:"function,omitempty"`
+	CurrentVersion string `json:"currentVersion"`
+	LatestVersion  string `json:"latestVersion"`
+	Status         string `json:"status"`
+	VersionsBehind int    `json:"versionsBehind"`
+	AgeDays        int    `json:"ageDays"`
+}
+
+// Summary is the summary of a scan:
+//   - Path: the scanned path, and Time the time of the scan
+//   - Files and Resources: the number of scanned files and resources
+//   - Latest, Outdated, Promotable, Unknown and Unresolved: the number of resources per status
+//   - DriftScore: the drift score of the scanned path (see types.Drift)
+//   - Top: the outdated and promotable resources furthest behind their latest API versions, at most the given number
+type Summary struct {
+	Path       string    `json:"path"`
+	Time       time.Time `json:"time"`
+	Files      int       `json:"files"`
+	Resources  int       `json:"resources"`
+	Latest     int       `json:"latest"`
+	Outdated   int       `json:"outdated"`
+	Promotable int       `json:"promotable"`
+	Unknown    int       `json:"unknown"`
+	Unresolved int       `json:"unresolved"`
+	DriftScore float64   `json:"driftScore"`
+	Top        []Entry   `json:"top"`
+}
+
+// NewSummary returns the summary of a scanned directory at the given time, listing at most top outdated resources (none if top is negative).
+// The top resources are the ones with the most versions behind, then the oldest API versions, with their paths relative to the directory.
+func NewSummary(bicepDirectory *types.BicepDirectory, top int, now time.Time) Summary {
+	if top < 0 {
+		top = 0
+	}
+	summary := Summary{Path: bicepDirectory.Path, Time: now, Files: len(bicepDirectory.Files), Top: []Entry{}}
+	entries := []Entry{}
+	for _, bicepFile := range bicepDirectory.Files {
+		file := bicepFile.Path
+		if rel, err := filepath.Rel(bicepDirectory.Path, bicepFile.Path); err == nil && rel != "." {
+			file = filepath.ToSlash(rel)
+		}
+		for _, resource := range bicepFile.Resources {
+			summary.Resources++
+			status := resource.Status()
+			switch status {
+			case types.StatusLatest:
+				summary.Latest++
+				continue
+			case types.StatusUnknown:
+				summary.Unknown++
+				continue
+			case types.StatusUnresolved:
+				summary.Unresolved++
+				continue
+			case types.StatusPromotable:
+				summary.Promotable++
+			case types.StatusOutdated:
+				summary.Outdated++
+			}
+			entries = append(entries, Entry{
+				File:           file,
+				Line:           resource.Line,
+				Type:           resource.ID,
+				Function:       resource.Function,
+				CurrentVersion: resource.CurrentAPIVersion,
+				LatestVersion:  resource.LatestAPIVersion(),
+				Status:         status.String(),
+				VersionsBehind: resource.VersionsBehind(),
+				AgeDays:        resource.AgeDays(now),
+			})
+		}
+	}
+	summary.DriftScore = bicepDirectory.Drift(now).Score
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].VersionsBehind != entries[j].VersionsBehind {
+			return entries[i].VersionsBehind > entries[j].VersionsBehind
+		}
+		return entries[i].AgeDays > entries[j].AgeDays
+	})
+	if len(entries) > top {
+		entries = entries[:top]
+	}
+	summary.Top = append(summary.Top, entries...)
+	return summary
+}
@@ -0,0 +1,182 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"text/template"
+)
+
+// Format is the format of the payload posted to a webhook.
+type Format string
+
+const (
+	// FormatSlack posts a Slack message, for Slack incoming webhooks (and compatible services such as Mattermost).
+	FormatSlack Format = "slack"
+
+	// FormatTeams posts a message with an Adaptive Card, for Microsoft Teams workflows.
+	FormatTeams Format = "teams"
+
+	// FormatGeneric posts the summary as JSON, or the body rendered by the template of the webhook.
+	FormatGeneric Format = "generic"
+)
+
+// ParseFormat parses the format of a webhook.
+func ParseFormat(format string) (Format, error) {
+	switch f := Format(format); f {
+	case FormatSlack, FormatTeams, FormatGeneric:
+		return f, nil
+	}
+	return "", fmt.Errorf("invalid webhook format %q: expected slack, teams or generic", format)
+}
+
+// ParseTemplate parses the template of a generic payload, executed with a Summary.
+// Besides the builtin functions, json encodes a value as JSON (e.g. {{ json .Path }} for a quoted and escaped string).
+func ParseTemplate(text string) (*template.Template, error) {
+	return template.New("webhook").Funcs(template.FuncMap{
+		"json": func(v any) (string, error) {
+			data, err := json.Marshal(v)
+			return string(data), err
+		},
+	}).Parse(text)
+}
+
+// Webhook posts summaries to a URL in a format.
+//   - Template: the template of the generic payload, executed with the summary (the summary as JSON if nil)
+//   - HTTPClient: the client posting the payloads (http.DefaultClient if nil)
+//   - Top: the number of outdated resources listed in the summaries posted
+type Webhook struct {
+	URL        string
+	Format     Format
+	Template   *template.Template
+	HTTPClient *http.Client
+	Top        int
+}
+
+// title returns the title of a message summarizing a scan.
+func title(summary Summary) string {
+	return fmt.Sprintf("bruh scan of %s", summary.Path)
+}
+
+// counts returns the counts of a summary in a sentence.
+func counts(summary Summary) string {
+	return fmt.Sprintf("%d outdated, %d promotable and %d unknown out of %d resources in %d files (drift score %g)",
+		summary.Outdated, summary.Promotable, summary.Unknown, summary.Resources, summary.Files, summary.DriftScore)
+}
+
+// entryLine returns the description of an entry of a summary, quoting its resource type with the given delimiter.
+func entryLine(entry Entry, quote string) string {
+	resource := quote + entry.Type + quote
+	if entry.Function != "" {
+		resource += " (" + entry.Function + ")"
+	}
+	return fmt.Sprintf("%s in %s:%d: %s → %s (%d versions behind, %d days old)",
+		resource, entry.File, entry.Line, entry.CurrentVersion, entry.LatestVersion, entry.VersionsBehind, entry.AgeDays)
+}
+
+// slackPayload returns the Slack message of a summary, in mrkdwn.
+func slackPayload(summary Summary) any {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%s*\n%s", title(summary), counts(summary))
+	if len(summary.Top) > 0 {
+		b.WriteString("\n\n*Top outdated resources*")
+		for _, entry := range summary.Top {
+			b.WriteString("\n• " + entryLine(entry, "`"))
+		}
+	}
+	return map[string]string{"text": b.String()}
+}
+
+// teamsPayload returns the Teams message of a summary, with an Adaptive Card.
+func teamsPayload(summary Summary) any {
+	facts := []map[string]string{}
+	for _, fact := range [][2]string{
+		{"Files", fmt.Sprint(summary.Files)},
+		{"Resources", fmt.Sprint(summary.Resources)},
+		{"Latest", fmt.Sprint(summary.Latest)},
+		{"Outdated", fmt.Sprint(summary.Outdated)},
+		{"Promotable", fmt.Sprint(summary.Promotable)},
+		{"Unknown", fmt.Sprint(summary.Unknown)},
+		{"Drift score", fmt.Sprint(summary.DriftScore)},
+	} {
+		facts = append(facts, map[string]string{"title": fact[0], "value": fact[1]})
+	}
+	body := []map[string]any{
+		{"type": "TextBlock", "text": title(summary), "weight": "Bolder", "size": "Medium", "wrap": true},
+		{"type": "FactSet", "facts": facts},
+	}
+	if len(summary.Top) > 0 {
+		lines := []string{}
+		for _, entry := range summary.Top {
+			lines = append(lines, "- "+entryLine(entry, ""))
+		}
+		body = append(body,
+			map[string]any{"type": "TextBlock", "text": "Top outdated resources", "weight": "Bolder", "wrap": true},
+			map[string]any{"type": "TextBlock", "text": strings.Join(lines, "\n"), "wrap": true},
+		)
+	}
+	return map[string]any{
+		"type": "message",
+		"attachments": []map[string]any{{
+			"contentType": "application/vnd.microsoft.card.adaptive",
+			"content": map[string]any{
+				"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+				"type":    "AdaptiveCard",
+				"version": "1.4",
+				"body":    body,
+			},
+		}},
+	}
+}
+
+// Payload returns the body posted for a summary in the format of the webhook.
+func (w *Webhook) Payload(summary Summary) ([]byte, error) {
+	switch w.Format {
+	case FormatSlack:
+		return json.Marshal(slackPayload(summary))
+	case FormatTeams:
+		return json.Marshal(teamsPayload(summary))
+	case FormatGeneric:
+		if w.Template == nil {
+			return json.Marshal(summary)
+		}
+		var buf bytes.Buffer
+		if err := w.Template.Execute(&buf, summary); err != nil {
+			return nil, fmt.Errorf("failed to render webhook template: %w", err)
+		}
+		return buf.Bytes(), nil
+	}
+	return nil, fmt.Errorf("invalid webhook format %q", w.Format)
+}
+
+// Send posts a summary to the webhook, returning an error if the response status is not 2xx.
+func (w *Webhook) Send(ctx context.Context, summary Summary) error {
+	payload, err := w.Payload(summary)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := w.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("webhook responded with status %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
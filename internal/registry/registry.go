@@ -0,0 +1,277 @@
+/*
+Package registry provides functions to fetch the available versions of Bicep modules published to OCI registries.
+
+The tags of a module are listed through the OCI distribution API (GET /v2/<repository>/tags/list), using anonymous bearer tokens
+when the registry requires them (e.g. mcr.microsoft.com or Azure Container Registries allowing anonymous pull).
+Only semantic version tags (e.g. 0.4.0 or v1.2.3) are considered, sorted in descending order.
+*/
+package registry
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/christosgalano/bruh/internal/types"
+)
+
+var (
+	// ErrNotFound is returned when the requested repository does not exist.
+	ErrNotFound = errors.New("repository not found")
+
+	// semverRegex is the regex used to match semantic version tags.
+	semverRegex = regexp.MustCompile(`^v?(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)(?:-([0-9A-Za-z.-]+))?(?:\+[0-9A-Za-z.-]+)?$`)
+
+	// challengeRegex is the regex used to extract the parameters of a bearer challenge (e.g. realm="https://...").
+	challengeRegex = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+	// linkRegex is the regex used to extract the next page of a paginated response from its Link header.
+	linkRegex = regexp.MustCompile(`<([^>]+)>\s*;\s*rel="?next"?`)
+)
+
+// baseURL returns the base URL of a registry. Registries on localhost are accessed over plain HTTP.
+func baseURL(registry string) string {
+	host := registry
+	if h, _, found := strings.Cut(registry, ":"); found {
+		host = h
+	}
+	if host == "localhost" || host == "127.0.0.1" || host == "[::1]" {
+		return "http://" + registry
+	}
+	return "https://" + registry
+}
+
+// Client fetches the tags of modules with its HTTP client. The zero value uses http.DefaultClient.
+type Client struct {
+	HTTPClient *http.Client
+}
+
+// httpClient returns the HTTP client of the client, or http.DefaultClient if it is not set.
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient == nil {
+		return http.DefaultClient
+	}
+	return c.HTTPClient
+}
+
+// token requests an anonymous bearer token as described by a WWW-Authenticate challenge.
+func (c *Client) token(challenge string) (string, error) {
+	params := map[string]string{}
+	for _, match := range challengeRegex.FindAllStringSubmatch(challenge, -1) {
+		params[strings.ToLower(match[1])] = match[2]
+	}
+	realm, ok := params["realm"]
+	if !ok || !strings.HasPrefix(strings.ToLower(challenge), "bearer") {
+		return "", fmt.Errorf("unsupported authentication challenge %q", challenge)
+	}
+
+	query := url.Values{}
+	for _, key := range []string{"service", "scope"} {
+		if value, ok := params[key]; ok {
+			query.Set(key, value)
+		}
+	}
+	resp, err := c.httpClient().Get(realm + "?" + query.Encode())
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status %q: %s", resp.Status, realm)
+	}
+
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", err
+	}
+	if body.Token != "" {
+		return body.Token, nil
+	}
+	return body.AccessToken, nil
+}
+
+// get fetches a URL of a registry, authenticating with an anonymous bearer token if the registry requires one.
+// The token is reused for subsequent requests.
+func (c *Client) get(target string, bearer *string) (*http.Response, error) {
+	request := func() (*http.Response, error) {
+		req, err := http.NewRequest(http.MethodGet, target, http.NoBody)
+		if err != nil {
+			return nil, err
+		}
+		if *bearer != "" {
+			req.Header.Set("Authorization", "Bearer "+*bearer)
+		}
+		return c.httpClient().Do(req)
+	}
+
+	resp, err := request()
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusUnauthorized {
+		return resp, nil
+	}
+	resp.Body.Close()
+
+	t, err := c.token(resp.Header.Get("WWW-Authenticate"))
+	if err != nil {
+		return nil, err
+	}
+	*bearer = t
+	return request()
+}
+
+// ListTags returns the tags of a repository of a registry (e.g. mcr.microsoft.com and bicep/avm/res/storage/storage-account),
+// using http.DefaultClient. If the repository does not exist, the function returns ErrNotFound.
+func ListTags(registry, repository string) ([]string, error) {
+	return (&Client{}).ListTags(registry, repository)
+}
+
+// ListTags returns the tags of a repository of a registry (e.g. mcr.microsoft.com and bicep/avm/res/storage/storage-account).
+// If the repository does not exist, the method returns ErrNotFound.
+func (c *Client) ListTags(registry, repository string) ([]string, error) {
+	base := baseURL(registry)
+	next := base + "/v2/" + repository + "/tags/list"
+	bearer := ""
+	tags := []string{}
+
+	for next != "" {
+		resp, err := c.get(next, &bearer)
+		if err != nil {
+			return nil, err
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("%w: %s/%s", ErrNotFound, registry, repository)
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("unexpected status %q: %s", resp.Status, next)
+		}
+
+		var page struct {
+			Tags []string `json:"tags"`
+		}
+		if err := json.Unmarshal(body, &page); err != nil {
+			return nil, fmt.Errorf("failed to parse tags of %s/%s: %w", registry, repository, err)
+		}
+		tags = append(tags, page.Tags...)
+
+		// Follow the pagination links, which may be relative to the registry
+		next = ""
+		if match := linkRegex.FindStringSubmatch(resp.Header.Get("Link")); match != nil {
+			next = match[1]
+			if strings.HasPrefix(next, "/") {
+				next = base + next
+			}
+		}
+	}
+	return tags, nil
+}
+
+// semver is a parsed semantic version.
+type semver struct {
+	major, minor, patch int
+	prerelease          string
+}
+
+// parseSemver parses a semantic version tag, or returns false if the tag is not one.
+func parseSemver(tag string) (semver, bool) {
+	match := semverRegex.FindStringSubmatch(tag)
+	if match == nil {
+		return semver{}, false
+	}
+	major, _ := strconv.Atoi(match[1])
+	minor, _ := strconv.Atoi(match[2])
+	patch, _ := strconv.Atoi(match[3])
+	return semver{major: major, minor: minor, patch: patch, prerelease: match[4]}, true
+}
+
+// less returns true if the version has a lower precedence than the other one.
+// Pre-release versions have a lower precedence than the associated normal version (e.g. 1.0.0-beta < 1.0.0).
+func (v semver) less(other semver) bool {
+	switch {
+	case v.major != other.major:
+		return v.major < other.major
+	case v.minor != other.minor:
+		return v.minor < other.minor
+	case v.patch != other.patch:
+		return v.patch < other.patch
+	case v.prerelease == "" || other.prerelease == "":
+		return v.prerelease != "" && other.prerelease == ""
+	}
+	return v.prerelease < other.prerelease
+}
+
+// SortTags returns the semantic version tags sorted in descending order, ignoring any other tags.
+// If includePrerelease is false, pre-release tags (e.g. 1.0.0-beta) are ignored as well.
+func SortTags(tags []string, includePrerelease bool) []string {
+	type version struct {
+		tag    string
+		semver semver
+	}
+	versions := []version{}
+	for _, tag := range tags {
+		v, ok := parseSemver(tag)
+		if !ok || (v.prerelease != "" && !includePrerelease) {
+			continue
+		}
+		versions = append(versions, version{tag: tag, semver: v})
+	}
+	sort.SliceStable(versions, func(i, j int) bool {
+		return versions[j].semver.less(versions[i].semver)
+	})
+
+	sorted := make([]string, 0, len(versions))
+	for _, v := range versions {
+		sorted = append(sorted, v.tag)
+	}
+	return sorted
+}
+
+// UpdateResource updates the available versions of a module resource with the tags of its repository, using http.DefaultClient.
+// See Client.UpdateResource for details.
+func UpdateResource(resource *types.Resource, includePrerelease bool) error {
+	return (&Client{}).UpdateResource(resource, includePrerelease)
+}
+
+// UpdateResource updates the available versions of a module resource with the tags of its repository.
+// The namespace of the resource is the registry and its name the repository (e.g. mcr.microsoft.com and bicep/avm/res/storage/storage-account).
+// If includePrerelease is true, pre-release tags will be included.
+// If the repository does not exist, the resource is marked as unknown instead of returning an error.
+// A current tag newer than all the available ones (e.g. an excluded pre-release tag) is kept as the latest one, so that it is never downgraded.
+func (c *Client) UpdateResource(resource *types.Resource, includePrerelease bool) error {
+	tags, err := c.ListTags(resource.Namespace, resource.Name)
+	if errors.Is(err, ErrNotFound) {
+		resource.Unknown = true
+		resource.AvailableAPIVersions = nil
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", resource.ID, err)
+	}
+	sorted := SortTags(tags, includePrerelease)
+	if current, ok := parseSemver(resource.CurrentAPIVersion); ok {
+		if len(sorted) == 0 {
+			sorted = []string{resource.CurrentAPIVersion}
+		} else if latest, _ := parseSemver(sorted[0]); latest.less(current) {
+			sorted = append([]string{resource.CurrentAPIVersion}, sorted...)
+		}
+	}
+	resource.AvailableAPIVersions = sorted
+	return nil
+}
@@ -0,0 +1,159 @@
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/christosgalano/bruh/internal/types"
+)
+
+// newRegistry starts a fake registry that requires an anonymous bearer token and paginates the tags of bicep/modules/storage.
+func newRegistry(t *testing.T) string {
+	t.Helper()
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			if r.URL.Query().Get("scope") != "repository:bicep/modules/storage:pull" {
+				http.Error(w, "invalid scope", http.StatusBadRequest)
+				return
+			}
+			fmt.Fprint(w, `{"token": "anonymous"}`)
+			return
+		}
+
+		if r.Header.Get("Authorization") != "Bearer anonymous" {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="test",scope="repository:bicep/modules/storage:pull"`, server.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch {
+		case r.URL.Path == "/v2/bicep/modules/storage/tags/list" && r.URL.Query().Get("last") == "":
+			w.Header().Set("Link", `</v2/bicep/modules/storage/tags/list?last=1.0.0>; rel="next"`)
+			_ = json.NewEncoder(w).Encode(map[string]any{"name": "bicep/modules/storage", "tags": []string{"0.9.0", "1.0.0"}})
+		case r.URL.Path == "/v2/bicep/modules/storage/tags/list":
+			_ = json.NewEncoder(w).Encode(map[string]any{"name": "bicep/modules/storage", "tags": []string{"1.2.0-beta", "1.1.0", "latest"}})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return strings.TrimPrefix(server.URL, "http://")
+}
+
+func TestListTags(t *testing.T) {
+	registry := newRegistry(t)
+	tests := []struct {
+		name       string
+		repository string
+		want       []string
+		wantErr    error
+	}{
+		{
+			name:       "paginated-tags",
+			repository: "bicep/modules/storage",
+			want:       []string{"0.9.0", "1.0.0", "1.2.0-beta", "1.1.0", "latest"},
+		},
+		{
+			name:       "non-existent-repository",
+			repository: "bicep/modules/missing",
+			wantErr:    ErrNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ListTags(registry, tt.repository)
+			if tt.wantErr != nil {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr.Error()) {
+					t.Fatalf("ListTags() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ListTags() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ListTags() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSortTags(t *testing.T) {
+	tags := []string{"0.9.0", "latest", "1.0.0", "v1.10.0", "1.2.0-beta", "1.2.0-alpha", "1.1.0", "1.2"}
+	tests := []struct {
+		name              string
+		includePrerelease bool
+		want              []string
+	}{
+		{
+			name:              "stable",
+			includePrerelease: false,
+			want:              []string{"v1.10.0", "1.1.0", "1.0.0", "0.9.0"},
+		},
+		{
+			name:              "prerelease",
+			includePrerelease: true,
+			want:              []string{"v1.10.0", "1.2.0-beta", "1.2.0-alpha", "1.1.0", "1.0.0", "0.9.0"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SortTags(tags, tt.includePrerelease); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SortTags() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateResource(t *testing.T) {
+	registry := newRegistry(t)
+	tests := []struct {
+		name              string
+		resource          types.Resource
+		includePrerelease bool
+		want              []string
+		wantUnknown       bool
+	}{
+		{
+			name:     "stable",
+			resource: types.Resource{Namespace: registry, Name: "bicep/modules/storage", CurrentAPIVersion: "0.9.0", Module: true},
+			want:     []string{"1.1.0", "1.0.0", "0.9.0"},
+		},
+		{
+			name:              "prerelease",
+			resource:          types.Resource{Namespace: registry, Name: "bicep/modules/storage", CurrentAPIVersion: "0.9.0", Module: true},
+			includePrerelease: true,
+			want:              []string{"1.2.0-beta", "1.1.0", "1.0.0", "0.9.0"},
+		},
+		{
+			name:     "current-prerelease-kept",
+			resource: types.Resource{Namespace: registry, Name: "bicep/modules/storage", CurrentAPIVersion: "1.2.0-beta", Module: true},
+			want:     []string{"1.2.0-beta", "1.1.0", "1.0.0", "0.9.0"},
+		},
+		{
+			name:        "unknown-module",
+			resource:    types.Resource{Namespace: registry, Name: "bicep/modules/missing", CurrentAPIVersion: "1.0.0", Module: true},
+			wantUnknown: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resource := tt.resource
+			if err := UpdateResource(&resource, tt.includePrerelease); err != nil {
+				t.Fatalf("UpdateResource() error = %v", err)
+			}
+			if resource.Unknown != tt.wantUnknown {
+				t.Errorf("UpdateResource() unknown = %v, want %v", resource.Unknown, tt.wantUnknown)
+			}
+			if !reflect.DeepEqual(resource.AvailableAPIVersions, tt.want) {
+				t.Errorf("UpdateResource() = %v, want %v", resource.AvailableAPIVersions, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+/*
+Package scanner provides sessions scanning Bicep files, ARM templates and Terraform files for the API versions of their resources.
+
+A Session holds everything a scan needs: its options, the provider fetching the available versions of resources along with its HTTP client,
+a logger, and the cache of the versions already fetched, so that each resource type (or registry module) is fetched once per session.
+Sessions share no state, so that several independent scans (e.g. with different options) can run in the same process,
+and files are read from disk on every scan, so that a long-lived session (e.g. in watch mode) always sees their latest content.
+*/
+package scanner
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/christosgalano/bruh/internal/apiversions"
+	"github.com/christosgalano/bruh/internal/bicep"
+	"github.com/christosgalano/bruh/internal/types"
+)
+
+// Provider fetches the available versions of a resource, setting its AvailableAPIVersions or marking it as unknown
+// (e.g. apiversions.Client, which fetches them from the Microsoft Learn website and module registries).
+type Provider interface {
+	UpdateResource(resource *types.Resource, includePreview bool) error
+}
+
+// Options are the options of a session:
+//   - IncludePreview: whether preview API versions (and pre-release module tags) are considered
+//   - Filter: the files and directories walked when scanning a directory
+type Options struct {
+	IncludePreview bool
+	Filter         bicep.Filter
+}
+
+// entry is a resource whose available versions are fetched once, closing done when the fetch completes.
+type entry struct {
+	done     chan struct{}
+	resource types.Resource
+	err      error
+}
+
+// Session scans files with its options, fetching the available versions of their resources with its provider and caching them.
+// A session is safe for concurrent use.
+type Session struct {
+	options  Options
+	provider Provider
+	logger   *log.Logger
+
+	mu    sync.Mutex
+	cache map[string]*entry
+}
+
+// New returns a session fetching the available versions from the Microsoft Learn website and module registries
+// with the given HTTP client (http.DefaultClient if nil), and logging to the given logger (nowhere if nil).
+func New(options Options, client *http.Client, logger *log.Logger) *Session {
+	return NewWithProvider(options, &apiversions.Client{HTTPClient: client}, logger)
+}
+
+// NewWithProvider returns a session fetching the available versions with the given provider, and logging to the given logger (nowhere if nil).
+func NewWithProvider(options Options, provider Provider, logger *log.Logger) *Session {
+	if logger == nil {
+		logger = log.New(io.Discard, "", 0)
+	}
+	return &Session{
+		options:  options,
+		provider: provider,
+		logger:   logger,
+		cache:    map[string]*entry{},
+	}
+}
+
+// Options returns the options of the session.
+func (s *Session) Options() Options {
+	return s.options
+}
+
+// key returns the cache key of a resource: its type, or its module reference along with its tag,
+// as the available versions of a module include its current tag when it is newer than the published ones.
+func key(resource *types.Resource) string {
+	if resource.Module {
+		return resource.ID + ":" + resource.CurrentAPIVersion
+	}
+	return resource.ID
+}
+
+// Resolve sets the available versions of a resource, fetching them only if the versions of its type (or registry module)
+// were not fetched yet by the session. Resources with an unresolved API version are left untouched.
+// Failed fetches are not cached, so that they are retried by the next call.
+func (s *Session) Resolve(resource *types.Resource) error {
+	if resource.Unresolved {
+		return nil
+	}
+
+	k := key(resource)
+	s.mu.Lock()
+	e, ok := s.cache[k]
+	if !ok {
+		e = &entry{done: make(chan struct{}), resource: *resource}
+		s.cache[k] = e
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		s.logger.Printf("fetching the available versions of %s", resource.ID)
+		e.err = s.provider.UpdateResource(&e.resource, s.options.IncludePreview)
+		if e.err != nil {
+			s.logger.Printf("failed to fetch the available versions of %s: %s", resource.ID, e.err)
+			s.mu.Lock()
+			delete(s.cache, k)
+			s.mu.Unlock()
+		}
+		close(e.done)
+	}
+	<-e.done
+
+	if e.err != nil {
+		return e.err
+	}
+	resource.AvailableAPIVersions = e.resource.AvailableAPIVersions
+	resource.Unknown = e.resource.Unknown
+	resource.Suggestions = e.resource.Suggestions
+	return nil
+}
+
+// ResolveFile sets the available versions of the resources of a file concurrently, like Resolve does.
+func (s *Session) ResolveFile(bicepFile *types.BicepFile) error {
+	resources := make([]*types.Resource, 0, len(bicepFile.Resources))
+	for i := range bicepFile.Resources {
+		resources = append(resources, &bicepFile.Resources[i])
+	}
+	return s.resolveAll(resources)
+}
+
+// ResolveFiles sets the available versions of the resources of the given files concurrently, like Resolve does.
+func (s *Session) ResolveFiles(bicepFiles []types.BicepFile) error {
+	resources := []*types.Resource{}
+	for i := range bicepFiles {
+		for j := range bicepFiles[i].Resources {
+			resources = append(resources, &bicepFiles[i].Resources[j])
+		}
+	}
+	return s.resolveAll(resources)
+}
+
+// resolveAll resolves the given resources concurrently and returns the first error, if any.
+func (s *Session) resolveAll(resources []*types.Resource) error {
+	var wg sync.WaitGroup
+	errs := make(chan error, 1)
+	for _, resource := range resources {
+		wg.Add(1)
+		go func(resource *types.Resource) {
+			defer wg.Done()
+			if err := s.Resolve(resource); err != nil {
+				select {
+				case errs <- err:
+				default:
+				}
+			}
+		}(resource)
+	}
+	wg.Wait()
+
+	select {
+	case err := <-errs:
+		return err
+	default:
+		return nil
+	}
+}
+
+// Scan parses a file or a directory, walking it with the filter of the session, and sets the available versions of its resources.
+// A file is returned as the only file of its directory.
+func (s *Session) Scan(path string) (*types.BicepDirectory, error) {
+	fs, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var bicepDirectory *types.BicepDirectory
+	if fs.IsDir() {
+		if bicepDirectory, err = bicep.ParseDirectory(path, s.options.Filter); err != nil {
+			return nil, err
+		}
+	} else {
+		bicepFile, err := bicep.ParseFile(path)
+		if err != nil {
+			return nil, err
+		}
+		bicepDirectory = &types.BicepDirectory{Path: filepath.Dir(path), Files: []types.BicepFile{*bicepFile}}
+	}
+
+	if err := s.ResolveFiles(bicepDirectory.Files); err != nil {
+		return nil, err
+	}
+	return bicepDirectory, nil
+}
@@ -0,0 +1,168 @@
+package scanner
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/christosgalano/bruh/internal/types"
+)
+
+// fakeProvider returns fixed versions per resource type, preview ones included only if requested, and counts the calls per type.
+// The types in fail return an error on their first call.
+type fakeProvider struct {
+	mu    sync.Mutex
+	calls map[string]int
+	fail  map[string]bool
+}
+
+func (p *fakeProvider) UpdateResource(resource *types.Resource, includePreview bool) error {
+	p.mu.Lock()
+	p.calls[resource.ID]++
+	fail := p.fail[resource.ID] && p.calls[resource.ID] == 1
+	p.mu.Unlock()
+
+	if fail {
+		return errors.New("temporary failure")
+	}
+	switch resource.ID {
+	case "Microsoft.Web/sites":
+		resource.AvailableAPIVersions = []string{"2023-01-01", "2022-09-01"}
+		if includePreview {
+			resource.AvailableAPIVersions = append([]string{"2024-01-01-preview"}, resource.AvailableAPIVersions...)
+		}
+	default:
+		resource.Unknown = true
+	}
+	return nil
+}
+
+// write writes a file in a directory.
+func write(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSession_Scan(t *testing.T) {
+	dir := t.TempDir()
+	write(t, dir, "app.bicep", "resource app 'Microsoft.Web/sites@2022-09-01' = {\n  name: 'app'\n}\n")
+	write(t, dir, "api.bicep", "resource api 'Microsoft.Web/sites@2022-09-01' = {\n  name: 'api'\n}\n"+
+		"resource fake 'Microsoft.Fake/things@2021-01-01' = {\n  name: 'fake'\n}\n")
+
+	tests := []struct {
+		name           string
+		includePreview bool
+		want           []string
+	}{
+		{name: "ga-versions", includePreview: false, want: []string{"2023-01-01", "2022-09-01"}},
+		{name: "preview-versions", includePreview: true, want: []string{"2024-01-01-preview", "2023-01-01", "2022-09-01"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &fakeProvider{calls: map[string]int{}}
+			session := NewWithProvider(Options{IncludePreview: tt.includePreview}, provider, nil)
+
+			got, err := session.Scan(dir)
+			if err != nil {
+				t.Fatalf("Scan() error = %v", err)
+			}
+			if len(got.Files) != 2 {
+				t.Fatalf("Scan() files = %d, want %d", len(got.Files), 2)
+			}
+			for _, file := range got.Files {
+				for _, resource := range file.Resources {
+					if resource.ID == "Microsoft.Fake/things" {
+						if !resource.Unknown {
+							t.Errorf("Scan() %s unknown = %v, want %v", resource.ID, resource.Unknown, true)
+						}
+						continue
+					}
+					if !reflect.DeepEqual(resource.AvailableAPIVersions, tt.want) {
+						t.Errorf("Scan() %s versions = %v, want %v", resource.ID, resource.AvailableAPIVersions, tt.want)
+					}
+				}
+			}
+
+			// A second scan sees the changed file, without fetching the versions again
+			write(t, dir, "app.bicep", "resource app 'Microsoft.Web/sites@2023-01-01' = {\n  name: 'app'\n}\n")
+			defer write(t, dir, "app.bicep", "resource app 'Microsoft.Web/sites@2022-09-01' = {\n  name: 'app'\n}\n")
+			got, err = session.Scan(filepath.Join(dir, "app.bicep"))
+			if err != nil {
+				t.Fatalf("Scan() error = %v", err)
+			}
+			if version := got.Files[0].Resources[0].CurrentAPIVersion; version != "2023-01-01" {
+				t.Errorf("Scan() current version = %v, want %v", version, "2023-01-01")
+			}
+			if want := map[string]int{"Microsoft.Web/sites": 1, "Microsoft.Fake/things": 1}; !reflect.DeepEqual(provider.calls, want) {
+				t.Errorf("provider calls = %v, want %v", provider.calls, want)
+			}
+		})
+	}
+}
+
+func TestSession_Resolve(t *testing.T) {
+	provider := &fakeProvider{calls: map[string]int{}, fail: map[string]bool{"Microsoft.Web/sites": true}}
+	session := NewWithProvider(Options{}, provider, nil)
+
+	resource := types.Resource{ID: "Microsoft.Web/sites", CurrentAPIVersion: "2022-09-01"}
+	if err := session.Resolve(&resource); err == nil {
+		t.Fatal("Resolve() error = nil, want an error")
+	}
+	if err := session.Resolve(&resource); err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if want := []string{"2023-01-01", "2022-09-01"}; !reflect.DeepEqual(resource.AvailableAPIVersions, want) {
+		t.Errorf("Resolve() versions = %v, want %v", resource.AvailableAPIVersions, want)
+	}
+
+	unresolved := types.Resource{ID: "Microsoft.Web/sites", CurrentAPIVersion: "[variables('apiVersion')]", Unresolved: true}
+	if err := session.Resolve(&unresolved); err != nil || unresolved.AvailableAPIVersions != nil {
+		t.Errorf("Resolve() unresolved = %v, %v, want no versions", unresolved.AvailableAPIVersions, err)
+	}
+	if calls := provider.calls["Microsoft.Web/sites"]; calls != 2 {
+		t.Errorf("provider calls = %d, want %d", calls, 2)
+	}
+}
+
+// redirect is a transport sending every request to a test server.
+type redirect struct {
+	server *httptest.Server
+}
+
+func (r redirect) RoundTrip(req *http.Request) (*http.Response, error) {
+	target, err := url.Parse(r.server.URL)
+	if err != nil {
+		return nil, err
+	}
+	req = req.Clone(req.Context())
+	req.URL.Scheme, req.URL.Host = target.Scheme, target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestNew(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/en-us/azure/templates/microsoft.web/sites" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`<a href="2022-09-01/sites">2022-09-01</a><a href="2023-01-01/sites">2023-01-01</a>`))
+	}))
+	defer server.Close()
+
+	session := New(Options{}, &http.Client{Transport: redirect{server: server}}, nil)
+	resource := types.Resource{ID: "Microsoft.Web/sites", Namespace: "Microsoft.Web", Name: "sites", CurrentAPIVersion: "2022-09-01"}
+	if err := session.Resolve(&resource); err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if want := []string{"2023-01-01", "2022-09-01"}; !reflect.DeepEqual(resource.AvailableAPIVersions, want) {
+		t.Errorf("Resolve() versions = %v, want %v", resource.AvailableAPIVersions, want)
+	}
+}
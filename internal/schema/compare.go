@@ -0,0 +1,205 @@
+package schema
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/christosgalano/bruh/internal/types"
+)
+
+// splitPath splits a property path into the path of its parent object and its name (e.g. properties.subnets[].name -> properties.subnets[], name).
+func splitPath(path string) (string, string) {
+	i := strings.LastIndex(path, ".")
+	if i < 0 {
+		return "", path
+	}
+	return path[:i], path[i+1:]
+}
+
+// objectAt returns the object type at the given path or the root type if the path is empty.
+func objectAt(root *Type, path string) (*Type, bool) {
+	if path == "" {
+		return root, true
+	}
+	p, ok := root.Walk(path)
+	if !ok {
+		return nil, false
+	}
+	return p.Type, true
+}
+
+// findRename returns the name of the property that most likely replaced a removed one.
+// A candidate must only exist in the target object, and its name must match the removed one case-insensitively or contain it (or vice versa).
+// If there is no single candidate, the function returns an empty string.
+func findRename(current, target *Type, name string) string {
+	currentProperties := current.Properties()
+	candidates := []string{}
+	for candidate := range target.Properties() {
+		if _, ok := currentProperties[candidate]; ok {
+			continue
+		}
+		lc, ln := strings.ToLower(candidate), strings.ToLower(name)
+		if lc == ln || strings.Contains(lc, ln) || strings.Contains(ln, lc) {
+			candidates = append(candidates, candidate)
+		}
+	}
+	if len(candidates) != 1 {
+		return ""
+	}
+	return candidates[0]
+}
+
+// isCovered returns true if the path is nested under one of the given paths.
+func isCovered(path string, reported map[string]bool) bool {
+	for parent := range reported {
+		if strings.HasPrefix(path, parent+".") || strings.HasPrefix(path, parent+"[]") {
+			return true
+		}
+	}
+	return false
+}
+
+// Compare returns the changes between the current and target body types that affect a resource body setting the given property paths:
+//   - properties that are set but no longer exist (removed or renamed)
+//   - properties that are set but whose type changed
+//   - properties that are not set but became required
+func Compare(current, target *Type, paths []string) []types.PropertyChange {
+	changes := []types.PropertyChange{}
+	reported := map[string]bool{}
+
+	set := map[string]bool{}
+	for _, path := range paths {
+		set[path] = true
+	}
+
+	// Properties that are set in the body
+	for _, path := range paths {
+		if isCovered(path, reported) {
+			continue
+		}
+		cp, ok := current.Walk(path)
+		if !ok {
+			continue // unknown to the current version as well, nothing to compare
+		}
+
+		tp, ok := target.Walk(path)
+		if !ok {
+			reported[path] = true
+			parent, name := splitPath(path)
+			currentParent, okCurrent := objectAt(current, parent)
+			targetParent, okTarget := objectAt(target, parent)
+			if okCurrent && okTarget {
+				if renamed := findRename(currentParent, targetParent, strings.TrimSuffix(name, "[]")); renamed != "" {
+					changes = append(changes, types.PropertyChange{Path: path, Kind: types.ChangeRenamed, Detail: "now " + renamed})
+					continue
+				}
+			}
+			changes = append(changes, types.PropertyChange{Path: path, Kind: types.ChangeRemoved})
+			continue
+		}
+
+		currentKind, targetKind := cp.Type.Kind(), tp.Type.Kind()
+		if currentKind != targetKind && currentKind != "any" && targetKind != "any" && currentKind != "union" && targetKind != "union" {
+			reported[path] = true
+			changes = append(changes, types.PropertyChange{Path: path, Kind: types.ChangeTypeChanged, Detail: currentKind + " -> " + targetKind})
+		}
+	}
+
+	// Properties that are not set in the body but are required by the target version
+	objects := []string{""}
+	for _, path := range paths {
+		if p, ok := target.Walk(path); ok && p.Type.Kind() == "object" && !reported[path] {
+			objects = append(objects, path)
+		}
+	}
+	for _, object := range objects {
+		targetObject, _ := objectAt(target, object)
+		currentObject, okCurrent := objectAt(current, object)
+
+		names := []string{}
+		for name, p := range targetObject.Properties() {
+			if p.Required && !p.ReadOnly {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			path := name
+			if object != "" {
+				path = object + "." + name
+			}
+			if set[path] {
+				continue
+			}
+			if okCurrent {
+				if cp, ok := currentObject.Property(name); ok && cp.Required {
+					continue
+				}
+			}
+			changes = append(changes, types.PropertyChange{Path: path, Kind: types.ChangeRequired})
+		}
+	}
+
+	return changes
+}
+
+// CheckResource fills the breaking changes of a resource between its current and latest API versions.
+// If changelog is true, all the property changes between the two versions are filled as well.
+// Resources that are up to date, have an unknown type or an unresolved API version, or lack type definitions for either version are left without changes,
+// and so are function calls (e.g. listKeys) and registry modules, which have no resource body.
+func CheckResource(idx *Index, resource *types.Resource, changelog bool) error {
+	resource.BreakingChanges = nil
+	resource.Changelog = nil
+	if resource.Unknown || resource.Unresolved || resource.Function != "" || resource.Module || resource.CurrentAPIVersion == resource.LatestAPIVersion() {
+		return nil
+	}
+
+	current, err := idx.Lookup(resource.ID, resource.CurrentAPIVersion)
+	if errors.Is(err, ErrTypeNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	target, err := idx.Lookup(resource.ID, resource.LatestAPIVersion())
+	if errors.Is(err, ErrTypeNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if changes := Compare(current, target, resource.Properties); len(changes) > 0 {
+		resource.BreakingChanges = changes
+	}
+	if changelog {
+		if changes := Diff(current, target); len(changes) > 0 {
+			resource.Changelog = changes
+		}
+	}
+	return nil
+}
+
+// CheckBicepFile fills the breaking changes of all resources in a given bicep file.
+// If changelog is true, all the property changes are filled as well.
+func CheckBicepFile(idx *Index, bicepFile *types.BicepFile, changelog bool) error {
+	for i := range bicepFile.Resources {
+		if err := CheckResource(idx, &bicepFile.Resources[i], changelog); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CheckBicepDirectory fills the breaking changes of all resources in all bicep files of a given bicep directory.
+// If changelog is true, all the property changes are filled as well.
+func CheckBicepDirectory(idx *Index, bicepDirectory *types.BicepDirectory, changelog bool) error {
+	for i := range bicepDirectory.Files {
+		if err := CheckBicepFile(idx, &bicepDirectory.Files[i], changelog); err != nil {
+			return err
+		}
+	}
+	return nil
+}
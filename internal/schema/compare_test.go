@@ -0,0 +1,116 @@
+package schema
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/christosgalano/bruh/internal/types"
+)
+
+func TestCompare(t *testing.T) {
+	idx, err := Load("testdata/generated")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	current, err := idx.Lookup("Microsoft.Web/sites", "2021-01-01")
+	if err != nil {
+		t.Fatalf("Lookup() error = %v", err)
+	}
+	target, err := idx.Lookup("Microsoft.Web/sites", "2022-03-01")
+	if err != nil {
+		t.Fatalf("Lookup() error = %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		paths []string
+		want  []types.PropertyChange
+	}{
+		{
+			name: "breaking-body",
+			paths: []string{
+				"name", "location", "tags", "tags.environment", "properties", "properties.siteConfig",
+				"properties.siteConfig.alwaysOn", "properties.siteConfig.minTlsVersion", "properties.siteConfig.numberOfWorkers",
+				"properties.legacy", "properties.httpsOnly",
+			},
+			want: []types.PropertyChange{
+				{Path: "properties.siteConfig.numberOfWorkers", Kind: types.ChangeTypeChanged, Detail: "int -> string"},
+				{Path: "properties.legacy", Kind: types.ChangeRenamed, Detail: "now legacyEnabled"},
+				{Path: "properties.kind", Kind: types.ChangeRequired},
+				{Path: "properties.serverFarmId", Kind: types.ChangeRequired},
+			},
+		},
+		{
+			name:  "compatible-body",
+			paths: []string{"name", "location", "properties", "properties.kind", "properties.serverFarmId", "properties.siteConfig", "properties.siteConfig.alwaysOn"},
+			want:  []types.PropertyChange{},
+		},
+		{
+			name:  "no-properties-object",
+			paths: []string{"name", "location"},
+			want:  []types.PropertyChange{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Compare(current, target, tt.paths); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Compare() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckBicepFile(t *testing.T) {
+	idx, err := Load("testdata/generated")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	bicepFile := &types.BicepFile{
+		Path: "compute.bicep",
+		Resources: []types.Resource{
+			{
+				ID:                   "Microsoft.Web/sites",
+				Name:                 "sites",
+				Namespace:            "Microsoft.Web",
+				CurrentAPIVersion:    "2021-01-01",
+				AvailableAPIVersions: []string{"2022-03-01", "2021-01-01"},
+				Properties:           []string{"name", "location", "properties", "properties.legacy"},
+			},
+			{
+				ID:                   "Microsoft.Web/sites",
+				Name:                 "sites",
+				Namespace:            "Microsoft.Web",
+				CurrentAPIVersion:    "2022-03-01",
+				AvailableAPIVersions: []string{"2022-03-01", "2021-01-01"},
+				Properties:           []string{"name", "location", "properties", "properties.legacyEnabled"},
+			},
+			{
+				ID:                   "Microsoft.Web/sites",
+				Name:                 "sites",
+				Namespace:            "Microsoft.Web",
+				CurrentAPIVersion:    "2019-08-01",
+				AvailableAPIVersions: []string{"2022-03-01", "2019-08-01"},
+				Properties:           []string{"name", "location", "properties", "properties.legacy"},
+			},
+		},
+	}
+	if err := CheckBicepFile(idx, bicepFile, false); err != nil {
+		t.Fatalf("CheckBicepFile() error = %v", err)
+	}
+
+	want := [][]types.PropertyChange{
+		{
+			{Path: "properties.legacy", Kind: types.ChangeRenamed, Detail: "now legacyEnabled"},
+			{Path: "properties.kind", Kind: types.ChangeRequired},
+			{Path: "properties.serverFarmId", Kind: types.ChangeRequired},
+		},
+		nil, // up to date
+		nil, // no type definition for the current version
+	}
+	for i, resource := range bicepFile.Resources {
+		if !reflect.DeepEqual(resource.BreakingChanges, want[i]) {
+			t.Errorf("CheckBicepFile() resource %d = %v, want %v", i, resource.BreakingChanges, want[i])
+		}
+	}
+}
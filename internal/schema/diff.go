@@ -0,0 +1,111 @@
+package schema
+
+import (
+	"sort"
+
+	"github.com/christosgalano/bruh/internal/types"
+)
+
+// visitKey identifies a pair of compared types by their types.json file and index.
+type visitKey struct {
+	fromPath, toPath string
+	from, to         int
+}
+
+// differ compares pairs of object types, comparing each pair once however many property paths reach it.
+//   - results: the changes of each compared pair, with paths relative to the pair
+//   - stack: the pairs being compared on the current path, where a pair already on it is a recursive definition
+type differ struct {
+	results map[visitKey][]types.PropertyChange
+	stack   map[visitKey]bool
+}
+
+// Diff returns all the property changes between two body types of the same resource type:
+//   - properties that were added or removed
+//   - properties whose type changed
+//   - properties that became required
+//
+// Properties that are read-only in both versions are ignored, since they cannot be set in a resource body.
+// Types shared by several properties are compared once, and their changes are reported under each of the properties.
+func Diff(from, to *Type) []types.PropertyChange {
+	d := &differ{results: map[visitKey][]types.PropertyChange{}, stack: map[visitKey]bool{}}
+	return d.diffObjects(from, to)
+}
+
+// diffObjects returns the changes between two object types, recursing into nested objects and arrays of objects,
+// with paths relative to the compared types.
+func (d *differ) diffObjects(from, to *Type) []types.PropertyChange {
+	key := visitKey{fromPath: from.path, toPath: to.path, from: from.index, to: to.index}
+	if changes, ok := d.results[key]; ok {
+		return changes
+	}
+	if d.stack[key] {
+		return nil
+	}
+	d.stack[key] = true
+	defer delete(d.stack, key)
+
+	fromProperties, toProperties := from.Properties(), to.Properties()
+	names := []string{}
+	for name := range fromProperties {
+		names = append(names, name)
+	}
+	for name := range toProperties {
+		if _, ok := fromProperties[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	changes := []types.PropertyChange{}
+	nested := func(prefix string, nestedChanges []types.PropertyChange) {
+		for _, change := range nestedChanges {
+			change.Path = prefix + "." + change.Path
+			changes = append(changes, change)
+		}
+	}
+	for _, path := range names {
+		fp, inFrom := fromProperties[path]
+		tp, inTo := toProperties[path]
+
+		switch {
+		case !inFrom:
+			if tp.ReadOnly {
+				continue
+			}
+			detail := tp.Type.Kind()
+			if tp.Required {
+				detail += ", required"
+			}
+			changes = append(changes, types.PropertyChange{Path: path, Kind: types.ChangeAdded, Detail: detail})
+		case !inTo:
+			if fp.ReadOnly {
+				continue
+			}
+			changes = append(changes, types.PropertyChange{Path: path, Kind: types.ChangeRemoved})
+		default:
+			if fp.ReadOnly && tp.ReadOnly {
+				continue
+			}
+			fromKind, toKind := fp.Type.Kind(), tp.Type.Kind()
+			if fromKind != toKind {
+				changes = append(changes, types.PropertyChange{Path: path, Kind: types.ChangeTypeChanged, Detail: fromKind + " -> " + toKind})
+				continue
+			}
+			if tp.Required && !fp.Required && !tp.ReadOnly {
+				changes = append(changes, types.PropertyChange{Path: path, Kind: types.ChangeRequired})
+			}
+			switch fromKind {
+			case "object":
+				nested(path, d.diffObjects(fp.Type, tp.Type))
+			case "array":
+				fromItems, toItems := fp.Type.Items(), tp.Type.Items()
+				if fromItems != nil && toItems != nil && fromItems.Kind() == "object" && toItems.Kind() == "object" {
+					nested(path+"[]", d.diffObjects(fromItems, toItems))
+				}
+			}
+		}
+	}
+	d.results[key] = changes
+	return changes
+}
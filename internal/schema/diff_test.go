@@ -0,0 +1,119 @@
+package schema
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/christosgalano/bruh/internal/types"
+)
+
+// objects returns the types of a file with an object type used by two properties of another one (#/3), and a recursive object type (#/4).
+// The port properties have the type of the given reference.
+func objects(port string) []rawType {
+	return []rawType{
+		{Kind: "StringType"},
+		{Kind: "IntegerType"},
+		{Kind: "ObjectType", Name: "Endpoint", Properties: map[string]rawProperty{"port": {Type: reference{Ref: port}}}},
+		{Kind: "ObjectType", Name: "Body", Properties: map[string]rawProperty{"primary": {Type: reference{Ref: "#/2"}}, "secondary": {Type: reference{Ref: "#/2"}}}},
+		{Kind: "ObjectType", Name: "Node", Properties: map[string]rawProperty{"next": {Type: reference{Ref: "#/4"}}, "port": {Type: reference{Ref: port}}}},
+	}
+}
+
+// dag returns the types of a file with an object type (#/2) whose two properties have the same object type, and so on for the given depth,
+// so that the last object type is reachable through 2^depth property paths. Only the port property of the first object type has the type of the given reference.
+func dag(port string, depth int) []rawType {
+	file := []rawType{{Kind: "StringType"}, {Kind: "IntegerType"}}
+	for i := 0; i < depth; i++ {
+		next := reference{Ref: "#/" + strconv.Itoa(len(file)+1)}
+		properties := map[string]rawProperty{"left": {Type: next}, "right": {Type: next}}
+		if i == 0 {
+			properties["port"] = rawProperty{Type: reference{Ref: port}}
+		}
+		file = append(file, rawType{Kind: "ObjectType", Name: "Level" + strconv.Itoa(i), Properties: properties})
+	}
+	return append(file, rawType{Kind: "ObjectType", Name: "Leaf", Properties: map[string]rawProperty{"name": {Type: reference{Ref: "#/0"}}}})
+}
+
+func TestDiff(t *testing.T) {
+	idx, err := Load("testdata/generated")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	older, err := idx.Lookup("Microsoft.Web/sites", "2021-01-01")
+	if err != nil {
+		t.Fatalf("Lookup() error = %v", err)
+	}
+	newer, err := idx.Lookup("Microsoft.Web/sites", "2022-03-01")
+	if err != nil {
+		t.Fatalf("Lookup() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		from, to *Type
+		want     []types.PropertyChange
+	}{
+		{
+			name: "older-to-newer",
+			from: older,
+			to:   newer,
+			want: []types.PropertyChange{
+				{Path: "properties.kind", Kind: types.ChangeAdded, Detail: "string, required"},
+				{Path: "properties.legacy", Kind: types.ChangeRemoved},
+				{Path: "properties.legacyEnabled", Kind: types.ChangeAdded, Detail: "bool"},
+				{Path: "properties.serverFarmId", Kind: types.ChangeRequired},
+				{Path: "properties.siteConfig.numberOfWorkers", Kind: types.ChangeTypeChanged, Detail: "int -> string"},
+			},
+		},
+		{
+			name: "newer-to-older",
+			from: newer,
+			to:   older,
+			want: []types.PropertyChange{
+				{Path: "properties.kind", Kind: types.ChangeRemoved},
+				{Path: "properties.legacy", Kind: types.ChangeAdded, Detail: "bool"},
+				{Path: "properties.legacyEnabled", Kind: types.ChangeRemoved},
+				{Path: "properties.siteConfig.numberOfWorkers", Kind: types.ChangeTypeChanged, Detail: "string -> int"},
+			},
+		},
+		{
+			name: "same-version",
+			from: newer,
+			to:   newer,
+			want: []types.PropertyChange{},
+		},
+		{
+			name: "shared-type",
+			from: &Type{file: objects("#/1"), index: 3},
+			to:   &Type{file: objects("#/0"), index: 3},
+			want: []types.PropertyChange{
+				{Path: "primary.port", Kind: types.ChangeTypeChanged, Detail: "int -> string"},
+				{Path: "secondary.port", Kind: types.ChangeTypeChanged, Detail: "int -> string"},
+			},
+		},
+		{
+			name: "recursive-type",
+			from: &Type{file: objects("#/1"), index: 4},
+			to:   &Type{file: objects("#/0"), index: 4},
+			want: []types.PropertyChange{
+				{Path: "port", Kind: types.ChangeTypeChanged, Detail: "int -> string"},
+			},
+		},
+		{
+			name: "shared-type-dag",
+			from: &Type{file: dag("#/1", 40), index: 2},
+			to:   &Type{file: dag("#/0", 40), index: 2},
+			want: []types.PropertyChange{
+				{Path: "port", Kind: types.ChangeTypeChanged, Detail: "int -> string"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Diff(tt.from, tt.to); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Diff() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,363 @@
+/*
+Package schema provides functions to inspect the type definitions of Azure resources and detect breaking changes between API versions.
+
+The type definitions are read from a local copy of the "generated" directory of the bicep-types-az repository
+(https://github.com/Azure/bicep-types-az), which contains an index.json file and a types.json file for each provider and API version.
+*/
+package schema
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// flagRequired is the flag of a required object property.
+	flagRequired = 1
+	// flagReadOnly is the flag of a read-only object property.
+	flagReadOnly = 2
+)
+
+var (
+	// ErrTypeNotFound is returned when there is no type definition for a resource type and API version.
+	ErrTypeNotFound = errors.New("type definition not found")
+)
+
+// reference is a reference to a type, either in the same types.json file (#/N) or in another one (path#/N).
+type reference struct {
+	Ref string `json:"$ref"`
+}
+
+// rawProperty is an object property as stored in types.json.
+type rawProperty struct {
+	Type  reference `json:"type"`
+	Flags int       `json:"flags"`
+}
+
+// rawType is a type as stored in types.json. Only the fields used by the package are decoded.
+type rawType struct {
+	Kind                 string                 `json:"$type"`
+	Name                 string                 `json:"name"`
+	Body                 *reference             `json:"body"`
+	ItemType             *reference             `json:"itemType"`
+	Properties           map[string]rawProperty `json:"properties"`
+	AdditionalProperties *reference             `json:"additionalProperties"`
+	BaseProperties       map[string]rawProperty `json:"baseProperties"`
+	Elements             json.RawMessage        `json:"elements"`
+}
+
+// Index provides access to the type definitions of a bicep-types-az "generated" directory.
+// It is safe for concurrent use.
+type Index struct {
+	dir       string
+	resources map[string]string // lowercase "<type>@<version>" -> reference
+
+	mu    sync.Mutex
+	files map[string][]rawType // types.json path -> decoded types
+}
+
+// Load reads the index.json file of the given bicep-types-az "generated" directory.
+func Load(dir string) (*Index, error) {
+	data, err := os.ReadFile(filepath.Join(filepath.Clean(dir), "index.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read type index: %w", err)
+	}
+
+	var index struct {
+		Resources map[string]reference `json:"resources"`
+	}
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("failed to parse type index: %w", err)
+	}
+
+	idx := &Index{
+		dir:       dir,
+		resources: make(map[string]string, len(index.Resources)),
+		files:     map[string][]rawType{},
+	}
+	for key, ref := range index.Resources {
+		idx.resources[strings.ToLower(key)] = ref.Ref
+	}
+	return idx, nil
+}
+
+// Type is a resolved type of a resource body or one of its properties.
+// References to other types.json files are resolved relative to the file of the type, through the index it was looked up in.
+type Type struct {
+	idx   *Index
+	path  string
+	file  []rawType
+	index int
+}
+
+// Property is a property of an object type.
+type Property struct {
+	Name     string
+	Type     *Type
+	Required bool
+	ReadOnly bool
+}
+
+// Lookup returns the body type of the given resource type (e.g. Microsoft.Web/sites) and API version.
+// If there is no type definition for them, the function returns ErrTypeNotFound.
+func (idx *Index) Lookup(resourceType, apiVersion string) (*Type, error) {
+	ref, ok := idx.resources[strings.ToLower(resourceType+"@"+apiVersion)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s@%s", ErrTypeNotFound, resourceType, apiVersion)
+	}
+
+	path, index, err := splitReference(ref)
+	if err != nil {
+		return nil, err
+	}
+	file, err := idx.load(path)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Type{idx: idx, path: path, file: file, index: index}
+	if raw := t.raw(); raw != nil && raw.Kind == "ResourceType" && raw.Body != nil {
+		return t.resolve(*raw.Body), nil
+	}
+	return t, nil
+}
+
+// load reads and caches a types.json file relative to the index directory.
+func (idx *Index) load(path string) ([]rawType, error) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if file, ok := idx.files[path]; ok {
+		return file, nil
+	}
+
+	data, err := os.ReadFile(filepath.Join(filepath.Clean(idx.dir), filepath.FromSlash(path)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read type definitions: %w", err)
+	}
+	var file []rawType
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse type definitions %s: %w", path, err)
+	}
+	idx.files[path] = file
+	return file, nil
+}
+
+// splitReference splits a reference of the form "path#/N" into its path and index.
+func splitReference(ref string) (string, int, error) {
+	path, fragment, found := strings.Cut(ref, "#/")
+	if !found {
+		return "", 0, fmt.Errorf("invalid type reference %q", ref)
+	}
+	index, err := strconv.Atoi(fragment)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid type reference %q", ref)
+	}
+	return path, index, nil
+}
+
+// raw returns the raw definition of the type or nil if the reference is out of range.
+func (t *Type) raw() *rawType {
+	if t == nil || t.index < 0 || t.index >= len(t.file) {
+		return nil
+	}
+	return &t.file[t.index]
+}
+
+// resolve resolves a reference within the same types.json file or, if it has a path, within the types.json file it points to.
+// References that cannot be resolved (e.g. to a missing file) return nil, which is an opaque type of kind any.
+func (t *Type) resolve(ref reference) *Type {
+	file, index, err := splitReference(ref.Ref)
+	if err != nil {
+		return nil
+	}
+	if file == "" {
+		return &Type{idx: t.idx, path: t.path, file: t.file, index: index}
+	}
+	if t.idx == nil {
+		return nil
+	}
+	file = path.Join(path.Dir(t.path), file)
+	types, err := t.idx.load(file)
+	if err != nil {
+		return nil
+	}
+	return &Type{idx: t.idx, path: file, file: types, index: index}
+}
+
+// elements returns the element types of a union or discriminated object type.
+func (t *Type) elements() []*Type {
+	raw := t.raw()
+	if raw == nil || len(raw.Elements) == 0 {
+		return nil
+	}
+
+	refs := []reference{}
+	switch raw.Kind {
+	case "UnionType":
+		if err := json.Unmarshal(raw.Elements, &refs); err != nil {
+			return nil
+		}
+	case "DiscriminatedObjectType":
+		named := map[string]reference{}
+		if err := json.Unmarshal(raw.Elements, &named); err != nil {
+			return nil
+		}
+		for _, ref := range named {
+			refs = append(refs, ref)
+		}
+	}
+
+	elements := make([]*Type, 0, len(refs))
+	for _, ref := range refs {
+		elements = append(elements, t.resolve(ref))
+	}
+	return elements
+}
+
+// Kind returns the kind of the type: string, int, bool, array, object, any, null or union.
+// Unions whose elements are all of the same kind (e.g. enums) are reported as that kind.
+func (t *Type) Kind() string {
+	raw := t.raw()
+	if raw == nil {
+		return "any"
+	}
+
+	switch raw.Kind {
+	case "StringType", "StringLiteralType":
+		return "string"
+	case "IntegerType":
+		return "int"
+	case "BooleanType":
+		return "bool"
+	case "ArrayType":
+		return "array"
+	case "ObjectType", "DiscriminatedObjectType":
+		return "object"
+	case "NullType":
+		return "null"
+	case "UnionType":
+		kind := ""
+		for _, element := range t.elements() {
+			if k := element.Kind(); k != "null" {
+				if kind != "" && kind != k {
+					return "union"
+				}
+				kind = k
+			}
+		}
+		if kind == "" {
+			return "union"
+		}
+		return kind
+	}
+	return "any"
+}
+
+// Properties returns the properties of an object type, including those of all discriminated or union variants.
+func (t *Type) Properties() map[string]Property {
+	properties := map[string]Property{}
+	raw := t.raw()
+	if raw == nil {
+		return properties
+	}
+
+	add := func(props map[string]rawProperty) {
+		for name, p := range props {
+			if _, ok := properties[name]; ok {
+				continue
+			}
+			properties[name] = Property{
+				Name:     name,
+				Type:     t.resolve(p.Type),
+				Required: p.Flags&flagRequired != 0,
+				ReadOnly: p.Flags&flagReadOnly != 0,
+			}
+		}
+	}
+
+	switch raw.Kind {
+	case "ObjectType":
+		add(raw.Properties)
+	case "DiscriminatedObjectType":
+		add(raw.BaseProperties)
+		for _, element := range t.elements() {
+			for name, p := range element.Properties() {
+				if _, ok := properties[name]; !ok {
+					p.Required = false // only required for one of the variants
+					properties[name] = p
+				}
+			}
+		}
+	case "UnionType":
+		for _, element := range t.elements() {
+			for name, p := range element.Properties() {
+				if _, ok := properties[name]; !ok {
+					properties[name] = p
+				}
+			}
+		}
+	}
+	return properties
+}
+
+// Property returns the property of an object type with the given name, matched case-insensitively.
+// If the object allows additional properties, those are returned as well with an empty name.
+func (t *Type) Property(name string) (Property, bool) {
+	properties := t.Properties()
+	if p, ok := properties[name]; ok {
+		return p, true
+	}
+	for key, p := range properties {
+		if strings.EqualFold(key, name) {
+			return p, true
+		}
+	}
+	if raw := t.raw(); raw != nil && raw.AdditionalProperties != nil {
+		return Property{Type: t.resolve(*raw.AdditionalProperties)}, true
+	}
+	return Property{}, false
+}
+
+// Items returns the item type of an array type or nil for other types.
+func (t *Type) Items() *Type {
+	raw := t.raw()
+	if raw == nil || raw.ItemType == nil {
+		return nil
+	}
+	return t.resolve(*raw.ItemType)
+}
+
+// Walk returns the property at the given path (e.g. properties.subnets[].name), starting from the type.
+func (t *Type) Walk(path string) (Property, bool) {
+	current := Property{Type: t}
+	for _, segment := range strings.Split(path, ".") {
+		name := segment
+		arrays := 0
+		for strings.HasSuffix(name, "[]") {
+			name = strings.TrimSuffix(name, "[]")
+			arrays++
+		}
+
+		p, ok := current.Type.Property(name)
+		if !ok {
+			return Property{}, false
+		}
+		for ; arrays > 0; arrays-- {
+			items := p.Type.Items()
+			if items == nil {
+				return Property{}, false
+			}
+			p = Property{Name: p.Name, Type: items}
+		}
+		current = p
+	}
+	return current, true
+}
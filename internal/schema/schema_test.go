@@ -0,0 +1,180 @@
+package schema
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		dir     string
+		wantErr bool
+	}{
+		{
+			name:    "valid-directory",
+			dir:     "testdata/generated",
+			wantErr: false,
+		},
+		{
+			name:    "non-existent-directory",
+			dir:     "testdata/non-existent-dir",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Load(tt.dir); (err != nil) != tt.wantErr {
+				t.Fatalf("Load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestIndex_Lookup(t *testing.T) {
+	idx, err := Load("testdata/generated")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		resourceType string
+		apiVersion   string
+		wantErr      error
+	}{
+		{
+			name:         "existing-version",
+			resourceType: "Microsoft.Web/sites",
+			apiVersion:   "2021-01-01",
+		},
+		{
+			name:         "case-insensitive",
+			resourceType: "microsoft.web/Sites",
+			apiVersion:   "2022-03-01",
+		},
+		{
+			name:         "missing-version",
+			resourceType: "Microsoft.Web/sites",
+			apiVersion:   "2019-08-01",
+			wantErr:      ErrTypeNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := idx.Lookup(tt.resourceType, tt.apiVersion)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Lookup() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && got.Kind() != "object" {
+				t.Errorf("Lookup() kind = %v, want object", got.Kind())
+			}
+		})
+	}
+}
+
+func TestType_Walk(t *testing.T) {
+	idx, err := Load("testdata/generated")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	body, err := idx.Lookup("Microsoft.Web/sites", "2022-03-01")
+	if err != nil {
+		t.Fatalf("Lookup() error = %v", err)
+	}
+
+	tests := []struct {
+		path         string
+		wantKind     string
+		wantRequired bool
+		wantOk       bool
+	}{
+		{path: "name", wantKind: "string", wantRequired: true, wantOk: true},
+		{path: "properties.siteConfig", wantKind: "object", wantOk: true},
+		{path: "properties.siteConfig.minTlsVersion", wantKind: "string", wantOk: true},
+		{path: "properties.hostNames", wantKind: "array", wantOk: true},
+		{path: "properties.hostNames[]", wantKind: "string", wantOk: true},
+		{path: "properties.kind", wantKind: "string", wantRequired: true, wantOk: true},
+		{path: "tags.environment", wantKind: "string", wantOk: true},
+		{path: "properties.legacy", wantOk: false},
+		{path: "properties.httpsOnly[]", wantOk: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, ok := body.Walk(tt.path)
+			if ok != tt.wantOk {
+				t.Fatalf("Walk() ok = %v, want %v", ok, tt.wantOk)
+			}
+			if !ok {
+				return
+			}
+			if got.Type.Kind() != tt.wantKind {
+				t.Errorf("Walk() kind = %v, want %v", got.Type.Kind(), tt.wantKind)
+			}
+			if got.Required != tt.wantRequired {
+				t.Errorf("Walk() required = %v, want %v", got.Required, tt.wantRequired)
+			}
+		})
+	}
+}
+
+func TestType_crossFileReference(t *testing.T) {
+	// The body references a type of a common types.json file, whose index is a string type in the file of the body
+	dir := t.TempDir()
+	files := map[string]string{
+		"index.json": `{"resources": {"Microsoft.Web/sites@2022-03-01": {"$ref": "web/2022-03-01/types.json#/2"}}}`,
+		"web/2022-03-01/types.json": `[
+			{"$type": "StringType"},
+			{"$type": "ObjectType", "name": "Site", "properties": {
+				"config": {"type": {"$ref": "../../common/types.json#/0"}, "flags": 0},
+				"legacy": {"type": {"$ref": "../../missing/types.json#/0"}, "flags": 0}
+			}},
+			{"$type": "ResourceType", "name": "Microsoft.Web/sites@2022-03-01", "body": {"$ref": "#/1"}}
+		]`,
+		"common/types.json": `[
+			{"$type": "ObjectType", "name": "Config", "properties": {"port": {"type": {"$ref": "#/1"}, "flags": 1}}},
+			{"$type": "IntegerType"}
+		]`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	idx, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	body, err := idx.Lookup("Microsoft.Web/sites", "2022-03-01")
+	if err != nil {
+		t.Fatalf("Lookup() error = %v", err)
+	}
+
+	tests := []struct {
+		path         string
+		wantKind     string
+		wantRequired bool
+	}{
+		{path: "config", wantKind: "object"},
+		{path: "config.port", wantKind: "int", wantRequired: true},
+		{path: "legacy", wantKind: "any"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, ok := body.Walk(tt.path)
+			if !ok {
+				t.Fatalf("Walk() ok = %v, want %v", ok, true)
+			}
+			if got.Type.Kind() != tt.wantKind || got.Required != tt.wantRequired {
+				t.Errorf("Walk() = %v (required %v), want %v (required %v)", got.Type.Kind(), got.Required, tt.wantKind, tt.wantRequired)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// patchContext is the number of unchanged lines around the changes of a hunk.
+	patchContext = 3
+)
+
+// splitLines splits a text into lines, keeping their line endings.
+func splitLines(text string) []string {
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// writeLine writes a line of a hunk with the given prefix, noting a missing line ending.
+func writeLine(b *strings.Builder, prefix, line string) {
+	b.WriteString(prefix)
+	b.WriteString(line)
+	if !strings.HasSuffix(line, "\n") {
+		b.WriteString("\n\\ No newline at end of file\n")
+	}
+}
+
+// hunkRange returns the range of a hunk header (e.g. 3,7), whose start is 0 for an empty range.
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+// unifiedDiff returns the unified diff of two versions of a file, or an empty string if they are equal.
+// Updates only replace versions within lines, so when both versions have the same number of lines, the changed lines are compared one by one
+// and grouped into hunks with their context. Otherwise, the whole file is replaced by a single hunk.
+func unifiedDiff(path, before, after string) string {
+	if before == after {
+		return ""
+	}
+	old, updated := splitLines(before), splitLines(after)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- a/%s\n+++ b/%s\n", path, path)
+
+	if len(old) != len(updated) {
+		fmt.Fprintf(&b, "@@ -%s +%s @@\n", hunkRange(0, len(old)), hunkRange(0, len(updated)))
+		for _, line := range old {
+			writeLine(&b, "-", line)
+		}
+		for _, line := range updated {
+			writeLine(&b, "+", line)
+		}
+		return b.String()
+	}
+
+	changed := []int{}
+	for i := range old {
+		if old[i] != updated[i] {
+			changed = append(changed, i)
+		}
+	}
+
+	for first := 0; first < len(changed); {
+		// Group the changes whose contexts overlap
+		last := first
+		for last+1 < len(changed) && changed[last+1]-changed[last] <= 2*patchContext {
+			last++
+		}
+		start := changed[first] - patchContext
+		if start < 0 {
+			start = 0
+		}
+		end := changed[last] + patchContext + 1
+		if end > len(old) {
+			end = len(old)
+		}
+
+		fmt.Fprintf(&b, "@@ -%s +%s @@\n", hunkRange(start, end-start), hunkRange(start, end-start))
+		for i := start; i < end; {
+			if old[i] == updated[i] {
+				writeLine(&b, " ", old[i])
+				i++
+				continue
+			}
+			j := i
+			for j < end && old[j] != updated[j] {
+				j++
+			}
+			for k := i; k < j; k++ {
+				writeLine(&b, "-", old[k])
+			}
+			for k := i; k < j; k++ {
+				writeLine(&b, "+", updated[k])
+			}
+			i = j
+		}
+		first = last + 1
+	}
+	return b.String()
+}
@@ -0,0 +1,50 @@
+package server
+
+import (
+	"github.com/christosgalano/bruh/internal/types"
+)
+
+// finding is the JSON representation of a resource of a scanned file.
+type finding struct {
+	File           string   `json:"file"`
+	Line           int      `json:"line"`
+	Type           string   `json:"type"`
+	Function       string   `json:"function,omitempty"`
+	Module         bool     `json:"module,omitempty"`
+	CurrentVersion string   `json:"currentVersion"`
+	LatestVersion  string   `json:"latestVersion,omitempty"`
+	Status         string   `json:"status"`
+	VersionsBehind int      `json:"versionsBehind"`
+	Suggestions    []string `json:"suggestions,omitempty"`
+}
+
+// report is the JSON response of a scan: the findings of the scanned files, in order, and the number of findings per status.
+type report struct {
+	Files    int            `json:"files"`
+	Summary  map[string]int `json:"summary"`
+	Findings []finding      `json:"findings"`
+}
+
+// newReport returns the report of the scanned files of an upload.
+func newReport(u *upload, bicepDirectory *types.BicepDirectory) report {
+	r := report{Files: len(bicepDirectory.Files), Summary: map[string]int{}, Findings: []finding{}}
+	for _, bicepFile := range bicepDirectory.Files {
+		for _, resource := range bicepFile.Resources {
+			status := resource.Status().String()
+			r.Summary[status]++
+			r.Findings = append(r.Findings, finding{
+				File:           relative(u, bicepFile.Path),
+				Line:           resource.Line,
+				Type:           resource.ID,
+				Function:       resource.Function,
+				Module:         resource.Module,
+				CurrentVersion: resource.CurrentAPIVersion,
+				LatestVersion:  resource.LatestAPIVersion(),
+				Status:         status,
+				VersionsBehind: resource.VersionsBehind(),
+				Suggestions:    resource.Suggestions,
+			})
+		}
+	}
+	return r
+}
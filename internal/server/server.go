@@ -0,0 +1,424 @@
+/*
+Package server provides an HTTP server exposing the scan and the update of Bicep files, ARM templates and Terraform files as an API.
+
+Clients post a single file (named by the filename query parameter) or a tarball (optionally gzipped) of a project:
+  - POST /v1/scan returns the findings of its resources as JSON
+  - POST /v1/update returns the updated file, or a unified diff patch of the updated files of a tarball (or with format=patch)
+  - GET /healthz reports that the server is running, and GET /readyz that it accepts requests (503 once it is shutting down)
+
+Uploads are extracted into a temporary directory, which is removed once the request completes. Requests are limited in body size,
+number of extracted files and duration, and the scans and updates running at the same time are limited as well, answering 503
+to the requests in excess. The available versions of resources are cached by a scanner.Session per value of include-preview,
+which is renewed once its time to live expires.
+*/
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/christosgalano/bruh/internal/bicep"
+	"github.com/christosgalano/bruh/internal/config"
+	"github.com/christosgalano/bruh/internal/scanner"
+	"github.com/christosgalano/bruh/internal/types"
+)
+
+// Limits are the limits of the requests of a server, where zero means no limit:
+//   - MaxBodySize: the size of a request body in bytes
+//   - MaxFiles: the number of supported files in a tarball
+//   - MaxExtractedSize: the total size of the extracted files of a tarball in bytes
+//   - MaxConcurrent: the number of scans and updates running at the same time
+//   - Timeout: the duration of a scan or an update
+type Limits struct {
+	MaxBodySize      int64
+	MaxFiles         int
+	MaxExtractedSize int64
+	MaxConcurrent    int
+	Timeout          time.Duration
+}
+
+// DefaultLimits are the default limits of the requests.
+var DefaultLimits = Limits{
+	MaxBodySize:      10 << 20,
+	MaxFiles:         1000,
+	MaxExtractedSize: 50 << 20,
+	MaxConcurrent:    4,
+	Timeout:          2 * time.Minute,
+}
+
+// session is a scanner session along with its creation time.
+type session struct {
+	*scanner.Session
+	created time.Time
+}
+
+// Server serves the scan and the update of uploaded files, fetching the available versions of their resources with its provider.
+type Server struct {
+	provider scanner.Provider
+	limits   Limits
+	cacheTTL time.Duration
+	logger   *log.Logger
+
+	mu       sync.Mutex
+	sessions map[bool]*session
+
+	mux *http.ServeMux
+
+	slots    chan struct{}
+	shutdown atomic.Bool
+}
+
+// New returns a server fetching the available versions with the given provider (e.g. apiversions.Client), caching them for cacheTTL
+// (forever if zero), and logging its requests to the given logger (nowhere if nil).
+func New(provider scanner.Provider, limits Limits, cacheTTL time.Duration, logger *log.Logger) *Server {
+	if logger == nil {
+		logger = log.New(io.Discard, "", 0)
+	}
+	s := &Server{
+		provider: provider,
+		limits:   limits,
+		cacheTTL: cacheTTL,
+		logger:   logger,
+		sessions: map[bool]*session{},
+	}
+	if limits.MaxConcurrent > 0 {
+		s.slots = make(chan struct{}, limits.MaxConcurrent)
+	}
+	s.mux = http.NewServeMux()
+	s.mux.HandleFunc("/healthz", handleHealth)
+	s.mux.HandleFunc("/readyz", s.handleReady)
+	s.mux.Handle("/v1/scan", s.api(scan))
+	s.mux.Handle("/v1/update", s.api(update))
+	return s
+}
+
+// Handle registers an additional handler for the given pattern (e.g. /metrics), which is served without the limits of the API.
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, handler)
+}
+
+// session returns the session of the given value of include-preview, creating a new one if it does not exist or it has expired.
+func (s *Server) session(includePreview bool) *scanner.Session {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	current, ok := s.sessions[includePreview]
+	if !ok || (s.cacheTTL > 0 && time.Since(current.created) > s.cacheTTL) {
+		current = &session{
+			Session: scanner.NewWithProvider(scanner.Options{IncludePreview: includePreview}, s.provider, s.logger),
+			created: time.Now(),
+		}
+		s.sessions[includePreview] = current
+	}
+	return current.Session
+}
+
+// Handler returns the handler of the endpoints of the server.
+func (s *Server) Handler() http.Handler {
+	return s.logRequests(s.mux)
+}
+
+// ListenAndServe serves the endpoints of the server on the given address until the context is canceled,
+// then reports that it is not ready anymore and shuts down gracefully, waiting for the requests in progress.
+func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
+	httpServer := &http.Server{
+		Addr:              addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- httpServer.ListenAndServe()
+	}()
+	s.logger.Printf("listening on %s", addr)
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	s.shutdown.Store(true)
+	s.logger.Printf("shutting down")
+	timeout := s.limits.Timeout
+	if timeout == 0 {
+		timeout = DefaultLimits.Timeout
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errs; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// statusRecorder records the status code written by a handler.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// logRequests logs the method, path, status code and duration of each request.
+func (s *Server) logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+		s.logger.Printf("%s %s %d %s", r.Method, r.URL.Path, recorder.status, time.Since(start).Round(time.Millisecond))
+	})
+}
+
+// writeJSON writes a value as the JSON body of a response.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(v)
+}
+
+// writeError writes an error as the JSON body of a response.
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
+
+// handleHealth handles GET /healthz, reporting that the server is running.
+func handleHealth(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// handleReady handles GET /readyz, reporting whether the server accepts requests.
+func (s *Server) handleReady(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if s.shutdown.Load() {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "shutting down"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ready"})
+}
+
+// apiFunc handles a scan or an update of an upload, returning a request error for the invalid requests.
+type apiFunc func(w http.ResponseWriter, r *http.Request, u *upload, session *scanner.Session) error
+
+// api wraps the handler of a scan or an update with the limits of the server: it only accepts POST requests,
+// limits their body size and duration along with the number of requests handled at the same time,
+// and extracts their upload into a temporary directory.
+func (s *Server) api(handle apiFunc) http.Handler {
+	var handler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+
+		if s.slots != nil {
+			select {
+			case s.slots <- struct{}{}:
+				defer func() { <-s.slots }()
+			default:
+				w.Header().Set("Retry-After", "1")
+				writeError(w, http.StatusServiceUnavailable, "too many requests in progress")
+				return
+			}
+		}
+
+		includePreview := false
+		if value := r.URL.Query().Get("include-preview"); value != "" {
+			var err error
+			if includePreview, err = strconv.ParseBool(value); err != nil {
+				writeError(w, http.StatusBadRequest, "invalid include-preview "+strconv.Quote(value))
+				return
+			}
+		}
+
+		if s.limits.MaxBodySize > 0 {
+			r.Body = http.MaxBytesReader(w, r.Body, s.limits.MaxBodySize)
+		}
+		dir, err := os.MkdirTemp("", "bruh-serve-")
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		defer os.RemoveAll(dir)
+
+		u, err := extract(r, dir, s.limits)
+		if err == nil {
+			err = handle(w, r, u, s.session(includePreview))
+		}
+		var reqErr *requestError
+		switch {
+		case err == nil:
+		case errors.As(err, &reqErr):
+			writeError(w, reqErr.status, reqErr.message)
+		default:
+			writeError(w, http.StatusInternalServerError, err.Error())
+		}
+	})
+	if s.limits.Timeout > 0 {
+		handler = http.TimeoutHandler(handler, s.limits.Timeout, `{"error": "request timed out"}`)
+	}
+	return handler
+}
+
+// parse parses the files of an upload, honoring the ignore files of a tarball.
+// The aliases of registry module references are only read from the bicepconfig.json files of the upload, never from the directories of the server,
+// and the references are marked as unresolved, so that the server never contacts the registries named by an upload.
+func parse(u *upload) (*types.BicepDirectory, error) {
+	var bicepDirectory *types.BicepDirectory
+	var err error
+	if u.file != "" {
+		bicepDirectory = &types.BicepDirectory{Path: u.dir}
+		path := filepath.Join(u.dir, u.file)
+		var data []byte
+		var bicepFile *types.BicepFile
+		if data, err = os.ReadFile(path); err == nil {
+			if bicepFile, err = bicep.ParseContent(path, data, u.read); err == nil {
+				bicepDirectory.Files = []types.BicepFile{*bicepFile}
+			}
+		}
+	} else {
+		bicepDirectory, err = bicep.ParseDirectoryFunc(u.dir, bicep.Filter{IgnoreFiles: true}, u.read)
+	}
+	if err != nil {
+		return nil, badRequest("failed to parse upload: %s", err)
+	}
+	for i := range bicepDirectory.Files {
+		for j := range bicepDirectory.Files[i].Resources {
+			if bicepDirectory.Files[i].Resources[j].Module {
+				bicepDirectory.Files[i].Resources[j].Unresolved = true
+			}
+		}
+	}
+	return bicepDirectory, nil
+}
+
+// relative returns the path of a file of an upload relative to its directory, with forward slashes.
+func relative(u *upload, path string) string {
+	rel, err := filepath.Rel(u.dir, path)
+	if err != nil {
+		return path
+	}
+	return filepath.ToSlash(rel)
+}
+
+// scan handles POST /v1/scan, writing the findings of the resources of the upload.
+func scan(w http.ResponseWriter, _ *http.Request, u *upload, session *scanner.Session) error {
+	bicepDirectory, err := parse(u)
+	if err != nil {
+		return err
+	}
+	if err := session.ResolveFiles(bicepDirectory.Files); err != nil {
+		return &requestError{status: http.StatusBadGateway, message: "failed to fetch the available versions: " + err.Error()}
+	}
+	writeJSON(w, http.StatusOK, newReport(u, bicepDirectory))
+	return nil
+}
+
+// update handles POST /v1/update, updating the outdated resources of the upload to their latest versions (capped by the pins
+// of a .bruh.json at the root of a tarball), and writing the updated file, or the patch of the updated files.
+func update(w http.ResponseWriter, r *http.Request, u *upload, session *scanner.Session) error {
+	format := r.URL.Query().Get("format")
+	switch format {
+	case "":
+		format = "file"
+		if u.file == "" {
+			format = "patch"
+		}
+	case "file":
+		if u.file == "" {
+			return badRequest("format file is only supported for single files, use format patch for tarballs")
+		}
+	case "patch":
+	default:
+		return badRequest("invalid format %q: expected file or patch", format)
+	}
+
+	bicepDirectory, err := parse(u)
+	if err != nil {
+		return err
+	}
+	if err := session.ResolveFiles(bicepDirectory.Files); err != nil {
+		return &requestError{status: http.StatusBadGateway, message: "failed to fetch the available versions: " + err.Error()}
+	}
+
+	// Pins are only read from the root of a tarball, as config.Find would walk up the directories of the server
+	projectConfig := &config.Config{}
+	configPath := filepath.Join(u.dir, config.FileName)
+	if _, err := os.Stat(configPath); err == nil && u.file == "" {
+		if projectConfig, err = config.Load(configPath); err != nil {
+			return badRequest("invalid %s: %s", config.FileName, err)
+		}
+	}
+
+	updated := 0
+	patch := ""
+	for i := range bicepDirectory.Files {
+		bicepFile := &bicepDirectory.Files[i]
+		projectConfig.Apply(bicepFile)
+		changes := 0
+		for _, resource := range bicepFile.Resources {
+			status := resource.Status()
+			if !resource.Skipped && (status == types.StatusOutdated || status == types.StatusPromotable) {
+				changes++
+			}
+		}
+		if changes == 0 {
+			continue
+		}
+
+		before, err := os.ReadFile(bicepFile.Path)
+		if err != nil {
+			return err
+		}
+		if err := bicep.UpdateFile(bicepFile, true); err != nil {
+			return err
+		}
+		after, err := os.ReadFile(bicepFile.Path)
+		if err != nil {
+			return err
+		}
+		updated += changes
+		patch += unifiedDiff(relative(u, bicepFile.Path), string(before), string(after))
+	}
+
+	w.Header().Set("X-Bruh-Updated", strconv.Itoa(updated))
+	if format == "patch" {
+		w.Header().Set("Content-Type", "text/x-diff; charset=utf-8")
+		io.WriteString(w, patch)
+		return nil
+	}
+
+	data, err := os.ReadFile(filepath.Join(u.dir, u.file))
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Content-Disposition", "attachment; filename="+strconv.Quote(u.file))
+	w.Write(data)
+	return nil
+}
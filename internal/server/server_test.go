@@ -0,0 +1,570 @@
+package server
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/christosgalano/bruh/internal/apiversions"
+	"github.com/christosgalano/bruh/internal/types"
+)
+
+// fakeProvider returns fixed versions per resource type, marking the other types as unknown.
+// If block is set, it signals started (if set) and waits for block to be closed before returning.
+type fakeProvider struct {
+	started chan struct{}
+	block   chan struct{}
+}
+
+func (p fakeProvider) UpdateResource(resource *types.Resource, _ bool) error {
+	if p.started != nil {
+		select {
+		case p.started <- struct{}{}:
+		default:
+		}
+	}
+	if p.block != nil {
+		<-p.block
+	}
+	switch resource.ID {
+	case "Microsoft.Web/sites":
+		resource.AvailableAPIVersions = []string{"2023-01-01", "2022-09-01", "2021-02-01"}
+	case "Microsoft.Web/serverfarms":
+		resource.AvailableAPIVersions = []string{"2022-09-01", "2021-02-01"}
+	default:
+		resource.Unknown = true
+	}
+	return nil
+}
+
+const (
+	mainBicep = "resource site 'Microsoft.Web/sites@2021-02-01' = {\n  name: 'app'\n}\n\n" +
+		"resource plan 'Microsoft.Web/serverfarms@2022-09-01' = {\n  name: 'plan'\n}\n"
+	moduleBicep = "resource fake 'Microsoft.Fake/things@2021-01-01' = {\n  name: 'fake'\n}\n\n" +
+		"resource site 'Microsoft.Web/sites@2022-09-01' = {\n  name: 'other'\n}\n"
+)
+
+// tarball returns a gzipped tarball of the given entries, where a content starting with "->" is a symbolic link to the rest of it.
+func tarball(t *testing.T, entries [][2]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, entry := range entries {
+		header := &tar.Header{Name: entry[0], Mode: 0o644, Typeflag: tar.TypeReg, Size: int64(len(entry[1]))}
+		if strings.HasPrefix(entry[1], "->") {
+			header = &tar.Header{Name: entry[0], Mode: 0o777, Typeflag: tar.TypeSymlink, Linkname: strings.TrimPrefix(entry[1], "->")}
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if header.Typeflag == tar.TypeReg {
+			if _, err := tw.Write([]byte(entry[1])); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// post sends a request to the handler of a server and returns the response.
+func post(handler http.Handler, target, contentType string, body []byte) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, target, bytes.NewReader(body))
+	if contentType != "" {
+		r.Header.Set("Content-Type", contentType)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
+
+func TestServer_Scan(t *testing.T) {
+	handler := New(fakeProvider{}, DefaultLimits, time.Hour, nil).Handler()
+	project := tarball(t, [][2]string{{"main.bicep", mainBicep}, {"./modules/other.bicep", moduleBicep}, {"README.md", "# project"}})
+
+	tests := []struct {
+		name        string
+		target      string
+		contentType string
+		body        []byte
+		wantFiles   int
+		want        []string
+		wantSummary map[string]int
+	}{
+		{
+			name:        "single-file",
+			target:      "/v1/scan?filename=app.bicep",
+			body:        []byte(mainBicep),
+			wantFiles:   1,
+			want:        []string{"app.bicep:1 Microsoft.Web/sites outdated 2023-01-01 2", "app.bicep:5 Microsoft.Web/serverfarms latest 2022-09-01 0"},
+			wantSummary: map[string]int{"outdated": 1, "latest": 1},
+		},
+		{
+			name:        "single-file-path",
+			target:      "/v1/scan?filename=../../infra/app.bicep",
+			contentType: "text/plain",
+			body:        []byte(mainBicep),
+			wantFiles:   1,
+			want:        []string{"app.bicep:1 Microsoft.Web/sites outdated 2023-01-01 2", "app.bicep:5 Microsoft.Web/serverfarms latest 2022-09-01 0"},
+			wantSummary: map[string]int{"outdated": 1, "latest": 1},
+		},
+		{
+			name:        "tarball",
+			target:      "/v1/scan?include-preview=true",
+			contentType: "application/gzip",
+			body:        project,
+			wantFiles:   2,
+			want: []string{
+				"main.bicep:1 Microsoft.Web/sites outdated 2023-01-01 2",
+				"main.bicep:5 Microsoft.Web/serverfarms latest 2022-09-01 0",
+				"modules/other.bicep:1 Microsoft.Fake/things unknown  0",
+				"modules/other.bicep:5 Microsoft.Web/sites outdated 2023-01-01 1",
+			},
+			wantSummary: map[string]int{"outdated": 2, "latest": 1, "unknown": 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := post(handler, tt.target, tt.contentType, tt.body)
+			if w.Code != http.StatusOK {
+				t.Fatalf("POST %s status = %d, want %d: %s", tt.target, w.Code, http.StatusOK, w.Body)
+			}
+			var got report
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+			findings := []string{}
+			for _, f := range got.Findings {
+				findings = append(findings, f.File+":"+strconv.Itoa(f.Line)+" "+f.Type+" "+f.Status+" "+f.LatestVersion+" "+strconv.Itoa(f.VersionsBehind))
+			}
+			if got.Files != tt.wantFiles || !reflect.DeepEqual(findings, tt.want) || !reflect.DeepEqual(got.Summary, tt.wantSummary) {
+				t.Errorf("POST %s = %d files %v %v, want %d files %v %v", tt.target, got.Files, findings, got.Summary, tt.wantFiles, tt.want, tt.wantSummary)
+			}
+		})
+	}
+}
+
+func TestServer_Update(t *testing.T) {
+	handler := New(fakeProvider{}, DefaultLimits, time.Hour, nil).Handler()
+	pins := `{"pins": [{"resource": "Microsoft.Web/sites", "version": "2022-09-01", "path": "main.bicep"}]}`
+
+	tests := []struct {
+		name        string
+		target      string
+		contentType string
+		body        []byte
+		wantType    string
+		wantUpdated string
+		want        string
+	}{
+		{
+			name:        "single-file",
+			target:      "/v1/update?filename=main.bicep",
+			body:        []byte(mainBicep),
+			wantType:    "text/plain; charset=utf-8",
+			wantUpdated: "1",
+			want:        strings.Replace(mainBicep, "sites@2021-02-01", "sites@2023-01-01", 1),
+		},
+		{
+			name:        "single-file-patch",
+			target:      "/v1/update?filename=main.bicep&format=patch",
+			body:        []byte(mainBicep),
+			wantType:    "text/x-diff; charset=utf-8",
+			wantUpdated: "1",
+			want: "--- a/main.bicep\n+++ b/main.bicep\n@@ -1,4 +1,4 @@\n" +
+				"-resource site 'Microsoft.Web/sites@2021-02-01' = {\n+resource site 'Microsoft.Web/sites@2023-01-01' = {\n   name: 'app'\n }\n \n",
+		},
+		{
+			name:        "tarball-with-pins",
+			target:      "/v1/update",
+			contentType: "application/gzip",
+			body:        tarball(t, [][2]string{{"main.bicep", mainBicep}, {"modules/other.bicep", moduleBicep}, {".bruh.json", pins}}),
+			wantType:    "text/x-diff; charset=utf-8",
+			wantUpdated: "2",
+			want: "--- a/main.bicep\n+++ b/main.bicep\n@@ -1,4 +1,4 @@\n" +
+				"-resource site 'Microsoft.Web/sites@2021-02-01' = {\n+resource site 'Microsoft.Web/sites@2022-09-01' = {\n   name: 'app'\n }\n \n" +
+				"--- a/modules/other.bicep\n+++ b/modules/other.bicep\n@@ -2,6 +2,6 @@\n" +
+				"   name: 'fake'\n }\n \n-resource site 'Microsoft.Web/sites@2022-09-01' = {\n+resource site 'Microsoft.Web/sites@2023-01-01' = {\n   name: 'other'\n }\n",
+		},
+		{
+			name:        "up-to-date",
+			target:      "/v1/update",
+			contentType: "application/x-tar; charset=binary",
+			body:        gunzip(t, tarball(t, [][2]string{{"plan.bicep", "resource plan 'Microsoft.Web/serverfarms@2022-09-01' = {}\n"}})),
+			wantType:    "text/x-diff; charset=utf-8",
+			wantUpdated: "0",
+			want:        "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := post(handler, tt.target, tt.contentType, tt.body)
+			if w.Code != http.StatusOK {
+				t.Fatalf("POST %s status = %d, want %d: %s", tt.target, w.Code, http.StatusOK, w.Body)
+			}
+			if got := w.Header().Get("Content-Type"); got != tt.wantType {
+				t.Errorf("POST %s Content-Type = %q, want %q", tt.target, got, tt.wantType)
+			}
+			if got := w.Header().Get("X-Bruh-Updated"); got != tt.wantUpdated {
+				t.Errorf("POST %s X-Bruh-Updated = %q, want %q", tt.target, got, tt.wantUpdated)
+			}
+			if got := w.Body.String(); got != tt.want {
+				t.Errorf("POST %s = %q, want %q", tt.target, got, tt.want)
+			}
+		})
+	}
+}
+
+// gunzip returns the decompressed content of a gzipped tarball.
+func gunzip(t *testing.T, data []byte) []byte {
+	t.Helper()
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestServer_Errors(t *testing.T) {
+	limits := Limits{MaxBodySize: 4096, MaxFiles: 2, MaxExtractedSize: 1024}
+	handler := New(fakeProvider{}, limits, 0, nil).Handler()
+
+	tests := []struct {
+		name        string
+		method      string
+		target      string
+		contentType string
+		body        []byte
+		want        int
+	}{
+		{name: "method-not-allowed", method: http.MethodGet, target: "/v1/scan", want: http.StatusMethodNotAllowed},
+		{name: "unsupported-file", method: http.MethodPost, target: "/v1/scan?filename=main.yaml", body: []byte("a: b"), want: http.StatusBadRequest},
+		{name: "invalid-include-preview", method: http.MethodPost, target: "/v1/scan?include-preview=maybe", body: []byte(mainBicep), want: http.StatusBadRequest},
+		{name: "invalid-format", method: http.MethodPost, target: "/v1/update?format=zip", body: []byte(mainBicep), want: http.StatusBadRequest},
+		{
+			name:        "file-format-for-tarball",
+			method:      http.MethodPost,
+			target:      "/v1/update?format=file",
+			contentType: "application/gzip",
+			body:        tarball(t, [][2]string{{"main.bicep", mainBicep}}),
+			want:        http.StatusBadRequest,
+		},
+		{name: "body-too-large", method: http.MethodPost, target: "/v1/scan", body: bytes.Repeat([]byte("// padding\n"), 512), want: http.StatusRequestEntityTooLarge},
+		{name: "invalid-gzip", method: http.MethodPost, target: "/v1/scan", contentType: "application/gzip", body: []byte(mainBicep), want: http.StatusBadRequest},
+		{
+			name:        "path-traversal",
+			method:      http.MethodPost,
+			target:      "/v1/scan",
+			contentType: "application/gzip",
+			body:        tarball(t, [][2]string{{"../main.bicep", mainBicep}}),
+			want:        http.StatusBadRequest,
+		},
+		{
+			name:        "absolute-path",
+			method:      http.MethodPost,
+			target:      "/v1/scan",
+			contentType: "application/gzip",
+			body:        tarball(t, [][2]string{{"/etc/main.bicep", mainBicep}}),
+			want:        http.StatusBadRequest,
+		},
+		{
+			name:        "symbolic-link",
+			method:      http.MethodPost,
+			target:      "/v1/scan",
+			contentType: "application/gzip",
+			body:        tarball(t, [][2]string{{"main.bicep", "->/etc/passwd"}}),
+			want:        http.StatusBadRequest,
+		},
+		{
+			name:        "too-many-files",
+			method:      http.MethodPost,
+			target:      "/v1/scan",
+			contentType: "application/gzip",
+			body:        tarball(t, [][2]string{{"a.bicep", "// a"}, {"b.bicep", "// b"}, {"c.bicep", "// c"}}),
+			want:        http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:        "extracted-too-large",
+			method:      http.MethodPost,
+			target:      "/v1/scan",
+			contentType: "application/gzip",
+			body:        tarball(t, [][2]string{{"main.bicep", strings.Repeat("// padding\n", 100)}}),
+			want:        http.StatusRequestEntityTooLarge,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.target, bytes.NewReader(tt.body))
+			if tt.contentType != "" {
+				r.Header.Set("Content-Type", tt.contentType)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			if w.Code != tt.want {
+				t.Errorf("%s %s status = %d, want %d: %s", tt.method, tt.target, w.Code, tt.want, w.Body)
+			}
+			var body map[string]string
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body["error"] == "" {
+				t.Errorf("%s %s body = %s, want an error", tt.method, tt.target, w.Body)
+			}
+		})
+	}
+}
+
+func TestServer_Limits(t *testing.T) {
+	t.Run("max-concurrent", func(t *testing.T) {
+		started, block := make(chan struct{}, 1), make(chan struct{})
+		handler := New(fakeProvider{started: started, block: block}, Limits{MaxConcurrent: 1}, 0, nil).Handler()
+
+		first := make(chan *httptest.ResponseRecorder)
+		go func() {
+			first <- post(handler, "/v1/scan", "", []byte(mainBicep))
+		}()
+
+		// The first request holds the only slot until it is unblocked
+		<-started
+		if w := post(handler, "/v1/scan", "", []byte(mainBicep)); w.Code != http.StatusServiceUnavailable {
+			t.Errorf("POST /v1/scan status = %d, want %d while another request is in progress", w.Code, http.StatusServiceUnavailable)
+		}
+		close(block)
+		if w := <-first; w.Code != http.StatusOK {
+			t.Errorf("POST /v1/scan status = %d, want %d", w.Code, http.StatusOK)
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		block := make(chan struct{})
+		defer close(block)
+		handler := New(fakeProvider{block: block}, Limits{Timeout: 50 * time.Millisecond}, 0, nil).Handler()
+		if w := post(handler, "/v1/scan", "", []byte(mainBicep)); w.Code != http.StatusServiceUnavailable {
+			t.Errorf("POST /v1/scan status = %d, want %d", w.Code, http.StatusServiceUnavailable)
+		}
+	})
+}
+
+func TestServer_Probes(t *testing.T) {
+	s := New(fakeProvider{}, DefaultLimits, 0, nil)
+	handler := s.Handler()
+	get := func(target string) int {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		return w.Code
+	}
+
+	if got := get("/healthz"); got != http.StatusOK {
+		t.Errorf("GET /healthz status = %d, want %d", got, http.StatusOK)
+	}
+	if got := get("/readyz"); got != http.StatusOK {
+		t.Errorf("GET /readyz status = %d, want %d", got, http.StatusOK)
+	}
+	s.Handle("/metrics", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { io.WriteString(w, "bruh_files 1\n") }))
+	if got := get("/metrics"); got != http.StatusOK {
+		t.Errorf("GET /metrics status = %d, want %d once registered", got, http.StatusOK)
+	}
+
+	s.shutdown.Store(true)
+	if got := get("/readyz"); got != http.StatusServiceUnavailable {
+		t.Errorf("GET /readyz status = %d, want %d while shutting down", got, http.StatusServiceUnavailable)
+	}
+	if got := get("/healthz"); got != http.StatusOK {
+		t.Errorf("GET /healthz status = %d, want %d while shutting down", got, http.StatusOK)
+	}
+}
+
+func TestServer_ListenAndServe(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 1)
+	go func() {
+		errs <- New(fakeProvider{}, DefaultLimits, 0, nil).ListenAndServe(ctx, "127.0.0.1:0")
+	}()
+	cancel()
+	select {
+	case err := <-errs:
+		if err != nil {
+			t.Errorf("ListenAndServe() error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("ListenAndServe() did not return after the context was canceled")
+	}
+}
+
+func TestServer_RegistryModules(t *testing.T) {
+	var hits atomic.Int32
+	registry := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		hits.Add(1)
+		_ = json.NewEncoder(w).Encode(map[string]any{"name": "bicep/modules/storage", "tags": []string{"1.0.0", "2.0.0"}})
+	}))
+	defer registry.Close()
+	host := strings.TrimPrefix(registry.URL, "http://")
+	moduleRef := "module storage 'br:" + host + "/bicep/modules/storage:1.0.0' = {\n  name: 'storage'\n}\n"
+	aliasRef := "module storage 'br/internal:storage:1.0.0' = {\n  name: 'storage'\n}\n"
+	bicepConfig := `{"moduleAliases": {"br": {"internal": {"registry": "` + host + `", "modulePath": "bicep/modules"}}}}`
+	handler := New(&apiversions.Client{HTTPClient: registry.Client()}, DefaultLimits, time.Hour, nil).Handler()
+
+	tests := []struct {
+		name        string
+		target      string
+		contentType string
+		body        []byte
+	}{
+		{
+			name:   "scan-single-file",
+			target: "/v1/scan",
+			body:   []byte(moduleRef),
+		},
+		{
+			name:        "scan-tarball-alias",
+			target:      "/v1/scan",
+			contentType: "application/gzip",
+			body:        tarball(t, [][2]string{{"main.bicep", aliasRef}, {"bicepconfig.json", bicepConfig}}),
+		},
+		{
+			name:   "update-single-file",
+			target: "/v1/update",
+			body:   []byte(moduleRef),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := post(handler, tt.target, tt.contentType, tt.body)
+			if w.Code != http.StatusOK {
+				t.Fatalf("POST %s status = %d, want %d: %s", tt.target, w.Code, http.StatusOK, w.Body)
+			}
+			if n := hits.Load(); n != 0 {
+				t.Fatalf("POST %s sent %d requests to the registry of the upload, want none", tt.target, n)
+			}
+			if tt.target != "/v1/scan" {
+				if w.Body.String() != string(tt.body) {
+					t.Errorf("POST %s = %q, want %q", tt.target, w.Body, tt.body)
+				}
+				return
+			}
+			var got report
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+			if len(got.Findings) != 1 || !got.Findings[0].Module || got.Findings[0].Status != "unresolved" {
+				t.Errorf("POST %s findings = %+v, want an unresolved module", tt.target, got.Findings)
+			}
+		})
+	}
+}
+
+func Test_parse(t *testing.T) {
+	tests := []struct {
+		name      string
+		file      string
+		config    string
+		reference string
+		wantErr   string
+	}{
+		{name: "server-config", reference: "br/server:storage:1.0.0", wantErr: `unknown module alias "server"`},
+		{name: "server-config-single-file", file: "main.bicep", reference: "br/server:storage:1.0.0", wantErr: `unknown module alias "server"`},
+		{
+			name:      "upload-config",
+			config:    `{"moduleAliases": {"br": {"upload": {"registry": "upload.azurecr.io"}}}}`,
+			reference: "br/upload:storage:1.0.0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The bicepconfig.json of the server, above the directory of the upload, must not be read
+			root := t.TempDir()
+			dir := filepath.Join(root, "upload")
+			files := map[string]string{
+				filepath.Join(root, "bicepconfig.json"): `{"moduleAliases": {"br": {"server": {"registry": "server.azurecr.io"}}}}`,
+				filepath.Join(dir, "main.bicep"):        "module storage '" + tt.reference + "' = {\n  name: 'storage'\n}\n",
+			}
+			if tt.config != "" {
+				files[filepath.Join(dir, "bicepconfig.json")] = tt.config
+			}
+			if err := os.Mkdir(dir, 0o755); err != nil {
+				t.Fatal(err)
+			}
+			for path, content := range files {
+				if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			bicepDirectory, err := parse(&upload{dir: dir, file: tt.file})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("parse() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parse() error = %v", err)
+			}
+			if got := bicepDirectory.Files[0].Resources[0].Namespace; got != "upload.azurecr.io" {
+				t.Errorf("parse() registry = %q, want %q", got, "upload.azurecr.io")
+			}
+		})
+	}
+}
+
+func Test_unifiedDiff(t *testing.T) {
+	tests := []struct {
+		name   string
+		before string
+		after  string
+		want   string
+	}{
+		{name: "equal", before: "a\nb\n", after: "a\nb\n", want: ""},
+		{
+			name:   "separate-hunks",
+			before: "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n",
+			after:  "X\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\nY\n",
+			want:   "--- a/f\n+++ b/f\n@@ -1,4 +1,4 @@\n-1\n+X\n 2\n 3\n 4\n@@ -9,4 +9,4 @@\n 9\n 10\n 11\n-12\n+Y\n",
+		},
+		{
+			name:   "merged-hunk",
+			before: "1\n2\n3\n4\n5\n6\n7\n",
+			after:  "1\nX\nY\n4\n5\n6\nZ\n",
+			want:   "--- a/f\n+++ b/f\n@@ -1,7 +1,7 @@\n 1\n-2\n-3\n+X\n+Y\n 4\n 5\n 6\n-7\n+Z\n",
+		},
+		{
+			name:   "no-newline-at-end",
+			before: "a\nb",
+			after:  "a\nc",
+			want:   "--- a/f\n+++ b/f\n@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+c\n\\ No newline at end of file\n",
+		},
+		{
+			name:   "different-line-counts",
+			before: "a\n",
+			after:  "a\nb\n",
+			want:   "--- a/f\n+++ b/f\n@@ -1,1 +1,2 @@\n-a\n+a\n+b\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := unifiedDiff("f", tt.before, tt.after); got != tt.want {
+				t.Errorf("unifiedDiff() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
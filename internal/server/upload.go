@@ -0,0 +1,206 @@
+package server
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// requestError is an error answered with its status code, such as an invalid request (400) or a failed fetch of the available versions (502).
+type requestError struct {
+	status  int
+	message string
+}
+
+func (e *requestError) Error() string {
+	return e.message
+}
+
+// badRequest returns a request error answered with 400 Bad Request.
+func badRequest(format string, args ...any) error {
+	return &requestError{status: http.StatusBadRequest, message: fmt.Sprintf(format, args...)}
+}
+
+// tooLarge returns a request error answered with 413 Request Entity Too Large.
+func tooLarge(format string, args ...any) error {
+	return &requestError{status: http.StatusRequestEntityTooLarge, message: fmt.Sprintf(format, args...)}
+}
+
+// bodyError converts the error of reading a request body, whose size is limited by http.MaxBytesReader.
+func bodyError(err error) error {
+	var maxBytesError *http.MaxBytesError
+	if errors.As(err, &maxBytesError) {
+		return tooLarge("request body exceeds %d bytes", maxBytesError.Limit)
+	}
+	return badRequest("failed to read request body: %s", err)
+}
+
+// supportedFile reports whether a file is scanned by bruh, based on its extension.
+func supportedFile(name string) bool {
+	switch path.Ext(name) {
+	case ".bicep", ".json", ".tf":
+		return true
+	}
+	return false
+}
+
+// archiveType returns the type of archive sent in a request (tar or gzip), or an empty string for a single file.
+func archiveType(r *http.Request) (string, error) {
+	contentType := r.Header.Get("Content-Type")
+	if contentType == "" {
+		return "", nil
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", badRequest("invalid Content-Type %q", contentType)
+	}
+	switch mediaType {
+	case "application/x-tar":
+		return "tar", nil
+	case "application/gzip", "application/x-gzip", "application/x-gtar", "application/x-compressed-tar":
+		return "gzip", nil
+	}
+	return "", nil
+}
+
+// upload is the content of a request extracted into a temporary directory:
+// either a single file, whose name is given by the filename query parameter, or the files of a tarball.
+type upload struct {
+	dir  string
+	file string
+}
+
+// read reads a file of an upload, reporting the files outside its directory (e.g. the bicepconfig.json files of the server) as not existing.
+func (u *upload) read(path string) ([]byte, error) {
+	dir, err := filepath.Abs(u.dir)
+	if err != nil {
+		return nil, err
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	if rel, err := filepath.Rel(dir, abs); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil, fmt.Errorf("%w: %s is outside of the upload", os.ErrNotExist, path)
+	}
+	return os.ReadFile(abs)
+}
+
+// extract writes the content of a request into dir. Tarballs are limited to limits.MaxFiles supported files
+// and limits.MaxExtractedSize bytes, and their entries must be regular files or directories with relative paths inside the archive.
+func extract(r *http.Request, dir string, limits Limits) (*upload, error) {
+	kind, err := archiveType(r)
+	if err != nil {
+		return nil, err
+	}
+	if kind == "" {
+		return extractFile(r, dir)
+	}
+
+	var body io.Reader = r.Body
+	if kind == "gzip" {
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			return nil, bodyError(err)
+		}
+		defer gz.Close()
+		body = gz
+	}
+	if err := extractTar(body, dir, limits); err != nil {
+		return nil, err
+	}
+	return &upload{dir: dir}, nil
+}
+
+// extractFile writes the body of a request into dir, as the file named by the filename query parameter.
+func extractFile(r *http.Request, dir string) (*upload, error) {
+	name := r.URL.Query().Get("filename")
+	if name == "" {
+		name = "main.bicep"
+	}
+	name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+	if !supportedFile(name) {
+		return nil, badRequest("unsupported file %q: expected a .bicep, .json or .tf file, or a tarball", name)
+	}
+
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, bodyError(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), data, 0o600); err != nil {
+		return nil, err
+	}
+	return &upload{dir: dir, file: name}, nil
+}
+
+// extractTar writes the entries of a tarball into dir.
+// Entries other than regular files and directories (e.g. symbolic links) are rejected, and the files that are not scanned are skipped,
+// except for the ignore files.
+func extractTar(body io.Reader, dir string, limits Limits) error {
+	tr := tar.NewReader(body)
+	files := 0
+	var size int64
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return bodyError(err)
+		}
+
+		name := strings.TrimPrefix(path.Clean(strings.ReplaceAll(header.Name, "\\", "/")), "./")
+		if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return badRequest("invalid path %q in tarball", header.Name)
+		}
+		target := filepath.Join(dir, filepath.FromSlash(name))
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0o700); err != nil {
+				return err
+			}
+			continue
+		case tar.TypeReg:
+		default:
+			return badRequest("unsupported entry %q in tarball: only regular files and directories are allowed", header.Name)
+		}
+
+		base := path.Base(name)
+		if !supportedFile(name) && base != ".bruhignore" && base != ".gitignore" {
+			continue
+		}
+		files++
+		if limits.MaxFiles > 0 && files > limits.MaxFiles {
+			return tooLarge("tarball exceeds %d files", limits.MaxFiles)
+		}
+		size += header.Size
+		if limits.MaxExtractedSize > 0 && size > limits.MaxExtractedSize {
+			return tooLarge("tarball exceeds %d extracted bytes", limits.MaxExtractedSize)
+		}
+
+		if err := os.MkdirAll(filepath.Dir(target), 0o700); err != nil {
+			return err
+		}
+		f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(f, io.LimitReader(tr, header.Size))
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return bodyError(err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,327 @@
+package terraform
+
+import (
+	"strings"
+)
+
+// hclScanner is a minimal scanner of HCL, used to find azapi blocks and walk the objects of their body.
+type hclScanner struct {
+	src string
+	pos int
+}
+
+// eof returns true if the scanner has reached the end of the source.
+func (s *hclScanner) eof() bool {
+	return s.pos >= len(s.src)
+}
+
+// peek returns the current byte or 0 at the end of the source.
+func (s *hclScanner) peek() byte {
+	if s.eof() {
+		return 0
+	}
+	return s.src[s.pos]
+}
+
+// consume advances past the given token if the source continues with it.
+func (s *hclScanner) consume(token string) bool {
+	if strings.HasPrefix(s.src[s.pos:], token) {
+		s.pos += len(token)
+		return true
+	}
+	return false
+}
+
+// skipSpace skips whitespace and comments. If separators is true, newlines and commas are skipped as well.
+func (s *hclScanner) skipSpace(separators bool) {
+	for !s.eof() {
+		switch c := s.peek(); {
+		case c == ' ' || c == '\t' || c == '\r':
+			s.pos++
+		case separators && (c == '\n' || c == ','):
+			s.pos++
+		case c == '#' || strings.HasPrefix(s.src[s.pos:], "//"):
+			end := strings.IndexByte(s.src[s.pos:], '\n')
+			if end < 0 {
+				s.pos = len(s.src)
+			} else {
+				s.pos += end
+			}
+		case strings.HasPrefix(s.src[s.pos:], "/*"):
+			end := strings.Index(s.src[s.pos+2:], "*/")
+			if end < 0 {
+				s.pos = len(s.src)
+			} else {
+				s.pos += end + 4
+			}
+		default:
+			return
+		}
+	}
+}
+
+// skipString skips a quoted string, including interpolations (e.g. "${var.name}") and directives (e.g. "%{ if x }").
+func (s *hclScanner) skipString() {
+	s.pos++
+	for !s.eof() {
+		switch {
+		case s.peek() == '\\':
+			s.pos += 2
+		case s.peek() == '"':
+			s.pos++
+			return
+		case strings.HasPrefix(s.src[s.pos:], "${") || strings.HasPrefix(s.src[s.pos:], "%{"):
+			s.pos++
+			s.skipExpression(true)
+		default:
+			s.pos++
+		}
+	}
+}
+
+// skipHeredoc skips a heredoc string (e.g. <<EOT ... EOT or <<-EOT ... EOT), including its closing marker.
+func (s *hclScanner) skipHeredoc() {
+	s.consume("<<")
+	s.consume("-")
+	start := s.pos
+	for !s.eof() && s.peek() != '\n' && s.peek() != '\r' {
+		s.pos++
+	}
+	marker := strings.TrimSpace(s.src[start:s.pos])
+	for !s.eof() {
+		end := strings.IndexByte(s.src[s.pos:], '\n')
+		if end < 0 {
+			s.pos = len(s.src)
+			return
+		}
+		s.pos += end + 1
+		lineEnd := strings.IndexByte(s.src[s.pos:], '\n')
+		if lineEnd < 0 {
+			lineEnd = len(s.src) - s.pos
+		}
+		if strings.TrimSpace(s.src[s.pos:s.pos+lineEnd]) == marker {
+			s.pos += lineEnd
+			return
+		}
+	}
+}
+
+// skipExpression skips an expression until a separator or a closing bracket at depth zero.
+// If single is true, only a single bracketed group is skipped (e.g. "${var.name}" or a nested block).
+func (s *hclScanner) skipExpression(single bool) {
+	depth := 0
+	for !s.eof() {
+		switch c := s.peek(); {
+		case c == '"':
+			s.skipString()
+			continue
+		case strings.HasPrefix(s.src[s.pos:], "<<"):
+			s.skipHeredoc()
+			continue
+		case c == '#' || strings.HasPrefix(s.src[s.pos:], "//") || strings.HasPrefix(s.src[s.pos:], "/*"):
+			s.skipSpace(false)
+			continue
+		case c == '(' || c == '[' || c == '{':
+			depth++
+		case c == ')' || c == ']' || c == '}':
+			if depth == 0 {
+				return
+			}
+			depth--
+			if depth == 0 && single {
+				s.pos++
+				return
+			}
+		case (c == '\n' || c == ',') && depth == 0:
+			return
+		}
+		s.pos++
+	}
+}
+
+// readKey reads an identifier or a quoted string and returns its value.
+func (s *hclScanner) readKey() string {
+	start := s.pos
+	if s.peek() == '"' {
+		s.skipString()
+		if s.pos-start < 2 {
+			return ""
+		}
+		return s.src[start+1 : s.pos-1]
+	}
+	for !s.eof() {
+		c := s.peek()
+		if c == '_' || (c == '-' && s.pos > start) || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9' && s.pos > start) {
+			s.pos++
+			continue
+		}
+		break
+	}
+	return s.src[start:s.pos]
+}
+
+// parseBlock parses the body of an azapi block starting at its opening brace.
+// If the block has no type attribute with a literal resource type, the function returns false.
+func (s *hclScanner) parseBlock() (block, bool) {
+	b := block{}
+	found := false
+	s.pos++
+	for {
+		s.skipSpace(true)
+		if s.eof() {
+			return b, found
+		}
+		if s.peek() == '}' {
+			s.pos++
+			return b, found
+		}
+
+		start := s.pos
+		key := s.readKey()
+		s.skipSpace(false)
+		if key == "" || !s.consume("=") {
+			// Nested blocks (e.g. identity { ... }) and anything else
+			if key == "identity" {
+				b.properties = append(b.properties, key)
+			}
+			s.skipExpression(false)
+			if s.pos == start {
+				s.pos++
+			}
+			continue
+		}
+		s.skipSpace(false)
+
+		switch {
+		case key == "type" && s.peek() == '"':
+			found = s.parseType(&b)
+		case key == "body":
+			b.properties = append(b.properties, s.parseValue("")...)
+		case resourceAttributes[key]:
+			b.properties = append(b.properties, key)
+			s.skipExpression(false)
+		default:
+			s.skipExpression(false)
+		}
+	}
+}
+
+// parseType parses the value of a type attribute (e.g. "Microsoft.App/containerApps@2023-05-01") into the block.
+// If the resource type is not a literal or the API version is neither a literal nor an interpolation, the function returns false.
+func (s *hclScanner) parseType(b *block) bool {
+	start := s.pos
+	s.skipString()
+	if s.pos-start < 2 {
+		return false
+	}
+	value := s.src[start+1 : s.pos-1]
+
+	resourceType, version, found := strings.Cut(value, "@")
+	if !found || strings.Contains(resourceType, "${") || !strings.Contains(resourceType, "/") {
+		return false
+	}
+	unresolved := strings.Contains(version, "${")
+	if !unresolved && !versionRegex.MatchString(version) {
+		return false
+	}
+
+	b.resourceType = resourceType
+	b.version = version
+	b.unresolved = unresolved
+	b.start = start + 1 + len(resourceType) + 1
+	b.end = s.pos - 1
+	return true
+}
+
+// parseObject parses an object starting at the current position and returns the paths of its properties.
+func (s *hclScanner) parseObject(prefix string) []string {
+	paths := []string{}
+	s.pos++
+	for {
+		s.skipSpace(true)
+		if s.eof() {
+			return paths
+		}
+		if s.peek() == '}' {
+			s.pos++
+			return paths
+		}
+
+		start := s.pos
+		key := s.readKey()
+		s.skipSpace(false)
+		if key == "" || !(s.consume("=") || s.consume(":")) {
+			s.skipExpression(false)
+			if s.pos == start {
+				s.pos++
+			}
+			continue
+		}
+		s.skipSpace(false)
+
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+		paths = append(paths, path)
+		paths = append(paths, s.parseValue(path)...)
+	}
+}
+
+// parseArray parses an array starting at the current position and returns the paths of the properties of its object items.
+func (s *hclScanner) parseArray(prefix string) []string {
+	paths := []string{}
+	path := prefix + "[]"
+	s.pos++
+	for {
+		s.skipSpace(true)
+		if s.eof() {
+			return paths
+		}
+		if s.peek() == ']' {
+			s.pos++
+			return paths
+		}
+		start := s.pos
+		paths = append(paths, s.parseValue(path)...)
+		if s.pos == start {
+			s.pos++
+		}
+	}
+}
+
+// parseValue parses an attribute value, unwrapping jsonencode calls, and returns the paths of any nested properties.
+// For the top-level value of a body, the returned paths are deduplicated.
+func (s *hclScanner) parseValue(path string) []string {
+	if s.consume("jsonencode(") {
+		s.skipSpace(true)
+		paths := s.parseValue(path)
+		s.skipSpace(true)
+		s.consume(")")
+		return paths
+	}
+
+	var paths []string
+	switch s.peek() {
+	case '{':
+		paths = s.parseObject(path)
+	case '[':
+		paths = s.parseArray(path)
+	default:
+		s.skipExpression(false)
+		return nil
+	}
+	if path != "" {
+		return paths
+	}
+
+	seen := map[string]bool{}
+	unique := []string{}
+	for _, p := range paths {
+		if !seen[p] {
+			seen[p] = true
+			unique = append(unique, p)
+		}
+	}
+	return unique
+}
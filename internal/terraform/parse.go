@@ -0,0 +1,164 @@
+/*
+Package terraform provides a set of functions to manipulate Terraform files that use the azapi provider.
+
+It offers methods for parsing .tf files to extract the type and API version of each azapi_resource, azapi_update_resource
+and azapi_resource_action block (e.g. type = "Microsoft.App/containerApps@2023-05-01"), along with the properties set in their body.
+The results are returned as types.BicepFile objects, so that they can be processed the same way as Bicep files.
+
+The package also includes UpdateFile, which rewrites the API versions of the type attributes while preserving the rest of the file.
+*/
+package terraform
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/christosgalano/bruh/internal/types"
+)
+
+var (
+	// versionRegex is the regex used to match literal API versions.
+	versionRegex = regexp.MustCompile(`^[0-9]{4}-[0-9]{2}-[0-9]{2}(-preview)?$`)
+
+	// azapiResources are the resource types of the azapi provider whose type attribute contains an API version.
+	azapiResources = map[string]bool{
+		"azapi_resource":        true,
+		"azapi_update_resource": true,
+		"azapi_resource_action": true,
+	}
+
+	// resourceAttributes are the attributes of an azapi block that correspond to properties of the resource body.
+	resourceAttributes = map[string]bool{
+		"name":     true,
+		"location": true,
+		"tags":     true,
+	}
+)
+
+// block is an azapi block along with the offsets of the API version in its type attribute.
+type block struct {
+	resourceType string
+	version      string
+	unresolved   bool
+	start        int
+	end          int
+	properties   []string
+}
+
+// readTerraformFile reads a Terraform file and returns its contents.
+// If the file does not exist, is a directory, or does not have the .tf extension, the function returns an error
+// wrapping types.ErrFileNotExist, types.ErrIsDirectory or types.ErrInvalidExtension respectively.
+func readTerraformFile(filePath string) (string, error) {
+	f, err := os.Stat(filePath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", fmt.Errorf("%w %q", types.ErrFileNotExist, filePath)
+		}
+		return "", err
+	}
+
+	if f.IsDir() {
+		return "", fmt.Errorf("%w %q", types.ErrIsDirectory, filePath)
+	}
+
+	if ext := filepath.Ext(filePath); ext != ".tf" {
+		return "", fmt.Errorf("%w %q", types.ErrInvalidExtension, ext)
+	}
+
+	data, err := os.ReadFile(filepath.Clean(filePath))
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// blocks returns the azapi blocks of a Terraform file with a literal resource type, in order of appearance.
+func blocks(content string) []block {
+	s := &hclScanner{src: content}
+	results := []block{}
+	for {
+		s.skipSpace(true)
+		if s.eof() {
+			return results
+		}
+
+		start := s.pos
+		keyword := s.readKey()
+		if keyword == "" {
+			s.skipExpression(false)
+			if s.pos == start {
+				s.pos++
+			}
+			continue
+		}
+
+		// resource "<type>" "<name>" {
+		s.skipSpace(false)
+		labels := []string{}
+		for s.peek() == '"' {
+			labels = append(labels, s.readKey())
+			s.skipSpace(false)
+		}
+		if keyword != "resource" || len(labels) != 2 || !azapiResources[labels[0]] || s.peek() != '{' {
+			s.skipExpression(false)
+			continue
+		}
+
+		if b, ok := s.parseBlock(); ok {
+			results = append(results, b)
+		}
+	}
+}
+
+// newResource creates a resource from an azapi block.
+func newResource(b block) types.Resource {
+	namespace, name, _ := strings.Cut(b.resourceType, "/")
+	return types.Resource{
+		ID:                b.resourceType,
+		Name:              name,
+		Namespace:         namespace,
+		CurrentAPIVersion: b.version,
+		Properties:        b.properties,
+		Unresolved:        b.unresolved,
+	}
+}
+
+// ParseFile parses a Terraform file and returns a pointer to a BicepFile object with a resource for each azapi block.
+// Blocks whose type is not a literal (e.g. "${var.type}@2023-05-01") are ignored, while those whose API version
+// is not a literal (e.g. "Microsoft.App/containerApps@${var.version}") are marked as unresolved.
+func ParseFile(filePath string) (*types.BicepFile, error) {
+	content, err := readTerraformFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return parseBlocks(filePath, content), nil
+}
+
+// ParseContent parses the content of a Terraform file read from elsewhere than the file system (e.g. a git revision),
+// and returns a pointer to a BicepFile object whose path is the given one.
+// If the path does not have the .tf extension, the function returns an error wrapping types.ErrInvalidExtension.
+func ParseContent(filePath, content string) (*types.BicepFile, error) {
+	if ext := filepath.Ext(filePath); ext != ".tf" {
+		return nil, fmt.Errorf("%w %q", types.ErrInvalidExtension, ext)
+	}
+	return parseBlocks(filePath, content), nil
+}
+
+// parseBlocks returns a BicepFile object with a resource for each azapi block of the content of a Terraform file.
+func parseBlocks(filePath, content string) *types.BicepFile {
+	results := []types.Resource{}
+	for _, b := range blocks(content) {
+		resource := newResource(b)
+		resource.Line = types.LineAt(content, b.start)
+		results = append(results, resource)
+	}
+
+	return &types.BicepFile{
+		Path:      filePath,
+		Resources: results,
+	}
+}
@@ -0,0 +1,94 @@
+package terraform
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/christosgalano/bruh/internal/types"
+)
+
+func TestParseFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		filePath string
+		want     []types.Resource
+		wantErr  bool
+	}{
+		{
+			name:     "azapi-blocks",
+			filePath: "testdata/main.tf",
+			want: []types.Resource{
+				{
+					ID:                "Microsoft.App/managedEnvironments",
+					Name:              "managedEnvironments",
+					Namespace:         "Microsoft.App",
+					CurrentAPIVersion: "2022-03-01",
+					Line:              11,
+					Properties:        []string{"name", "location", "properties", "properties.zoneRedundant"},
+				},
+				{
+					ID:                "Microsoft.App/containerApps",
+					Name:              "containerApps",
+					Namespace:         "Microsoft.App",
+					CurrentAPIVersion: "2023-05-01",
+					Line:              25,
+					Properties: []string{
+						"name", "location", "identity", "properties", "properties.configuration", "properties.configuration.ingress",
+						"properties.configuration.ingress.external", "properties.configuration.ingress.targetPort",
+						"properties.template", "properties.template.containers",
+						"properties.template.containers[].name", "properties.template.containers[].image",
+					},
+				},
+				{
+					ID:                "Microsoft.Web/sites",
+					Name:              "sites",
+					Namespace:         "Microsoft.Web",
+					CurrentAPIVersion: "${var.sites_version}",
+					Line:              60,
+					Unresolved:        true,
+				},
+				{
+					ID:                "Microsoft.Web/sites",
+					Name:              "sites",
+					Namespace:         "Microsoft.Web",
+					CurrentAPIVersion: "2022-03-01",
+					Line:              69,
+				},
+			},
+		},
+		{
+			name:     "no-azapi-blocks",
+			filePath: "testdata/other.tf",
+			want:     []types.Resource{},
+		},
+		{
+			name:     "non-existent-file",
+			filePath: "testdata/non-existent-file.tf",
+			wantErr:  true,
+		},
+		{
+			name:     "directory",
+			filePath: "testdata",
+			wantErr:  true,
+		},
+		{
+			name:     "invalid-extension",
+			filePath: "../bicep/testdata/parse/azure.deploy.bicep",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseFile(tt.filePath)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got.Resources, tt.want) {
+				t.Errorf("\nParseFile() = %v\nwant %v", got.Resources, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+terraform {
+  required_providers {
+    azapi = {
+      source = "Azure/azapi"
+    }
+  }
+}
+
+# Container app environment, with a v1-style body
+resource "azapi_resource" "environment" {
+  type      = "Microsoft.App/managedEnvironments@2022-03-01"
+  name      = "env"
+  location  = var.location
+  parent_id = azurerm_resource_group.rg.id
+
+  body = jsonencode({
+    properties = {
+      zoneRedundant = false
+    }
+  })
+}
+
+// Container app, with a v2-style body
+resource "azapi_resource" "app" {
+  type     = "Microsoft.App/containerApps@2023-05-01"
+  name     = "app"
+  location = var.location
+
+  identity {
+    type = "SystemAssigned"
+  }
+
+  body = {
+    properties = {
+      configuration = {
+        ingress = {
+          external   = true
+          targetPort = 80
+        }
+      }
+      template = {
+        containers = [
+          {
+            name  = "app"
+            image = "${var.registry}/app:latest"
+          },
+        ]
+      }
+    }
+  }
+}
+
+/* Not an azapi resource */
+resource "azurerm_resource_group" "rg" {
+  name     = "rg"
+  location = "westeurope"
+}
+
+resource "azapi_update_resource" "tls" {
+  type        = "Microsoft.Web/sites@${var.sites_version}"
+  resource_id = azurerm_linux_web_app.app.id
+
+  body = <<EOT
+{"properties": {"httpsOnly": true}}
+EOT
+}
+
+resource "azapi_resource_action" "restart" {
+  type        = "Microsoft.Web/sites@2022-03-01"
+  resource_id = azurerm_linux_web_app.app.id
+  action      = "restart"
+}
+
+resource "azapi_resource" "dynamic" {
+  type = "${var.type}@2022-03-01"
+  name = "ignored"
+}
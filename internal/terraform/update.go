@@ -0,0 +1,59 @@
+package terraform
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/christosgalano/bruh/internal/types"
+)
+
+// UpdateFile receives a pointer to a BicepFile object parsed from a Terraform file and updates the API version of each azapi block.
+// Only the API versions of the type attributes are rewritten, so the rest of the file is preserved.
+// Resources with an unknown type, an unresolved API version or excluded from the update are left untouched.
+// inPlace determines whether the function will update the file in place or create a new one with the suffix ".updated" (e.g. main.tf.updated).
+// The new file does not have the .tf extension, as Terraform loads every .tf file of a module and would find each block declared twice.
+func UpdateFile(bicepFile *types.BicepFile, inPlace bool) error {
+	content, err := readTerraformFile(bicepFile.Path)
+	if err != nil {
+		return err
+	}
+
+	found := blocks(content)
+	if len(found) != len(bicepFile.Resources) {
+		return fmt.Errorf("file %q changed since it was parsed", bicepFile.Path)
+	}
+
+	// Replace the versions from the end of the file, so that the offsets of the previous blocks remain valid
+	for i := len(found) - 1; i >= 0; i-- {
+		resource := &bicepFile.Resources[i]
+		if !strings.EqualFold(found[i].resourceType, resource.ID) {
+			return fmt.Errorf("file %q changed since it was parsed", bicepFile.Path)
+		}
+		if resource.Unknown || resource.Unresolved || resource.Skipped {
+			continue
+		}
+		latestAPIVersion := resource.LatestAPIVersion()
+		if latestAPIVersion == "" || resource.CurrentAPIVersion == latestAPIVersion {
+			continue
+		}
+		content = content[:found[i].start] + latestAPIVersion + content[found[i].end:]
+		resource.CurrentAPIVersion = latestAPIVersion
+	}
+
+	// Use the same permissions as the original file
+	f, err := os.Stat(bicepFile.Path)
+	if err != nil {
+		return err
+	}
+
+	// If the file is not updated in place, create a new one with the suffix ".updated"
+	if !inPlace {
+		bicepFile.Path += ".updated"
+	}
+
+	if err := os.WriteFile(bicepFile.Path, []byte(content), f.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to update file %s", err)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package terraform
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUpdateFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		inPlace  bool
+		wantPath string
+	}{
+		{
+			name:     "in-place",
+			inPlace:  true,
+			wantPath: "main.tf",
+		},
+		{
+			name:     "new-file",
+			inPlace:  false,
+			wantPath: "main.tf.updated",
+		},
+	}
+
+	original, err := os.ReadFile("testdata/main.tf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "main.tf")
+			if err := os.WriteFile(path, original, 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			file, err := ParseFile(path)
+			if err != nil {
+				t.Fatalf("ParseFile() error = %v", err)
+			}
+			file.Resources[0].AvailableAPIVersions = []string{"2023-05-01", "2022-03-01"}
+			file.Resources[1].AvailableAPIVersions = []string{"2023-05-01"}
+			file.Resources[2].AvailableAPIVersions = []string{"2022-09-01", "2022-03-01"}
+			file.Resources[3].AvailableAPIVersions = []string{"2022-09-01", "2022-03-01"}
+			file.Resources[3].Skipped = true
+
+			if err := UpdateFile(file, tt.inPlace); err != nil {
+				t.Fatalf("UpdateFile() error = %v", err)
+			}
+			if got := filepath.Base(file.Path); got != tt.wantPath {
+				t.Errorf("UpdateFile() path = %v, want %v", got, tt.wantPath)
+			}
+
+			// Terraform loads every .tf file of a module, so the original file must remain the only one
+			if matches, _ := filepath.Glob(filepath.Join(filepath.Dir(path), "*.tf")); len(matches) != 1 {
+				t.Errorf("UpdateFile() .tf files = %v, want only %s", matches, path)
+			}
+
+			data, err := os.ReadFile(file.Path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := strings.Replace(string(original), "Microsoft.App/managedEnvironments@2022-03-01", "Microsoft.App/managedEnvironments@2023-05-01", 1)
+			if string(data) != want {
+				t.Errorf("UpdateFile() content =\n%s\nwant\n%s", data, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,11 @@
+//go:build darwin || freebsd
+
+package tui
+
+import "syscall"
+
+const (
+	// ioctlGetTermios and ioctlSetTermios are the ioctls getting and setting the state of a terminal.
+	ioctlGetTermios = syscall.TIOCGETA
+	ioctlSetTermios = syscall.TIOCSETA
+)
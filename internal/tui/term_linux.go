@@ -0,0 +1,9 @@
+package tui
+
+import "syscall"
+
+const (
+	// ioctlGetTermios and ioctlSetTermios are the ioctls getting and setting the state of a terminal.
+	ioctlGetTermios = syscall.TCGETS
+	ioctlSetTermios = syscall.TCSETS
+)
@@ -0,0 +1,18 @@
+//go:build !linux && !darwin && !freebsd
+
+package tui
+
+import "errors"
+
+// errUnsupported is returned when the terminal interface runs on a platform whose terminals cannot be switched to raw mode.
+var errUnsupported = errors.New("the terminal interface is not supported on this platform")
+
+// makeRaw returns errUnsupported.
+func makeRaw(fd int) (func(), error) {
+	return nil, errUnsupported
+}
+
+// size returns errUnsupported.
+func size(fd int) (int, int, error) {
+	return 0, 0, errUnsupported
+}
@@ -0,0 +1,54 @@
+//go:build linux || darwin || freebsd
+
+package tui
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// winsize is the size of a terminal, as returned by the TIOCGWINSZ ioctl.
+type winsize struct {
+	rows, cols, x, y uint16
+}
+
+// ioctl performs an ioctl on a file descriptor.
+func ioctl(fd int, request uintptr, arg unsafe.Pointer) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), request, uintptr(arg)); errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// makeRaw switches a terminal to raw mode (no echo, no line buffering, no signals) and returns a function restoring its previous state.
+func makeRaw(fd int) (func(), error) {
+	var state syscall.Termios
+	if err := ioctl(fd, ioctlGetTermios, unsafe.Pointer(&state)); err != nil {
+		return nil, err
+	}
+
+	raw := state
+	raw.Iflag &^= syscall.IGNBRK | syscall.BRKINT | syscall.PARMRK | syscall.ISTRIP | syscall.INLCR | syscall.IGNCR | syscall.ICRNL | syscall.IXON
+	raw.Oflag &^= syscall.OPOST
+	raw.Lflag &^= syscall.ECHO | syscall.ECHONL | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+	raw.Cflag &^= syscall.CSIZE | syscall.PARENB
+	raw.Cflag |= syscall.CS8
+	raw.Cc[syscall.VMIN] = 1
+	raw.Cc[syscall.VTIME] = 0
+	if err := ioctl(fd, ioctlSetTermios, unsafe.Pointer(&raw)); err != nil {
+		return nil, err
+	}
+
+	return func() {
+		_ = ioctl(fd, ioctlSetTermios, unsafe.Pointer(&state))
+	}, nil
+}
+
+// size returns the width and height of a terminal.
+func size(fd int) (int, int, error) {
+	var ws winsize
+	if err := ioctl(fd, syscall.TIOCGWINSZ, unsafe.Pointer(&ws)); err != nil {
+		return 0, 0, err
+	}
+	return int(ws.cols), int(ws.rows), nil
+}
@@ -0,0 +1,218 @@
+package tui
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/christosgalano/bruh/internal/types"
+)
+
+// nodeKind represents the kind of a node of the tree.
+type nodeKind int8
+
+const (
+	kindDirectory nodeKind = iota // kindDirectory corresponds to a directory containing files
+	kindFile                      // kindFile corresponds to a file containing resources
+	kindResource                  // kindResource corresponds to a resource of a file
+)
+
+// node is a directory, file or resource of the tree. File and resource nodes refer to the parsed files,
+// so that they reflect the API versions written by an update.
+type node struct {
+	kind     nodeKind
+	name     string
+	file     *types.BicepFile
+	index    int
+	parent   *node
+	children []*node
+	expanded bool
+}
+
+// resource returns the resource of a resource node.
+func (n *node) resource() *types.Resource {
+	return &n.file.Resources[n.index]
+}
+
+// label returns the label of a node: the name of a directory with a trailing slash, the base name of a file, or the label of a resource.
+func (n *node) label() string {
+	switch n.kind {
+	case kindDirectory:
+		return n.name + "/"
+	case kindFile:
+		return filepath.Base(n.file.Path)
+	}
+	return n.resource().Label()
+}
+
+// resources returns the resource nodes of a node, including itself for resource nodes.
+func (n *node) resources() []*node {
+	if n.kind == kindResource {
+		return []*node{n}
+	}
+	result := []*node{}
+	for _, child := range n.children {
+		result = append(result, child.resources()...)
+	}
+	return result
+}
+
+// drift returns the drift of the resources of a node at the given time.
+func (n *node) drift(now time.Time) types.Drift {
+	file := types.BicepFile{}
+	for _, r := range n.resources() {
+		file.Resources = append(file.Resources, *r.resource())
+	}
+	return file.Drift(now)
+}
+
+// buildTree returns the root of the tree of a directory: its subdirectories and files, each file along with its resources.
+// The root and the files are expanded, while the subdirectories are expanded as well so that the whole tree is visible at first.
+func buildTree(bicepDirectory *types.BicepDirectory) *node {
+	root := &node{kind: kindDirectory, name: filepath.Clean(bicepDirectory.Path), expanded: true}
+	directories := map[string]*node{".": root}
+
+	var directory func(rel string) *node
+	directory = func(rel string) *node {
+		if d, ok := directories[rel]; ok {
+			return d
+		}
+		parent := directory(filepath.Dir(rel))
+		d := &node{kind: kindDirectory, name: filepath.Base(rel), parent: parent, expanded: true}
+		parent.children = append(parent.children, d)
+		directories[rel] = d
+		return d
+	}
+
+	for i := range bicepDirectory.Files {
+		file := &bicepDirectory.Files[i]
+		rel, err := filepath.Rel(bicepDirectory.Path, file.Path)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			rel = filepath.Base(file.Path)
+		}
+		parent := directory(filepath.Dir(rel))
+		f := &node{kind: kindFile, file: file, parent: parent, expanded: true}
+		for j := range file.Resources {
+			f.children = append(f.children, &node{kind: kindResource, file: file, index: j, parent: f})
+		}
+		parent.children = append(parent.children, f)
+	}
+	return root
+}
+
+// sortMode represents the order of the nodes of the tree.
+type sortMode int8
+
+const (
+	sortPath  sortMode = iota // sortPath corresponds to directories first and then files, by name, and resources in order of appearance
+	sortDrift                 // sortDrift corresponds to the highest drift score first
+)
+
+// String returns a string representation of a tui.sortMode object.
+func (s sortMode) String() string {
+	if s == sortDrift {
+		return "drift"
+	}
+	return "path"
+}
+
+// filter determines the resources shown in the tree: those of a namespace (all if empty) and a status (all if nil).
+type filter struct {
+	namespace string
+	status    *types.Status
+}
+
+// active returns true if any resources are hidden by the filter.
+func (f filter) active() bool {
+	return f.namespace != "" || f.status != nil
+}
+
+// matches returns true if a resource node is shown by the filter, or, for other nodes, if any of their resources is.
+// Directories and files without resources are shown only when the filter is not active.
+func (f filter) matches(n *node) bool {
+	if n.kind != kindResource {
+		if !f.active() {
+			return true
+		}
+		for _, r := range n.resources() {
+			if f.matches(r) {
+				return true
+			}
+		}
+		return false
+	}
+	resource := n.resource()
+	if f.namespace != "" && !strings.EqualFold(resource.Namespace, f.namespace) {
+		return false
+	}
+	return f.status == nil || resource.Status() == *f.status
+}
+
+// sortedChildren returns the children of a node in the given order.
+func sortedChildren(n *node, mode sortMode, now time.Time) []*node {
+	children := append([]*node{}, n.children...)
+	switch {
+	case mode == sortDrift:
+		scores := map[*node]float64{}
+		for _, child := range children {
+			if child.kind == kindResource {
+				scores[child] = float64(child.resource().GapDays())
+			} else {
+				scores[child] = child.drift(now).Score
+			}
+		}
+		sort.SliceStable(children, func(i, j int) bool {
+			return scores[children[i]] > scores[children[j]]
+		})
+	case n.kind == kindDirectory:
+		sort.SliceStable(children, func(i, j int) bool {
+			if children[i].kind != children[j].kind {
+				return children[i].kind == kindDirectory
+			}
+			return children[i].label() < children[j].label()
+		})
+	}
+	return children
+}
+
+// row is a visible node of the tree along with its depth.
+type row struct {
+	node  *node
+	depth int
+}
+
+// rows returns the visible nodes of the tree in order: the nodes shown by the filter whose ancestors are all expanded.
+func rows(root *node, f filter, mode sortMode, now time.Time) []row {
+	result := []row{}
+	var walk func(n *node, depth int)
+	walk = func(n *node, depth int) {
+		if !f.matches(n) {
+			return
+		}
+		result = append(result, row{node: n, depth: depth})
+		if !n.expanded {
+			return
+		}
+		for _, child := range sortedChildren(n, mode, now) {
+			walk(child, depth+1)
+		}
+	}
+	walk(root, 0)
+	return result
+}
+
+// namespaces returns the namespaces of the resources of the tree, sorted in lexical order.
+func namespaces(root *node) []string {
+	seen := map[string]bool{}
+	result := []string{}
+	for _, r := range root.resources() {
+		namespace := r.resource().Namespace
+		if !seen[namespace] {
+			seen[namespace] = true
+			result = append(result, namespace)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,552 @@
+/*
+Package tui provides a full-screen terminal interface for exploring the drift of the API versions of a directory.
+
+The directories, files and resources are shown as a tree next to a detail pane, which holds the drift of the selected directory or file,
+or the full version history of the selected resource. The tree can be sorted by drift score and filtered by namespace or status,
+while the resources marked for update are applied through an update function (e.g. bicep.UpdateFile).
+
+The terminal is switched to raw mode and the alternate screen on Linux, macOS and FreeBSD, while other platforms are not supported.
+The interface itself (key handling and rendering) does not depend on the terminal, so that it can be tested.
+*/
+package tui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/christosgalano/bruh/internal/types"
+)
+
+const (
+	// enterScreen switches to the alternate screen and hides the cursor, while exitScreen restores both.
+	enterScreen = "\x1b[?1049h\x1b[?25l"
+	exitScreen  = "\x1b[?25h\x1b[?1049l"
+
+	// help is the key reference shown at the bottom of the screen.
+	help = "↑/↓ move  ←/→ collapse/expand  space mark  s sort  n namespace  f status  a apply  q quit"
+)
+
+// statuses are the values the status filter cycles through, after showing all statuses.
+var statuses = []types.Status{types.StatusOutdated, types.StatusPromotable, types.StatusUnknown, types.StatusUnresolved, types.StatusLatest}
+
+// UpdateFunc updates a file with the latest API versions of its resources, leaving the skipped ones untouched (e.g. bicep.UpdateFile).
+type UpdateFunc func(bicepFile *types.BicepFile) error
+
+// App is the state of the terminal interface: the tree, the selected row, the order and filter of the tree, the resources marked for update,
+// and the parsed files along with the resources already applied to them.
+type App struct {
+	root    *node
+	update  UpdateFunc
+	now     time.Time
+	cursor  int
+	offset  int
+	sort    sortMode
+	filter  filter
+	marked  map[*node]bool
+	sources map[*types.BicepFile]types.BicepFile
+	applied map[*types.BicepFile]map[int]bool
+	confirm bool
+	message string
+	quit    bool
+}
+
+// New returns the terminal interface of a directory whose resources have already been updated with the available API versions.
+// The marked resources are applied with the given function, and the ages of the API versions are computed at the given time.
+func New(bicepDirectory *types.BicepDirectory, update UpdateFunc, now time.Time) *App {
+	return &App{
+		root:    buildTree(bicepDirectory),
+		update:  update,
+		now:     now,
+		marked:  map[*node]bool{},
+		sources: sources(bicepDirectory),
+		applied: map[*types.BicepFile]map[int]bool{},
+	}
+}
+
+// rows returns the visible rows of the tree.
+func (a *App) rows() []row {
+	return rows(a.root, a.filter, a.sort, a.now)
+}
+
+// selected returns the node of the selected row.
+func (a *App) selected() *node {
+	visible := a.rows()
+	if len(visible) == 0 {
+		return nil
+	}
+	if a.cursor >= len(visible) {
+		a.cursor = len(visible) - 1
+	}
+	return visible[a.cursor].node
+}
+
+// follow moves the cursor to the given node if it is still visible (e.g. after sorting or filtering), or to the closest row otherwise.
+func (a *App) follow(n *node) {
+	visible := a.rows()
+	for i, r := range visible {
+		if r.node == n {
+			a.cursor = i
+			return
+		}
+	}
+	if a.cursor >= len(visible) {
+		a.cursor = len(visible) - 1
+	}
+	if a.cursor < 0 {
+		a.cursor = 0
+	}
+}
+
+// markable returns true if a resource can be updated, i.e. it has a known type, a resolved API version and a newer version available.
+func markable(resource *types.Resource) bool {
+	latest := resource.LatestAPIVersion()
+	return !resource.Unknown && !resource.Unresolved && latest != "" && resource.CurrentAPIVersion != latest
+}
+
+// toggle marks the updatable resources of a node shown by the filter, or unmarks them if they are all marked already.
+func (a *App) toggle(n *node) {
+	candidates := []*node{}
+	for _, r := range n.resources() {
+		if markable(r.resource()) && a.filter.matches(r) {
+			candidates = append(candidates, r)
+		}
+	}
+	if len(candidates) == 0 {
+		a.message = fmt.Sprintf("%s has no resources to update", n.label())
+		return
+	}
+
+	all := true
+	for _, r := range candidates {
+		all = all && a.marked[r]
+	}
+	for _, r := range candidates {
+		if all {
+			delete(a.marked, r)
+		} else {
+			a.marked[r] = true
+		}
+	}
+}
+
+// cycleNamespace moves the namespace filter to the next namespace of the tree, after showing all namespaces.
+func (a *App) cycleNamespace() {
+	all := namespaces(a.root)
+	next := ""
+	for i, namespace := range all {
+		if a.filter.namespace == "" {
+			next = namespace
+			break
+		}
+		if namespace == a.filter.namespace && i+1 < len(all) {
+			next = all[i+1]
+			break
+		}
+	}
+	a.filter.namespace = next
+}
+
+// cycleStatus moves the status filter to the next status, after showing all statuses.
+func (a *App) cycleStatus() {
+	if a.filter.status == nil {
+		a.filter.status = &statuses[0]
+		return
+	}
+	for i := range statuses {
+		if statuses[i] == *a.filter.status {
+			if i+1 < len(statuses) {
+				a.filter.status = &statuses[i+1]
+			} else {
+				a.filter.status = nil
+			}
+			return
+		}
+	}
+}
+
+// sources returns a copy of each file of a directory as it was parsed, which the updates are applied to.
+func sources(bicepDirectory *types.BicepDirectory) map[*types.BicepFile]types.BicepFile {
+	result := map[*types.BicepFile]types.BicepFile{}
+	for i := range bicepDirectory.Files {
+		source := bicepDirectory.Files[i]
+		source.Resources = append([]types.Resource{}, source.Resources...)
+		result[&bicepDirectory.Files[i]] = source
+	}
+	return result
+}
+
+// apply updates the files of the marked resources, skipping the resources that are not marked, and then clears the marks.
+// Each file is updated through a copy of the file as it was parsed, so that its path in the tree never changes (e.g. to "_updated.bicep")
+// and the update always starts from the original source: the resources applied earlier are therefore applied again along with the marked ones.
+func (a *App) apply() {
+	files := []*types.BicepFile{}
+	indexes := map[*types.BicepFile]map[int]bool{}
+	for _, r := range a.root.resources() {
+		if !a.marked[r] {
+			continue
+		}
+		if indexes[r.file] == nil {
+			indexes[r.file] = map[int]bool{}
+			files = append(files, r.file)
+		}
+		indexes[r.file][r.index] = true
+	}
+	if len(files) == 0 {
+		a.message = "No resources marked for update"
+		return
+	}
+
+	updated := 0
+	for _, file := range files {
+		source := a.sources[file]
+		source.Resources = append([]types.Resource{}, source.Resources...)
+		for i := range source.Resources {
+			source.Resources[i].Skipped = source.Resources[i].Skipped || !(indexes[file][i] || a.applied[file][i])
+		}
+		if err := a.update(&source); err != nil {
+			a.message = fmt.Sprintf("Error: %s", err)
+			return
+		}
+
+		if a.applied[file] == nil {
+			a.applied[file] = map[int]bool{}
+		}
+		for i := range indexes[file] {
+			a.applied[file][i] = true
+		}
+		for i := range a.applied[file] {
+			file.Resources[i].CurrentAPIVersion = source.Resources[i].CurrentAPIVersion
+		}
+		for r := range a.marked {
+			if r.file == file {
+				delete(a.marked, r)
+			}
+		}
+		updated += len(indexes[file])
+	}
+	a.message = fmt.Sprintf("Updated %d resource(s) in %d file(s)", updated, len(files))
+}
+
+// Handle handles a key (e.g. up, space or q). While an update waits for confirmation, any key other than y cancels it.
+func (a *App) Handle(key string) {
+	if a.confirm {
+		a.confirm = false
+		a.message = "Update cancelled"
+		if key == "y" {
+			a.apply()
+		}
+		return
+	}
+	a.message = ""
+
+	n := a.selected()
+	if n == nil {
+		if key == "q" || key == "ctrl+c" {
+			a.quit = true
+		}
+		return
+	}
+
+	switch key {
+	case "q", "ctrl+c":
+		a.quit = true
+	case "up", "k":
+		if a.cursor > 0 {
+			a.cursor--
+		}
+	case "down", "j":
+		if a.cursor < len(a.rows())-1 {
+			a.cursor++
+		}
+	case "home", "g":
+		a.cursor = 0
+	case "end", "G":
+		a.cursor = len(a.rows()) - 1
+	case "left", "h":
+		if n.kind != kindResource && n.expanded {
+			n.expanded = false
+		} else if n.parent != nil {
+			a.follow(n.parent)
+		}
+	case "right", "l", "enter":
+		if n.kind != kindResource && !n.expanded {
+			n.expanded = true
+		} else if n.kind != kindResource && a.cursor < len(a.rows())-1 {
+			a.cursor++
+		}
+	case "space":
+		a.toggle(n)
+	case "s":
+		a.sort = (a.sort + 1) % 2
+		a.follow(n)
+	case "n":
+		a.cycleNamespace()
+		a.follow(n)
+	case "f":
+		a.cycleStatus()
+		a.follow(n)
+	case "a":
+		if len(a.marked) == 0 {
+			a.message = "No resources marked for update"
+			return
+		}
+		a.confirm = true
+		a.message = fmt.Sprintf("Update %d marked resource(s)? (y/n)", len(a.marked))
+	}
+}
+
+// fit truncates or pads a string to the given width in runes.
+func fit(s string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	if n := utf8.RuneCountInString(s); n <= width {
+		return s + strings.Repeat(" ", width-n)
+	}
+	runes := []rune(s)
+	return string(runes[:width-1]) + "…"
+}
+
+// color returns the ANSI color code of the status of a resource, or an empty string for resources using the latest version.
+func color(resource *types.Resource) string {
+	switch resource.Status() {
+	case types.StatusOutdated:
+		return "\x1b[31m"
+	case types.StatusPromotable:
+		return "\x1b[33m"
+	case types.StatusUnknown, types.StatusUnresolved:
+		return "\x1b[35m"
+	}
+	return ""
+}
+
+// rowText returns the text of a row of the tree: directories and files with their number of outdated resources,
+// and resources with their mark, current and latest API versions, and status.
+func (a *App) rowText(r row) string {
+	indent := strings.Repeat("  ", r.depth)
+	n := r.node
+	if n.kind != kindResource {
+		glyph := "▸ "
+		if n.expanded {
+			glyph = "▾ "
+		}
+		drift := n.drift(a.now)
+		return fmt.Sprintf("%s%s%s  %d/%d outdated", indent, glyph, n.label(), drift.Outdated, drift.Resources)
+	}
+
+	resource := n.resource()
+	mark := "[ ] "
+	if a.marked[n] {
+		mark = "[x] "
+	}
+	versions := resource.CurrentAPIVersion
+	if markable(resource) {
+		versions += " → " + resource.LatestAPIVersion()
+	}
+	return fmt.Sprintf("%s%s%s  %s  %s", indent, mark, n.label(), versions, resource.Status())
+}
+
+// age returns a hint with the age of a version of a resource, or an empty string for registry modules, whose tags have no dates.
+func (a *App) age(resource *types.Resource, version string) string {
+	if resource.Module {
+		return ""
+	}
+	return fmt.Sprintf(" (%d days old)", types.Resource{CurrentAPIVersion: version}.AgeDays(a.now))
+}
+
+// details returns the lines of the detail pane of a node: the drift of a directory or file,
+// or the versions and full version history of a resource.
+func (a *App) details(n *node) []string {
+	if n == nil {
+		return []string{"No resources match the filter"}
+	}
+	if n.kind != kindResource {
+		drift := n.drift(a.now)
+		marked := 0
+		for _, r := range n.resources() {
+			if a.marked[r] {
+				marked++
+			}
+		}
+		return []string{
+			n.label(),
+			fmt.Sprintf("  resources: %d", drift.Resources),
+			fmt.Sprintf("  outdated: %d", drift.Outdated),
+			fmt.Sprintf("  versions behind: %d", drift.VersionsBehind),
+			fmt.Sprintf("  oldest version: %d days old", drift.MaxAgeDays),
+			fmt.Sprintf("  max gap: %d days", drift.MaxGapDays),
+			fmt.Sprintf("  drift score: %.1f", drift.Score),
+			fmt.Sprintf("  marked: %d", marked),
+		}
+	}
+
+	resource := n.resource()
+	lines := []string{
+		n.label(),
+		fmt.Sprintf("  file: %s:%d", n.file.Path, resource.Line),
+		fmt.Sprintf("  status: %s", resource.Status()),
+		fmt.Sprintf("  current: %s%s", resource.CurrentAPIVersion, a.age(resource, resource.CurrentAPIVersion)),
+	}
+	if resource.Unknown || resource.Unresolved {
+		return lines
+	}
+	lines = append(lines,
+		fmt.Sprintf("  latest: %s%s", resource.LatestAPIVersion(), a.age(resource, resource.LatestAPIVersion())),
+		fmt.Sprintf("  behind: %d version(s), %d days", resource.VersionsBehind(), resource.GapDays()),
+	)
+	if resource.Pinned {
+		lines = append(lines, "  pinned by the project configuration")
+	}
+	if len(resource.BreakingChanges) > 0 {
+		lines = append(lines, fmt.Sprintf("  breaking changes: %d", len(resource.BreakingChanges)))
+		for _, change := range resource.BreakingChanges {
+			lines = append(lines, "    ! "+change.String())
+		}
+	}
+
+	lines = append(lines, "", "Version history:")
+	for i, version := range resource.AvailableAPIVersions {
+		note := ""
+		switch {
+		case version == resource.CurrentAPIVersion && i == 0:
+			note = "  current, latest"
+		case version == resource.CurrentAPIVersion:
+			note = "  current"
+		case i == 0:
+			note = "  latest"
+		}
+		lines = append(lines, fmt.Sprintf("  %s%s%s", version, a.age(resource, version), note))
+	}
+	return lines
+}
+
+// Render returns the lines of the screen of the given size: a header with the order and filter of the tree,
+// the tree next to the detail pane, a status message and the key reference.
+func (a *App) Render(width, height int) []string {
+	namespace, status := "all", "all"
+	if a.filter.namespace != "" {
+		namespace = a.filter.namespace
+	}
+	if a.filter.status != nil {
+		status = a.filter.status.String()
+	}
+	header := fmt.Sprintf(" bruh  sort: %s  namespace: %s  status: %s  marked: %d", a.sort, namespace, status, len(a.marked))
+
+	bodyHeight := height - 3
+	if bodyHeight < 1 {
+		bodyHeight = 1
+	}
+	treeWidth := width * 3 / 5
+	detailWidth := width - treeWidth - 3
+
+	// Keep the selected row visible
+	visible := a.rows()
+	n := a.selected()
+	if a.cursor < a.offset {
+		a.offset = a.cursor
+	}
+	if a.cursor >= a.offset+bodyHeight {
+		a.offset = a.cursor - bodyHeight + 1
+	}
+
+	details := a.details(n)
+	lines := []string{"\x1b[7m" + fit(header, width) + "\x1b[0m"}
+	for i := 0; i < bodyHeight; i++ {
+		left := fit("", treeWidth)
+		if index := a.offset + i; index < len(visible) {
+			r := visible[index]
+			left = fit(a.rowText(r), treeWidth)
+			switch {
+			case index == a.cursor:
+				left = "\x1b[7m" + left + "\x1b[0m"
+			case r.node.kind == kindResource && color(r.node.resource()) != "":
+				left = color(r.node.resource()) + left + "\x1b[0m"
+			}
+		}
+		right := ""
+		if i < len(details) {
+			right = fit(details[i], detailWidth)
+		}
+		if i == bodyHeight-1 && len(details) > bodyHeight {
+			right = fit(fmt.Sprintf("  … %d more line(s)", len(details)-bodyHeight+1), detailWidth)
+		}
+		lines = append(lines, left+" │ "+right)
+	}
+	lines = append(lines, fit(" "+a.message, width), "\x1b[2m"+fit(" "+help, width)+"\x1b[0m")
+	return lines
+}
+
+// parseKeys returns the keys of the bytes read from the terminal, decoding the escape sequences of the arrow, home and end keys.
+func parseKeys(data []byte) []string {
+	sequences := map[string]string{
+		"\x1b[A": "up", "\x1b[B": "down", "\x1b[C": "right", "\x1b[D": "left",
+		"\x1b[H": "home", "\x1b[F": "end", "\x1b[1~": "home", "\x1b[4~": "end",
+		"\x1bOA": "up", "\x1bOB": "down", "\x1bOC": "right", "\x1bOD": "left",
+	}
+	keys := []string{}
+	s := string(data)
+	for len(s) > 0 {
+		matched := false
+		for sequence, key := range sequences {
+			if strings.HasPrefix(s, sequence) {
+				keys = append(keys, key)
+				s = s[len(sequence):]
+				matched = true
+				break
+			}
+		}
+		if matched {
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(s)
+		s = s[size:]
+		switch r {
+		case '\r', '\n':
+			keys = append(keys, "enter")
+		case ' ':
+			keys = append(keys, "space")
+		case 3:
+			keys = append(keys, "ctrl+c")
+		case 0x1b:
+			keys = append(keys, "esc")
+		default:
+			keys = append(keys, string(r))
+		}
+	}
+	return keys
+}
+
+// Run runs the terminal interface on the given terminal until it is quit, switching it to raw mode and the alternate screen.
+func (a *App) Run(in, out *os.File) error {
+	restore, err := makeRaw(int(in.Fd()))
+	if err != nil {
+		return err
+	}
+	defer restore()
+
+	fmt.Fprint(out, enterScreen)
+	defer fmt.Fprint(out, exitScreen)
+
+	buf := make([]byte, 64)
+	for !a.quit {
+		// Terminals that do not report their size (e.g. some pseudo terminals) get the default one
+		width, height, err := size(int(out.Fd()))
+		if err != nil || width == 0 || height == 0 {
+			width, height = 80, 24
+		}
+		fmt.Fprint(out, "\x1b[H"+strings.Join(a.Render(width, height), "\x1b[K\r\n")+"\x1b[K\x1b[J")
+
+		n, err := in.Read(buf)
+		if err != nil {
+			return err
+		}
+		for _, key := range parseKeys(buf[:n]) {
+			a.Handle(key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,309 @@
+package tui
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/christosgalano/bruh/internal/types"
+)
+
+var testNow = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func testDirectory() *types.BicepDirectory {
+	return &types.BicepDirectory{
+		Path: "infra",
+		Files: []types.BicepFile{
+			{
+				Path: "infra/main.bicep",
+				Resources: []types.Resource{
+					{ID: "Microsoft.Resources/resourceGroups", Namespace: "Microsoft.Resources", CurrentAPIVersion: "2023-07-01", AvailableAPIVersions: []string{"2023-07-01", "2021-04-01"}},
+				},
+			},
+			{
+				Path: "infra/modules/app.bicep",
+				Resources: []types.Resource{
+					{ID: "Microsoft.Web/serverfarms", Namespace: "Microsoft.Web", CurrentAPIVersion: "2022-09-01", AvailableAPIVersions: []string{"2023-01-01", "2022-09-01"}, Line: 4},
+					{ID: "Microsoft.Web/sites", Namespace: "Microsoft.Web", CurrentAPIVersion: "2021-02-01", AvailableAPIVersions: []string{"2023-01-01", "2022-09-01", "2021-02-01"}, Line: 9},
+					{ID: "Microsoft.Fake/things", Namespace: "Microsoft.Fake", CurrentAPIVersion: "2021-01-01", Unknown: true, Line: 14},
+				},
+			},
+		},
+	}
+}
+
+// labels returns the labels of the visible rows, indented by their depth.
+func labels(a *App) []string {
+	result := []string{}
+	for _, r := range a.rows() {
+		result = append(result, strings.Repeat("  ", r.depth)+r.node.label())
+	}
+	return result
+}
+
+func TestApp_rows(t *testing.T) {
+	tests := []struct {
+		name string
+		keys []string
+		want []string
+	}{
+		{
+			name: "path-order",
+			want: []string{
+				"infra/",
+				"  modules/",
+				"    app.bicep",
+				"      Microsoft.Web/serverfarms",
+				"      Microsoft.Web/sites",
+				"      Microsoft.Fake/things",
+				"  main.bicep",
+				"    Microsoft.Resources/resourceGroups",
+			},
+		},
+		{
+			name: "drift-order",
+			keys: []string{"s"},
+			want: []string{
+				"infra/",
+				"  modules/",
+				"    app.bicep",
+				"      Microsoft.Web/sites",
+				"      Microsoft.Web/serverfarms",
+				"      Microsoft.Fake/things",
+				"  main.bicep",
+				"    Microsoft.Resources/resourceGroups",
+			},
+		},
+		{
+			name: "namespace-filter",
+			keys: []string{"n"},
+			want: []string{
+				"infra/",
+				"  modules/",
+				"    app.bicep",
+				"      Microsoft.Fake/things",
+			},
+		},
+		{
+			name: "status-filter",
+			keys: []string{"f"},
+			want: []string{
+				"infra/",
+				"  modules/",
+				"    app.bicep",
+				"      Microsoft.Web/serverfarms",
+				"      Microsoft.Web/sites",
+			},
+		},
+		{
+			name: "collapse",
+			keys: []string{"down", "left"},
+			want: []string{
+				"infra/",
+				"  modules/",
+				"  main.bicep",
+				"    Microsoft.Resources/resourceGroups",
+			},
+		},
+		{
+			name: "collapse-parent-of-resource",
+			keys: []string{"end", "left", "left"},
+			want: []string{
+				"infra/",
+				"  modules/",
+				"    app.bicep",
+				"      Microsoft.Web/serverfarms",
+				"      Microsoft.Web/sites",
+				"      Microsoft.Fake/things",
+				"  main.bicep",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := New(testDirectory(), nil, testNow)
+			for _, key := range tt.keys {
+				a.Handle(key)
+			}
+			if got := labels(a); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("rows() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApp_apply(t *testing.T) {
+	tests := []struct {
+		name        string
+		keys        []string
+		updateErr   error
+		wantUpdated map[string][]bool
+		wantMessage string
+	}{
+		{
+			name: "mark-resource",
+			keys: []string{"down", "down", "down", "space", "a", "y"},
+			wantUpdated: map[string][]bool{
+				"infra/modules/app.bicep": {true, false, false},
+			},
+			wantMessage: "Updated 1 resource(s) in 1 file(s)",
+		},
+		{
+			name: "mark-directory",
+			keys: []string{"space", "a", "y"},
+			wantUpdated: map[string][]bool{
+				"infra/modules/app.bicep": {true, true, false},
+			},
+			wantMessage: "Updated 2 resource(s) in 1 file(s)",
+		},
+		{
+			name:        "cancel",
+			keys:        []string{"space", "a", "n"},
+			wantUpdated: map[string][]bool{},
+			wantMessage: "Update cancelled",
+		},
+		{
+			name:        "nothing-marked",
+			keys:        []string{"a"},
+			wantUpdated: map[string][]bool{},
+			wantMessage: "No resources marked for update",
+		},
+		{
+			name:        "update-error",
+			keys:        []string{"space", "a", "y"},
+			updateErr:   errors.New("permission denied"),
+			wantUpdated: map[string][]bool{"infra/modules/app.bicep": {true, true, false}},
+			wantMessage: "Error: permission denied",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updated := map[string][]bool{}
+			update := func(file *types.BicepFile) error {
+				for _, resource := range file.Resources {
+					updated[file.Path] = append(updated[file.Path], !resource.Skipped && !resource.Unknown)
+				}
+				return tt.updateErr
+			}
+			a := New(testDirectory(), update, testNow)
+			for _, key := range tt.keys {
+				a.Handle(key)
+			}
+			if !reflect.DeepEqual(updated, tt.wantUpdated) {
+				t.Errorf("apply() updated = %v, want %v", updated, tt.wantUpdated)
+			}
+			if a.message != tt.wantMessage {
+				t.Errorf("apply() message = %q, want %q", a.message, tt.wantMessage)
+			}
+			for _, r := range a.root.resources() {
+				if r.resource().Skipped {
+					t.Errorf("apply() left %s skipped", r.label())
+				}
+			}
+		})
+	}
+}
+
+func TestApp_applyTwice(t *testing.T) {
+	paths := []string{}
+	updated := [][]bool{}
+	update := func(file *types.BicepFile) error {
+		paths = append(paths, file.Path)
+		included := []bool{}
+		for i, resource := range file.Resources {
+			included = append(included, !resource.Skipped && !resource.Unknown)
+			if included[i] {
+				file.Resources[i].CurrentAPIVersion = resource.LatestAPIVersion()
+			}
+		}
+		updated = append(updated, included)
+
+		// Like bicep.UpdateFile without in-place, which writes a new file
+		file.Path = strings.TrimSuffix(file.Path, ".bicep") + "_updated.bicep"
+		return nil
+	}
+
+	directory := testDirectory()
+	a := New(directory, update, testNow)
+	for _, key := range []string{"down", "down", "down", "space", "a", "y", "down", "space", "a", "y"} {
+		a.Handle(key)
+	}
+
+	if want := []string{"infra/modules/app.bicep", "infra/modules/app.bicep"}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("apply() paths = %v, want %v", paths, want)
+	}
+	if want := [][]bool{{true, false, false}, {true, true, false}}; !reflect.DeepEqual(updated, want) {
+		t.Errorf("apply() updated = %v, want %v", updated, want)
+	}
+	file := directory.Files[1]
+	if file.Path != "infra/modules/app.bicep" || file.Resources[0].CurrentAPIVersion != "2023-01-01" || file.Resources[1].CurrentAPIVersion != "2023-01-01" {
+		t.Errorf("apply() file = %s with versions %s and %s, want the original path with the latest versions",
+			file.Path, file.Resources[0].CurrentAPIVersion, file.Resources[1].CurrentAPIVersion)
+	}
+}
+
+func TestApp_Render(t *testing.T) {
+	a := New(testDirectory(), nil, testNow)
+	for _, key := range []string{"down", "down", "down", "down"} {
+		a.Handle(key)
+	}
+	lines := a.Render(120, 20)
+	if len(lines) != 20 {
+		t.Fatalf("Render() = %d lines, want %d", len(lines), 20)
+	}
+	screen := strings.Join(lines, "\n")
+	for _, want := range []string{
+		"sort: path  namespace: all  status: all  marked: 0",
+		"[ ] Microsoft.Web/sites  2021-02-01 → 2023-01-01  outdated",
+		"file: infra/modules/app.bicep:9",
+		"2023-01-01 (365 days old)  latest",
+		"2021-02-01 (1064 days old)  current",
+		"space mark",
+	} {
+		if !strings.Contains(screen, want) {
+			t.Errorf("Render() = %q, want it to contain %q", screen, want)
+		}
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []string
+	}{
+		{name: "arrows", data: "\x1b[A\x1b[B\x1b[C\x1b[D", want: []string{"up", "down", "right", "left"}},
+		{name: "keys", data: "j \rq", want: []string{"j", "space", "enter", "q"}},
+		{name: "ctrl-c", data: "\x03", want: []string{"ctrl+c"}},
+		{name: "escape", data: "\x1b", want: []string{"esc"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseKeys([]byte(tt.data)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseKeys() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFit(t *testing.T) {
+	tests := []struct {
+		s     string
+		width int
+		want  string
+	}{
+		{s: "abc", width: 5, want: "abc  "},
+		{s: "abcdef", width: 4, want: "abc…"},
+		{s: "a → b", width: 5, want: "a → b"},
+		{s: "abc", width: 0, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			if got := fit(tt.s, tt.width); got != tt.want {
+				t.Errorf("fit() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
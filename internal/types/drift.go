@@ -0,0 +1,197 @@
+package types
+
+import (
+	"math"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// dateFormat is the format of the date part of the API versions.
+	dateFormat = "2006-01-02"
+
+	// hoursPerDay is the number of hours in a day, used to convert durations to days.
+	hoursPerDay = 24
+
+	// daysPerMonth is the average number of days in a month, used to compute drift scores.
+	daysPerMonth = 30
+)
+
+// versionDate returns the date of an API version (e.g. 2021-02-01 for 2021-02-01-preview).
+func versionDate(version string) (time.Time, bool) {
+	date, err := time.Parse(dateFormat, strings.TrimSuffix(version, "-preview"))
+	if err != nil {
+		return time.Time{}, false
+	}
+	return date, true
+}
+
+// days returns the number of whole days between two dates, or 0 if to is before from.
+func days(from, to time.Time) int {
+	if to.Before(from) {
+		return 0
+	}
+	return int(to.Sub(from).Hours() / hoursPerDay)
+}
+
+// VersionsBehind returns the number of available API versions that are newer than the current one.
+func (r Resource) VersionsBehind() int {
+	for i, version := range r.AvailableAPIVersions {
+		if version == r.CurrentAPIVersion {
+			return i
+		}
+	}
+
+	// The current version is not available (e.g. a preview version when only GA versions are considered)
+	current, ok := versionDate(r.CurrentAPIVersion)
+	if !ok {
+		return 0
+	}
+	behind := 0
+	for _, version := range r.AvailableAPIVersions {
+		if date, ok := versionDate(version); ok && !date.Before(current) {
+			behind++
+		}
+	}
+	return behind
+}
+
+// AgeDays returns the age in days of the current API version at the given time.
+func (r Resource) AgeDays(now time.Time) int {
+	current, ok := versionDate(r.CurrentAPIVersion)
+	if !ok {
+		return 0
+	}
+	return days(current, now)
+}
+
+// GapDays returns the number of days between the current and the latest API versions.
+func (r Resource) GapDays() int {
+	current, okCurrent := versionDate(r.CurrentAPIVersion)
+	latest, okLatest := versionDate(r.LatestAPIVersion())
+	if !okCurrent || !okLatest {
+		return 0
+	}
+	return days(current, latest)
+}
+
+// Drift contains the drift of a group of resources from their latest API versions:
+//   - Path: the path of the file or directory containing the resources
+//   - Resources: the number of resources with a known type and API version
+//   - Outdated: the number of resources not using the latest API version
+//   - VersionsBehind: the total number of API versions the resources are behind
+//   - MaxAgeDays: the age in days of the oldest API version in use
+//   - MaxGapDays: the largest gap in days between a current and a latest API version
+//   - Score: the drift score, i.e. the total gap in months between the current and latest API versions
+type Drift struct {
+	Path           string
+	Resources      int
+	Outdated       int
+	VersionsBehind int
+	MaxAgeDays     int
+	MaxGapDays     int
+	Score          float64
+
+	gapDays int
+}
+
+// add adds a resource to the drift at the given time. Resources with an unknown type or an unresolved API version are ignored.
+func (d *Drift) add(r Resource, now time.Time) {
+	if r.Unknown || r.Unresolved {
+		return
+	}
+	d.Resources++
+	if r.CurrentAPIVersion != r.LatestAPIVersion() {
+		d.Outdated++
+	}
+	d.VersionsBehind += r.VersionsBehind()
+	if age := r.AgeDays(now); age > d.MaxAgeDays {
+		d.MaxAgeDays = age
+	}
+	gap := r.GapDays()
+	if gap > d.MaxGapDays {
+		d.MaxGapDays = gap
+	}
+	d.gapDays += gap
+	d.Score = math.Round(float64(d.gapDays)/daysPerMonth*10) / 10
+}
+
+// Drift returns the drift of the resources in the bicep file at the given time.
+func (file BicepFile) Drift(now time.Time) Drift {
+	drift := Drift{Path: file.Path}
+	for _, r := range file.Resources {
+		drift.add(r, now)
+	}
+	return drift
+}
+
+// Drift returns the drift of the resources in all the files of the bicep directory at the given time.
+func (dir BicepDirectory) Drift(now time.Time) Drift {
+	drift := Drift{Path: dir.Path}
+	for _, file := range dir.Files {
+		for _, r := range file.Resources {
+			drift.add(r, now)
+		}
+	}
+	return drift
+}
+
+// Drifts returns the drift of each directory containing bicep files, including the bicep directory itself,
+// sorted by score in descending order. Each drift includes the resources of the nested directories as well.
+// Files outside the bicep directory (e.g. modules reached from an entry file) are only included in the drift of the bicep directory.
+func (dir BicepDirectory) Drifts(now time.Time) []Drift {
+	drifts := map[string]*Drift{}
+	root := filepath.Clean(dir.Path)
+	for _, file := range dir.Files {
+		for _, d := range rollUp(root, filepath.Dir(filepath.Clean(file.Path))) {
+			if drifts[d] == nil {
+				drifts[d] = &Drift{Path: d}
+			}
+			for _, r := range file.Resources {
+				drifts[d].add(r, now)
+			}
+		}
+	}
+
+	result := make([]Drift, 0, len(drifts))
+	for _, drift := range drifts {
+		result = append(result, *drift)
+	}
+	sortDrifts(result)
+	return result
+}
+
+// rollUp returns the directories from a directory up to the root, or only the root if the directory is outside of it.
+func rollUp(root, dir string) []string {
+	rel, err := filepath.Rel(root, dir)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return []string{root}
+	}
+	dirs := []string{}
+	for ; rel != "."; rel = filepath.Dir(rel) {
+		dirs = append(dirs, filepath.Join(root, rel))
+	}
+	return append(dirs, root)
+}
+
+// sortDrifts sorts drifts by score in descending order, and then by path.
+func sortDrifts(drifts []Drift) {
+	sort.Slice(drifts, func(i, j int) bool {
+		if drifts[i].Score != drifts[j].Score {
+			return drifts[i].Score > drifts[j].Score
+		}
+		return drifts[i].Path < drifts[j].Path
+	})
+}
+
+// FileDrifts returns the drift of each file of the bicep directory at the given time, sorted by score in descending order.
+func (dir BicepDirectory) FileDrifts(now time.Time) []Drift {
+	drifts := make([]Drift, 0, len(dir.Files))
+	for _, file := range dir.Files {
+		drifts = append(drifts, file.Drift(now))
+	}
+	sortDrifts(drifts)
+	return drifts
+}
@@ -0,0 +1,164 @@
+package types
+
+import (
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestResource_Drift(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name               string
+		resource           Resource
+		wantVersionsBehind int
+		wantAgeDays        int
+		wantGapDays        int
+	}{
+		{
+			name: "latest",
+			resource: Resource{
+				CurrentAPIVersion:    "2023-01-01",
+				AvailableAPIVersions: []string{"2023-01-01", "2022-01-01"},
+			},
+			wantVersionsBehind: 0,
+			wantAgeDays:        365,
+			wantGapDays:        0,
+		},
+		{
+			name: "outdated",
+			resource: Resource{
+				CurrentAPIVersion:    "2021-01-01",
+				AvailableAPIVersions: []string{"2023-01-01", "2022-01-01", "2021-01-01"},
+			},
+			wantVersionsBehind: 2,
+			wantAgeDays:        1095,
+			wantGapDays:        730,
+		},
+		{
+			name: "preview-not-available",
+			resource: Resource{
+				CurrentAPIVersion:    "2022-01-01-preview",
+				AvailableAPIVersions: []string{"2023-01-01", "2022-01-01", "2021-01-01"},
+			},
+			wantVersionsBehind: 2,
+			wantAgeDays:        730,
+			wantGapDays:        365,
+		},
+		{
+			name: "unknown",
+			resource: Resource{
+				CurrentAPIVersion: "2022-01-01",
+				Unknown:           true,
+			},
+			wantVersionsBehind: 0,
+			wantAgeDays:        730,
+			wantGapDays:        0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.resource.VersionsBehind(); got != tt.wantVersionsBehind {
+				t.Errorf("VersionsBehind() = %v, want %v", got, tt.wantVersionsBehind)
+			}
+			if got := tt.resource.AgeDays(now); got != tt.wantAgeDays {
+				t.Errorf("AgeDays() = %v, want %v", got, tt.wantAgeDays)
+			}
+			if got := tt.resource.GapDays(); got != tt.wantGapDays {
+				t.Errorf("GapDays() = %v, want %v", got, tt.wantGapDays)
+			}
+		})
+	}
+}
+
+func TestBicepDirectory_Drifts(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	dir := BicepDirectory{
+		Path: "bicep",
+		Files: []BicepFile{
+			{
+				Path: "bicep/main.bicep",
+				Resources: []Resource{
+					{CurrentAPIVersion: "2023-01-01", AvailableAPIVersions: []string{"2023-01-01"}},
+				},
+			},
+			{
+				Path: "bicep/modules/compute.bicep",
+				Resources: []Resource{
+					{CurrentAPIVersion: "2022-01-01", AvailableAPIVersions: []string{"2023-01-01", "2022-01-01"}},
+					{CurrentAPIVersion: "2021-01-01", AvailableAPIVersions: []string{"2023-01-01", "2022-01-01", "2021-01-01"}},
+					{CurrentAPIVersion: "2021-01-01", Unknown: true},
+					{CurrentAPIVersion: "[variables('apiVersion')]", Unresolved: true},
+				},
+			},
+		},
+	}
+
+	want := []Drift{
+		{Path: "bicep", Resources: 3, Outdated: 2, VersionsBehind: 3, MaxAgeDays: 1095, MaxGapDays: 730, Score: 36.5, gapDays: 1095},
+		{Path: "bicep/modules", Resources: 2, Outdated: 2, VersionsBehind: 3, MaxAgeDays: 1095, MaxGapDays: 730, Score: 36.5, gapDays: 1095},
+	}
+	if got := dir.Drifts(now); !reflect.DeepEqual(got, want) {
+		t.Errorf("Drifts() = %+v, want %+v", got, want)
+	}
+
+	wantFiles := []Drift{
+		{Path: "bicep/modules/compute.bicep", Resources: 2, Outdated: 2, VersionsBehind: 3, MaxAgeDays: 1095, MaxGapDays: 730, Score: 36.5, gapDays: 1095},
+		{Path: "bicep/main.bicep", Resources: 1, Outdated: 0, VersionsBehind: 0, MaxAgeDays: 365, MaxGapDays: 0, Score: 0, gapDays: 0},
+	}
+	if got := dir.FileDrifts(now); !reflect.DeepEqual(got, wantFiles) {
+		t.Errorf("FileDrifts() = %+v, want %+v", got, wantFiles)
+	}
+}
+
+func TestBicepDirectory_Drifts_outsideRoot(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	resources := []Resource{{CurrentAPIVersion: "2022-01-01", AvailableAPIVersions: []string{"2023-01-01", "2022-01-01"}}}
+	tests := []struct {
+		name  string
+		root  string
+		files []string
+		want  []string
+	}{
+		{
+			name:  "relative-root",
+			root:  "infra",
+			files: []string{"infra/main.bicep", "infra/modules/app.bicep", "shared/network.bicep", "../common/storage.bicep"},
+			want:  []string{"infra", "infra/modules"},
+		},
+		{
+			name:  "current-directory",
+			root:  ".",
+			files: []string{"main.bicep", "modules/app.bicep", "../shared/network.bicep"},
+			want:  []string{".", "modules"},
+		},
+		{
+			name:  "absolute-root",
+			root:  "/repo/infra",
+			files: []string{"/repo/infra/main.bicep", "/repo/shared/network.bicep"},
+			want:  []string{"/repo/infra"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := BicepDirectory{Path: tt.root}
+			for _, path := range tt.files {
+				dir.Files = append(dir.Files, BicepFile{Path: filepath.FromSlash(path), Resources: resources})
+			}
+
+			got := []string{}
+			for _, drift := range dir.Drifts(now) {
+				got = append(got, filepath.ToSlash(drift.Path))
+				if filepath.ToSlash(drift.Path) == tt.root && drift.Resources != len(tt.files) {
+					t.Errorf("Drifts() root resources = %d, want %d", drift.Resources, len(tt.files))
+				}
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Drifts() paths = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
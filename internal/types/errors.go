@@ -0,0 +1,14 @@
+package types
+
+import "errors"
+
+var (
+	// ErrFileNotExist is returned when a file to be parsed does not exist.
+	ErrFileNotExist = errors.New("file does not exist")
+
+	// ErrIsDirectory is returned when a file to be parsed is a directory.
+	ErrIsDirectory = errors.New("given path is a directory")
+
+	// ErrInvalidExtension is returned when a file to be parsed does not have the extension of its format (e.g. .bicep).
+	ErrInvalidExtension = errors.New("invalid file extension")
+)
@@ -6,6 +6,7 @@ package types
 import (
 	"fmt"
 	"path/filepath"
+	"strings"
 )
 
 // Resource contains information about a resource:
@@ -14,12 +15,106 @@ import (
 //   - Namespace: the resource namespace (e.g. Microsoft.Network)
 //   - CurrentAPIVersion: the used API version (e.g. 2021-02-01)
 //   - AvailableAPIVersions: the available API versions (e.g. [2021-02-01 2020-11-01])
+//   - Unknown: whether the resource type does not exist (e.g. Microsoft.Storage/storageAcounts)
+//   - Suggestions: the known resource types closest to an unknown one (e.g. [Microsoft.Storage/storageAccounts])
+//   - Properties: the paths of the properties set in the resource body (e.g. [name location properties.subnets[].name])
+//   - BreakingChanges: the changes of the latest API version that affect the resource body
+//   - Changelog: all the property changes between the current and latest API versions
+//   - Skipped: whether the resource is excluded from the update
+//   - Unresolved: whether the API version is an expression that cannot be statically resolved (e.g. [variables('apiVersion')])
+//   - Function: the function whose call passes the API version (e.g. listKeys), empty for resource declarations
+//   - Module: whether the resource is a Bicep registry module reference (e.g. br/public:avm/res/storage/storage-account),
+//     whose namespace is the registry, name is the repository, and API versions are tags
+//   - Line: the line (1-based) of the file where the API version is written (e.g. 12)
+//   - Pinned: whether the API version is pinned by the project configuration or during an interactive update, so that it is never updated past it
 type Resource struct {
 	ID                   string
 	Name                 string
 	Namespace            string
 	CurrentAPIVersion    string
 	AvailableAPIVersions []string
+	Unknown              bool
+	Suggestions          []string
+	Properties           []string
+	BreakingChanges      []PropertyChange
+	Changelog            []PropertyChange
+	Skipped              bool
+	Unresolved           bool
+	Function             string
+	Module               bool
+	Line                 int
+	Pinned               bool
+}
+
+// LatestAPIVersion returns the latest available API version of the resource or an empty string if there is none.
+func (r Resource) LatestAPIVersion() string {
+	if len(r.AvailableAPIVersions) == 0 {
+		return ""
+	}
+	return r.AvailableAPIVersions[0]
+}
+
+// Cap limits the available API versions of the resource to the given version and the older ones, so that it becomes the latest one.
+// If the version is not available, only the current API version is kept, so that the resource is not updated at all.
+func (r *Resource) Cap(version string) {
+	for i, v := range r.AvailableAPIVersions {
+		if v == version {
+			r.AvailableAPIVersions = r.AvailableAPIVersions[i:]
+			return
+		}
+	}
+	r.AvailableAPIVersions = []string{r.CurrentAPIVersion}
+}
+
+// GAAPIVersion returns the latest available non-preview API version of the resource or an empty string if there is none.
+func (r Resource) GAAPIVersion() string {
+	for _, version := range r.AvailableAPIVersions {
+		if !strings.HasSuffix(version, "-preview") {
+			return version
+		}
+	}
+	return ""
+}
+
+// Promotable returns true if the resource uses a preview API version while a non-preview one of the same date or newer is available.
+func (r Resource) Promotable() bool {
+	if !strings.HasSuffix(r.CurrentAPIVersion, "-preview") {
+		return false
+	}
+	ga := r.GAAPIVersion()
+	return ga != "" && ga >= strings.TrimSuffix(r.CurrentAPIVersion, "-preview")
+}
+
+// Status returns the status of the resource based on its current and available API versions.
+func (r Resource) Status() Status {
+	switch {
+	case r.Unresolved:
+		return StatusUnresolved
+	case r.Unknown:
+		return StatusUnknown
+	case r.Promotable():
+		return StatusPromotable
+	case r.CurrentAPIVersion != r.LatestAPIVersion():
+		return StatusOutdated
+	}
+	return StatusLatest
+}
+
+// Label returns the label of the resource: its ID for resource declarations, or the function call passing
+// the API version (e.g. listKeys(Microsoft.Storage/storageAccounts)) for function calls.
+func (r Resource) Label() string {
+	if r.Function != "" {
+		return r.Function + "(" + r.ID + ")"
+	}
+	return r.ID
+}
+
+// LineAt returns the line (1-based) of the given byte offset of a file's content.
+func LineAt(content string, offset int) int {
+	if offset > len(content) {
+		offset = len(content)
+	}
+	return strings.Count(content[:offset], "\n") + 1
 }
 
 // String returns a string representation of a types.Resource object.
@@ -28,12 +123,82 @@ func (r Resource) String() string {
 		r.ID, r.Name, r.Namespace, r.CurrentAPIVersion, r.AvailableAPIVersions)
 }
 
+// PropertyChange contains information about a change of a property between two API versions:
+//   - Path: the path to the property (e.g. properties.siteConfig.alwaysOn)
+//   - Kind: the kind of the change (e.g. removed)
+//   - Detail: additional information about the change (e.g. the new name of a renamed property)
+type PropertyChange struct {
+	Path   string
+	Kind   ChangeKind
+	Detail string
+}
+
+// String returns a string representation of a types.PropertyChange object.
+func (c PropertyChange) String() string {
+	if c.Detail == "" {
+		return fmt.Sprintf("%s: %s", c.Path, c.Kind)
+	}
+	return fmt.Sprintf("%s: %s (%s)", c.Path, c.Kind, c.Detail)
+}
+
+// ChangeKind represents the kind of a property change between two API versions.
+type ChangeKind int8
+
+const (
+	ChangeRemoved     ChangeKind = iota // ChangeRemoved corresponds to a property that no longer exists
+	ChangeRenamed                       // ChangeRenamed corresponds to a property that exists under a different name
+	ChangeRequired                      // ChangeRequired corresponds to a property that became required
+	ChangeTypeChanged                   // ChangeTypeChanged corresponds to a property whose type changed
+	ChangeAdded                         // ChangeAdded corresponds to a property that did not exist before
+)
+
+// String returns a string representation of a types.ChangeKind object.
+func (k ChangeKind) String() string {
+	switch k {
+	case ChangeRemoved:
+		return "removed"
+	case ChangeRenamed:
+		return "renamed"
+	case ChangeRequired:
+		return "newly required"
+	case ChangeTypeChanged:
+		return "type changed"
+	case ChangeAdded:
+		return "added"
+	}
+	return "changed"
+}
+
 // BicepFile contains information about a bicep file:
 //   - Path: the path to the bicep file (e.g. ./bicep/modules/virtualNetworks.bicep)
 //   - Resources: the bicep resources defined in the bicep file
+//   - Chain: the files through which the file is reached from an entry file, starting with the entry file (e.g. [main.bicep modules/app.bicep]),
+//     empty for the entry file itself and for files parsed on their own
+//   - References: the paths of the local files referenced by the modules and imports of the file (e.g. [modules/app.bicep])
 type BicepFile struct {
-	Path      string
-	Resources []Resource
+	Path       string
+	Resources  []Resource
+	Chain      []string
+	References []string
+}
+
+// Occurrence returns the occurrence (1-based) of the i-th resource among the resources of the file with the same ID
+// (e.g. 2 for the second Microsoft.Web/sites), or 0 if no other resource of the file has that ID.
+func (file BicepFile) Occurrence(i int) int {
+	occurrence, total := 0, 0
+	for j, r := range file.Resources {
+		if r.ID != file.Resources[i].ID {
+			continue
+		}
+		total++
+		if j <= i {
+			occurrence++
+		}
+	}
+	if total == 1 {
+		return 0
+	}
+	return occurrence
 }
 
 // String returns a string representation of a types.BicepFile object.
@@ -89,3 +254,31 @@ func (s Mode) String() string {
 	}
 	return "unknown"
 }
+
+// Status represents the status of a resource's API version.
+type Status int8
+
+const (
+	StatusLatest     Status = iota // StatusLatest corresponds to a resource using the latest API version
+	StatusOutdated                 // StatusOutdated corresponds to a resource using an older API version
+	StatusUnknown                  // StatusUnknown corresponds to a resource whose type does not exist
+	StatusPromotable               // StatusPromotable corresponds to a resource using a preview API version with a newer or same-date GA one available
+	StatusUnresolved               // StatusUnresolved corresponds to a resource whose API version is not statically resolvable
+)
+
+// String returns a string representation of a types.Status object.
+func (s Status) String() string {
+	switch s {
+	case StatusLatest:
+		return "latest"
+	case StatusOutdated:
+		return "outdated"
+	case StatusUnknown:
+		return "unknown"
+	case StatusPromotable:
+		return "promotable"
+	case StatusUnresolved:
+		return "unresolved"
+	}
+	return "unknown"
+}
@@ -0,0 +1,167 @@
+package types
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestResource_Status(t *testing.T) {
+	tests := []struct {
+		name     string
+		resource Resource
+		want     Status
+	}{
+		{
+			name: "latest",
+			resource: Resource{
+				CurrentAPIVersion:    "2023-01-31",
+				AvailableAPIVersions: []string{"2023-01-31", "2018-11-30"},
+			},
+			want: StatusLatest,
+		},
+		{
+			name: "outdated",
+			resource: Resource{
+				CurrentAPIVersion:    "2018-11-30",
+				AvailableAPIVersions: []string{"2023-01-31", "2018-11-30"},
+			},
+			want: StatusOutdated,
+		},
+		{
+			name: "unknown",
+			resource: Resource{
+				CurrentAPIVersion: "2023-01-01",
+				Unknown:           true,
+			},
+			want: StatusUnknown,
+		},
+		{
+			name: "unresolved",
+			resource: Resource{
+				CurrentAPIVersion: "[variables('apiVersion')]",
+				Unresolved:        true,
+			},
+			want: StatusUnresolved,
+		},
+		{
+			name: "promotable-newer-ga",
+			resource: Resource{
+				CurrentAPIVersion:    "2022-01-31-preview",
+				AvailableAPIVersions: []string{"2023-01-31", "2022-01-31-preview", "2018-11-30"},
+			},
+			want: StatusPromotable,
+		},
+		{
+			name: "promotable-same-date-ga",
+			resource: Resource{
+				CurrentAPIVersion:    "2023-01-31-preview",
+				AvailableAPIVersions: []string{"2023-05-01-preview", "2023-01-31", "2023-01-31-preview"},
+			},
+			want: StatusPromotable,
+		},
+		{
+			name: "latest-preview-with-older-ga",
+			resource: Resource{
+				CurrentAPIVersion:    "2023-05-01-preview",
+				AvailableAPIVersions: []string{"2023-05-01-preview", "2023-01-31"},
+			},
+			want: StatusLatest,
+		},
+		{
+			name: "outdated-preview-with-older-ga",
+			resource: Resource{
+				CurrentAPIVersion:    "2023-02-01-preview",
+				AvailableAPIVersions: []string{"2023-05-01-preview", "2023-02-01-preview", "2023-01-31"},
+			},
+			want: StatusOutdated,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.resource.Status(); got != tt.want {
+				t.Errorf("Status() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResource_Cap(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+		want    []string
+	}{
+		{
+			name:    "older-version",
+			version: "2022-09-01",
+			want:    []string{"2022-09-01", "2021-02-01"},
+		},
+		{
+			name:    "latest-version",
+			version: "2023-01-01",
+			want:    []string{"2023-01-01", "2022-09-01", "2021-02-01"},
+		},
+		{
+			name:    "unavailable-version",
+			version: "2020-01-01",
+			want:    []string{"2021-02-01"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := Resource{
+				CurrentAPIVersion:    "2021-02-01",
+				AvailableAPIVersions: []string{"2023-01-01", "2022-09-01", "2021-02-01"},
+			}
+			r.Cap(tt.version)
+			if !reflect.DeepEqual(r.AvailableAPIVersions, tt.want) {
+				t.Errorf("Cap() = %v, want %v", r.AvailableAPIVersions, tt.want)
+			}
+			if r.LatestAPIVersion() != tt.want[0] {
+				t.Errorf("LatestAPIVersion() = %v, want %v", r.LatestAPIVersion(), tt.want[0])
+			}
+		})
+	}
+}
+
+func TestResource_Label(t *testing.T) {
+	tests := []struct {
+		name     string
+		resource Resource
+		want     string
+	}{
+		{
+			name:     "declaration",
+			resource: Resource{ID: "Microsoft.Storage/storageAccounts"},
+			want:     "Microsoft.Storage/storageAccounts",
+		},
+		{
+			name:     "function-call",
+			resource: Resource{ID: "Microsoft.Storage/storageAccounts", Function: "listKeys"},
+			want:     "listKeys(Microsoft.Storage/storageAccounts)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.resource.Label(); got != tt.want {
+				t.Errorf("Label() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBicepFile_Occurrence(t *testing.T) {
+	file := BicepFile{Resources: []Resource{
+		{ID: "Microsoft.Web/sites"},
+		{ID: "Microsoft.Web/serverfarms"},
+		{ID: "Microsoft.Web/sites", Function: "list"},
+		{ID: "Microsoft.Web/sites"},
+	}}
+	got := []int{}
+	for i := range file.Resources {
+		got = append(got, file.Occurrence(i))
+	}
+	if want := []int{1, 0, 2, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Occurrence() = %v, want %v", got, want)
+	}
+}
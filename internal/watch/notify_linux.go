@@ -0,0 +1,146 @@
+package watch
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	// watchMask is the mask of the inotify events reported for each watched directory.
+	watchMask = syscall.IN_CLOSE_WRITE | syscall.IN_CREATE | syscall.IN_DELETE | syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO | syscall.IN_ONLYDIR
+)
+
+// inotify is an inotify instance watching the directories of a tree (or only its root if flat is true),
+// along with the directories of its watch descriptors.
+type inotify struct {
+	ctx    context.Context
+	w      *Watcher
+	root   string
+	flat   bool
+	events chan<- string
+	fd     int
+	file   *os.File
+	dirs   map[int]string
+}
+
+// add watches a directory and, unless flat is true, its subdirectories, sending the paths of the files found in them
+// (as the directory may have been populated before being watched) if report is true.
+func (in *inotify) add(dir string, report bool) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if !d.IsDir() {
+			if report {
+				send(in.ctx, in.events, path)
+			}
+			return nil
+		}
+		if (path != dir && in.flat) || in.w.skip(in.root, path) {
+			return filepath.SkipDir
+		}
+		wd, err := syscall.InotifyAddWatch(in.fd, path, watchMask)
+		if err != nil {
+			if errors.Is(err, syscall.ENOENT) || errors.Is(err, syscall.ENOTDIR) {
+				return nil
+			}
+			return err
+		}
+		in.dirs[wd] = path
+		return nil
+	})
+}
+
+// remove stops watching a directory moved out of the tree and its subdirectories, whose watches would otherwise
+// keep reporting their files under the paths they had in the tree.
+func (in *inotify) remove(dir string) {
+	for wd, path := range in.dirs {
+		if path == dir || strings.HasPrefix(path, dir+string(filepath.Separator)) {
+			_, _ = syscall.InotifyRmWatch(in.fd, uint32(wd))
+			delete(in.dirs, wd)
+		}
+	}
+}
+
+// notify watches a directory with inotify and sends the paths of the changed files until the context is done.
+// Errors reading the events are sent to errs. If inotify is not available, the function returns an error.
+func (w *Watcher) notify(ctx context.Context, dir string, flat bool, events chan<- string, errs chan<- error) error {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return err
+	}
+	// A non-blocking descriptor is handled by the runtime poller, so that closing the file unblocks a pending read
+	// (the descriptor is kept aside, as calling Fd would switch it back to blocking mode)
+	in := &inotify{ctx: ctx, w: w, root: dir, flat: flat, events: events, fd: fd, file: os.NewFile(uintptr(fd), "inotify"), dirs: map[int]string{}}
+	if err := in.add(dir, false); err != nil {
+		in.file.Close()
+		return err
+	}
+
+	go func() {
+		<-ctx.Done()
+		in.file.Close()
+	}()
+
+	go func() {
+		buf := make([]byte, 64*(syscall.SizeofInotifyEvent+syscall.NAME_MAX+1))
+		for {
+			n, err := in.file.Read(buf)
+			if err != nil {
+				if ctx.Err() == nil {
+					errs <- err
+				}
+				return
+			}
+			for offset := 0; offset+syscall.SizeofInotifyEvent <= n; {
+				event := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+				start := offset + syscall.SizeofInotifyEvent
+				name := strings.TrimRight(string(buf[start:start+int(event.Len)]), "\x00")
+				offset = start + int(event.Len)
+
+				if event.Mask&syscall.IN_Q_OVERFLOW != 0 {
+					send(ctx, events, dir)
+					continue
+				}
+				parent, ok := in.dirs[int(event.Wd)]
+				if !ok {
+					continue
+				}
+				if event.Mask&syscall.IN_IGNORED != 0 {
+					delete(in.dirs, int(event.Wd))
+					continue
+				}
+				path := filepath.Join(parent, name)
+				if event.Mask&syscall.IN_ISDIR == 0 {
+					send(ctx, events, path)
+					continue
+				}
+
+				// Directories created or moved into the tree are watched as well, and their files reported,
+				// while the ones removed or moved out of it are reported themselves, and the latter no longer watched
+				if event.Mask&(syscall.IN_CREATE|syscall.IN_MOVED_TO) != 0 && !flat {
+					if err := in.add(path, true); err != nil && ctx.Err() == nil {
+						errs <- err
+						return
+					}
+				}
+				if event.Mask&syscall.IN_MOVED_FROM != 0 && !flat {
+					in.remove(path)
+				}
+				if event.Mask&(syscall.IN_DELETE|syscall.IN_MOVED_FROM) != 0 && !flat {
+					send(ctx, events, path)
+				}
+			}
+		}
+	}()
+	return nil
+}
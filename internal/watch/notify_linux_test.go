@@ -0,0 +1,127 @@
+package watch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// expect reads the events of a notifier until the wanted paths, relative to dir, are all reported (or a timeout expires),
+// and returns the reported paths relative to dir.
+func expect(t *testing.T, events <-chan string, errs <-chan error, dir string, want []string) map[string]bool {
+	t.Helper()
+	reported := map[string]bool{}
+	timeout := time.After(5 * time.Second)
+	for {
+		missing := false
+		for _, path := range want {
+			if !reported[path] {
+				missing = true
+			}
+		}
+		if !missing {
+			return reported
+		}
+
+		select {
+		case path := <-events:
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			reported[filepath.ToSlash(rel)] = true
+		case err := <-errs:
+			t.Fatalf("notify() error = %v", err)
+		case <-timeout:
+			t.Fatalf("notify() reported %v, want %v", reported, want)
+		}
+	}
+}
+
+func TestWatcher_notify(t *testing.T) {
+	dir, outside := t.TempDir(), t.TempDir()
+	write(t, filepath.Join(dir, "main.bicep"), "")
+	write(t, filepath.Join(dir, "modules", "old.bicep"), "")
+	write(t, filepath.Join(dir, "modules", "nested", "keep.bicep"), "")
+	write(t, filepath.Join(outside, "moved", "nested", "in.bicep"), "")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, errs := make(chan string, 64), make(chan error, 1)
+	if err := New(dir).notify(ctx, dir, false, events, errs); err != nil {
+		t.Fatalf("notify() error = %v", err)
+	}
+
+	steps := []struct {
+		name    string
+		change  func()
+		want    []string
+		wantNot []string
+	}{
+		{
+			name:   "created-tree",
+			change: func() { write(t, filepath.Join(dir, "a", "b", "c", "new.bicep"), "") },
+			want:   []string{"a/b/c/new.bicep"},
+		},
+		{
+			name:   "write-in-created-tree",
+			change: func() { write(t, filepath.Join(dir, "a", "b", "c", "later.bicep"), "") },
+			want:   []string{"a/b/c/later.bicep"},
+		},
+		{
+			name: "moved-in-tree",
+			change: func() {
+				if err := os.Rename(filepath.Join(outside, "moved"), filepath.Join(dir, "moved")); err != nil {
+					t.Fatal(err)
+				}
+			},
+			want: []string{"moved/nested/in.bicep"},
+		},
+		{
+			name:   "write-in-moved-in-tree",
+			change: func() { write(t, filepath.Join(dir, "moved", "nested", "next.bicep"), "") },
+			want:   []string{"moved/nested/next.bicep"},
+		},
+		{
+			name: "removed-tree",
+			change: func() {
+				if err := os.RemoveAll(filepath.Join(dir, "modules")); err != nil {
+					t.Fatal(err)
+				}
+			},
+			want: []string{"modules/old.bicep", "modules/nested/keep.bicep"},
+		},
+		{
+			name: "moved-out-tree",
+			change: func() {
+				if err := os.Rename(filepath.Join(dir, "a"), filepath.Join(outside, "a")); err != nil {
+					t.Fatal(err)
+				}
+			},
+			want: []string{"a"},
+		},
+		{
+			// The files of a tree moved out are no longer reported, and the changes written after them are
+			name: "write-in-moved-out-tree",
+			change: func() {
+				write(t, filepath.Join(outside, "a", "b", "c", "gone.bicep"), "")
+				write(t, filepath.Join(dir, "main.bicep"), "param location string")
+			},
+			want:    []string{"main.bicep"},
+			wantNot: []string{"a/b/c/gone.bicep"},
+		},
+	}
+	for _, step := range steps {
+		t.Run(step.name, func(t *testing.T) {
+			step.change()
+			reported := expect(t, events, errs, dir, step.want)
+			for _, path := range step.wantNot {
+				if reported[path] {
+					t.Errorf("notify() reported %v, want no %v", reported, path)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,16 @@
+//go:build !linux
+
+package watch
+
+import (
+	"context"
+	"errors"
+)
+
+// errUnsupported is returned when file system notifications are not supported on the platform, so that the tree is polled instead.
+var errUnsupported = errors.New("file system notifications are not supported on this platform")
+
+// notify returns errUnsupported.
+func (w *Watcher) notify(ctx context.Context, dir string, flat bool, events chan<- string, errs chan<- error) error {
+	return errUnsupported
+}
@@ -0,0 +1,65 @@
+package watch
+
+import (
+	"context"
+	"io/fs"
+	"path/filepath"
+	"time"
+)
+
+// stamp is the modification time and size of a file, whose change denotes a change of the file.
+type stamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// snapshot returns the stamps of the files of a directory, walking its subdirectories unless flat is true.
+// Errors are ignored, as files may be removed while the tree is walked.
+func (w *Watcher) snapshot(dir string, flat bool) map[string]stamp {
+	stamps := map[string]stamp{}
+	_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if path != dir && (flat || w.skip(dir, path)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			stamps[path] = stamp{modTime: info.ModTime(), size: info.Size()}
+		}
+		return nil
+	})
+	return stamps
+}
+
+// poll sends the paths of the files of a directory that were created, modified or removed between two snapshots, every interval,
+// until the context is done.
+func (w *Watcher) poll(ctx context.Context, dir string, flat bool, events chan<- string) {
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+
+	previous := w.snapshot(dir, flat)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		current := w.snapshot(dir, flat)
+		for path, s := range current {
+			if p, ok := previous[path]; !ok || !p.modTime.Equal(s.modTime) || p.size != s.size {
+				send(ctx, events, path)
+			}
+		}
+		for path := range previous {
+			if _, ok := current[path]; !ok {
+				send(ctx, events, path)
+			}
+		}
+		previous = current
+	}
+}
@@ -0,0 +1,120 @@
+/*
+Package watch provides a watcher reporting the files changed under a directory (or a single file) in batches.
+
+On Linux, changes are reported by inotify, watching every directory of the tree, including the ones created after the watcher starts.
+On the other platforms, or when inotify is not available (e.g. the watch limit is reached), the tree is polled for changes of
+the modification time or size of its files instead.
+*/
+package watch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+const (
+	// DefaultDebounce is the default delay without changes after which a batch of changes is reported.
+	DefaultDebounce = 200 * time.Millisecond
+
+	// DefaultInterval is the default polling interval, used when file system notifications are not available.
+	DefaultInterval = time.Second
+)
+
+// Watcher reports the files changed under a path. The zero value is not usable, use New instead:
+//   - Debounce: the delay without changes after which a batch of changes is reported
+//   - Interval: the polling interval, used when file system notifications are not available
+//   - SkipDir: whether to skip a directory, given its path relative to the watched one with forward slashes (nil to watch every directory)
+type Watcher struct {
+	Debounce time.Duration
+	Interval time.Duration
+	SkipDir  func(rel string) bool
+
+	root    string
+	polling bool // poll even when file system notifications are available
+}
+
+// New returns a watcher of the given directory or file, with the default debounce delay and polling interval.
+func New(root string) *Watcher {
+	return &Watcher{
+		Debounce: DefaultDebounce,
+		Interval: DefaultInterval,
+		root:     root,
+	}
+}
+
+// Run watches the path until the context is done, calling changed with the paths that were created, modified, removed or renamed
+// in each batch of changes, sorted. The paths are joined with the watched path, and a directory is reported when the files under it were removed
+// (e.g. a directory moved out of the tree) or may have changed (the notification queue overflowed).
+// When a file is watched, only its own changes are reported. Run returns nil once the context is done.
+func (w *Watcher) Run(ctx context.Context, changed func(paths []string)) error {
+	fs, err := os.Stat(w.root)
+	if err != nil {
+		return err
+	}
+	dir, file := w.root, ""
+	if !fs.IsDir() {
+		dir, file = filepath.Dir(w.root), w.root
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	events := make(chan string, 64)
+	errs := make(chan error, 1)
+	if w.polling || w.notify(ctx, dir, file != "", events, errs) != nil {
+		go w.poll(ctx, dir, file != "", events)
+	}
+
+	pending := map[string]bool{}
+	var quiet <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-errs:
+			return err
+		case path := <-events:
+			if file != "" && path != file {
+				// Changes lost when watching a file are reported as changes of the file
+				if path != dir {
+					continue
+				}
+				path = file
+			}
+			pending[path] = true
+			quiet = time.After(w.Debounce)
+		case <-quiet:
+			paths := make([]string, 0, len(pending))
+			for path := range pending {
+				paths = append(paths, path)
+			}
+			sort.Strings(paths)
+			pending = map[string]bool{}
+			quiet = nil
+			changed(paths)
+		}
+	}
+}
+
+// skip returns true if a directory of the tree is skipped.
+func (w *Watcher) skip(dir, path string) bool {
+	if w.SkipDir == nil || path == dir {
+		return false
+	}
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	return w.SkipDir(filepath.ToSlash(rel))
+}
+
+// send sends a path unless the context is done.
+func send(ctx context.Context, events chan<- string, path string) {
+	select {
+	case events <- path:
+	case <-ctx.Done():
+	}
+}
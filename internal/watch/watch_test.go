@@ -0,0 +1,126 @@
+package watch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// collect runs a watcher until the wanted paths are reported (or a timeout expires) and returns the reported paths relative to dir.
+func collect(t *testing.T, w *Watcher, dir string, want []string, change func()) []string {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	reported := map[string]bool{}
+	done := make(chan error)
+	go func() {
+		done <- w.Run(ctx, func(paths []string) {
+			for _, path := range paths {
+				rel, err := filepath.Rel(dir, path)
+				if err != nil {
+					t.Error(err)
+				}
+				reported[filepath.ToSlash(rel)] = true
+			}
+			for _, path := range want {
+				if !reported[path] {
+					return
+				}
+			}
+			cancel()
+		})
+	}()
+
+	// Leave time for the watches to be added or the first snapshot to be taken
+	time.Sleep(100 * time.Millisecond)
+	change()
+	if err := <-done; err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	got := []string{}
+	for path := range reported {
+		got = append(got, path)
+	}
+	return got
+}
+
+// write writes a file, creating its parent directories.
+func write(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWatcher_Run(t *testing.T) {
+	tests := []struct {
+		name    string
+		polling bool
+		file    string
+		want    []string
+	}{
+		{
+			name: "directory",
+			want: []string{"main.bicep", "modules/new/storage.bicep", "modules/old.bicep"},
+		},
+		{
+			name:    "directory-polling",
+			polling: true,
+			want:    []string{"main.bicep", "modules/new/storage.bicep", "modules/old.bicep"},
+		},
+		{
+			name: "file",
+			file: "main.bicep",
+			want: []string{"main.bicep"},
+		},
+		{
+			name:    "file-polling",
+			polling: true,
+			file:    "main.bicep",
+			want:    []string{"main.bicep"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			write(t, filepath.Join(dir, "main.bicep"), "")
+			write(t, filepath.Join(dir, "modules", "old.bicep"), "")
+			write(t, filepath.Join(dir, ".hidden", "skipped.bicep"), "")
+
+			root := dir
+			if tt.file != "" {
+				root = filepath.Join(dir, tt.file)
+			}
+			w := New(root)
+			w.Debounce = 50 * time.Millisecond
+			w.Interval = 20 * time.Millisecond
+			w.SkipDir = func(rel string) bool {
+				return strings.HasPrefix(rel, ".")
+			}
+			w.polling = tt.polling
+
+			got := collect(t, w, dir, tt.want, func() {
+				write(t, filepath.Join(dir, ".hidden", "skipped.bicep"), "param skipped string")
+				write(t, filepath.Join(dir, "main.bicep"), "param location string")
+				write(t, filepath.Join(dir, "modules", "new", "storage.bicep"), "param name string")
+				if err := os.Remove(filepath.Join(dir, "modules", "old.bicep")); err != nil {
+					t.Fatal(err)
+				}
+			})
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Run() reported %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+/*
+Package bruh is the Go library of bruh, for embedding its scans and updates in other tools instead of running the binary and parsing its output.
+
+Scan parses a Bicep file, an ARM template, a Terraform file or a directory containing them, and fetches the available API versions of
+their resources (and the tags of their registry modules). Plan scans a path and lists the changes that would bring each outdated resource
+to its latest version, honouring the pins of the project configuration (.bruh.json), and Apply writes the changes of a plan that were kept.
+The behaviour of each call is tuned with options, such as WithIncludePreview, WithExclude or WithProgress.
+
+Every call is independent: it fetches the available versions with its own cache, so that calls with different options can run concurrently.
+The context of a call cancels its pending requests.
+
+The package follows semantic versioning along with the bruh module: the exported identifiers of this package are not removed or changed
+in incompatible ways within a major version, while new options, fields and functions may be added in minor versions.
+Everything under internal/ is not part of the API.
+*/
+package bruh
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/christosgalano/bruh/internal/apiversions"
+	"github.com/christosgalano/bruh/internal/bicep"
+	"github.com/christosgalano/bruh/internal/scanner"
+	"github.com/christosgalano/bruh/internal/types"
+)
+
+// contextTransport is an HTTP transport sending every request with the context of a call, so that cancelling the call cancels its requests.
+type contextTransport struct {
+	ctx  context.Context
+	base http.RoundTripper
+}
+
+// RoundTrip sends a request with the context of the transport.
+func (t contextTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.base.RoundTrip(req.WithContext(t.ctx))
+}
+
+// newSession returns a scanner session for a call with the given options, whose requests are sent with the context of the call.
+func newSession(ctx context.Context, o *options) *scanner.Session {
+	opts := scanner.Options{IncludePreview: o.includePreview, Filter: o.filter}
+	if o.provider != nil {
+		return scanner.NewWithProvider(opts, o.provider, o.logger)
+	}
+
+	client := http.Client{}
+	if o.client != nil {
+		client = *o.client
+	}
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	client.Transport = contextTransport{ctx: ctx, base: base}
+	return scanner.NewWithProvider(opts, &apiversions.Client{HTTPClient: &client}, o.logger)
+}
+
+// parse parses a file or a directory, walking it with the filter of the options. A file is returned as the only file of its directory.
+func parse(path string, o *options) (*types.BicepDirectory, error) {
+	fs, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if fs.IsDir() {
+		return bicep.ParseDirectory(path, o.filter)
+	}
+	bicepFile, err := bicep.ParseFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return &types.BicepDirectory{Path: filepath.Dir(path), Files: []types.BicepFile{*bicepFile}}, nil
+}
+
+// resolve fetches the available versions of the resources of a directory concurrently, reporting the progress after each resource.
+// If the context is done, the function returns its error.
+func resolve(ctx context.Context, session *scanner.Session, bicepDirectory *types.BicepDirectory, o *options) error {
+	resources := []*types.Resource{}
+	for i := range bicepDirectory.Files {
+		for j := range bicepDirectory.Files[i].Resources {
+			resources = append(resources, &bicepDirectory.Files[i].Resources[j])
+		}
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var firstErr error
+	done := 0
+	for _, resource := range resources {
+		wg.Add(1)
+		go func(resource *types.Resource) {
+			defer wg.Done()
+			if ctx.Err() != nil {
+				return
+			}
+			err := session.Resolve(resource)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				return
+			}
+			done++
+			if o.progress != nil {
+				o.progress(Progress{Resource: resource.ID, Done: done, Total: len(resources)})
+			}
+		}(resource)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return firstErr
+}
+
+// scan parses a path and fetches the available versions of its resources.
+func scan(ctx context.Context, path string, o *options) (*types.BicepDirectory, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	bicepDirectory, err := parse(path, o)
+	if err != nil {
+		return nil, err
+	}
+	if err := resolve(ctx, newSession(ctx, o), bicepDirectory, o); err != nil {
+		return nil, err
+	}
+	return bicepDirectory, nil
+}
+
+// Scan parses a Bicep file, an ARM template, a Terraform file or a directory containing them,
+// and returns the status of their resources along with their available API versions.
+func Scan(ctx context.Context, path string, opts ...Option) (*Result, error) {
+	o := newOptions(opts)
+	bicepDirectory, err := scan(ctx, path, o)
+	if err != nil {
+		return nil, err
+	}
+	return newResult(bicepDirectory), nil
+}
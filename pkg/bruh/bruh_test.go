@@ -0,0 +1,274 @@
+package bruh
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/christosgalano/bruh/internal/types"
+)
+
+// fakeProvider returns fixed versions per resource type, marking the other types as unknown.
+type fakeProvider struct{}
+
+func (fakeProvider) UpdateResource(resource *types.Resource, includePreview bool) error {
+	switch resource.ID {
+	case "Microsoft.Web/sites":
+		resource.AvailableAPIVersions = []string{"2023-01-01", "2022-09-01", "2021-02-01"}
+	case "Microsoft.Web/serverfarms":
+		resource.AvailableAPIVersions = []string{"2022-09-01", "2021-02-01"}
+	case "Microsoft.KeyVault/vaults":
+		resource.AvailableAPIVersions = []string{"2023-07-01"}
+		if includePreview {
+			resource.AvailableAPIVersions = []string{"2024-01-01-preview", "2023-07-01", "2023-02-01-preview"}
+		}
+	default:
+		resource.Unknown = true
+	}
+	return nil
+}
+
+// setup writes the files of a test project to a temporary directory and returns its path.
+func setup(t *testing.T, pins string) string {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		"main.bicep": "resource site 'Microsoft.Web/sites@2021-02-01' = {\n  name: 'app'\n}\n\n" +
+			"resource plan 'Microsoft.Web/serverfarms@2022-09-01' = {\n  name: 'plan'\n}\n",
+		"modules/vault.bicep": "resource vault 'Microsoft.KeyVault/vaults@2023-02-01-preview' = {\n  name: 'kv'\n}\n\n" +
+			"resource fake 'Microsoft.Fake/things@2021-01-01' = {\n  name: 'fake'\n}\n",
+	}
+	if pins != "" {
+		files[".bruh.json"] = pins
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestScan(t *testing.T) {
+	dir := setup(t, "")
+	tests := []struct {
+		name string
+		opts []Option
+		want map[string]string
+	}{
+		{
+			name: "directory",
+			want: map[string]string{
+				"Microsoft.Web/sites":       "outdated 2023-01-01",
+				"Microsoft.Web/serverfarms": "latest 2022-09-01",
+				"Microsoft.KeyVault/vaults": "promotable 2023-07-01",
+				"Microsoft.Fake/things":     "unknown ",
+			},
+		},
+		{
+			name: "include-preview",
+			opts: []Option{WithIncludePreview(true)},
+			want: map[string]string{
+				"Microsoft.Web/sites":       "outdated 2023-01-01",
+				"Microsoft.Web/serverfarms": "latest 2022-09-01",
+				"Microsoft.KeyVault/vaults": "promotable 2024-01-01-preview",
+				"Microsoft.Fake/things":     "unknown ",
+			},
+		},
+		{
+			name: "excluded-directory",
+			opts: []Option{WithExclude("modules")},
+			want: map[string]string{
+				"Microsoft.Web/sites":       "outdated 2023-01-01",
+				"Microsoft.Web/serverfarms": "latest 2022-09-01",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			progress := []Progress{}
+			opts := append([]Option{withProvider(fakeProvider{}), WithProgress(func(p Progress) { progress = append(progress, p) })}, tt.opts...)
+			result, err := Scan(context.Background(), dir, opts...)
+			if err != nil {
+				t.Fatalf("Scan() error = %v", err)
+			}
+
+			got := map[string]string{}
+			for _, file := range result.Files {
+				for _, resource := range file.Resources {
+					got[resource.Type] = string(resource.Status) + " " + resource.LatestVersion
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Scan() statuses = %v, want %v", got, tt.want)
+			}
+			if len(progress) != len(tt.want) || progress[len(progress)-1].Done != len(tt.want) || progress[0].Total != len(tt.want) {
+				t.Errorf("Scan() progress = %+v, want %d resources", progress, len(tt.want))
+			}
+		})
+	}
+}
+
+func TestScanCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := Scan(ctx, setup(t, ""), withProvider(fakeProvider{})); !errors.Is(err, context.Canceled) {
+		t.Errorf("Scan() error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestResult_Outdated(t *testing.T) {
+	result, err := Scan(context.Background(), setup(t, ""), withProvider(fakeProvider{}))
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	got := []string{}
+	for _, file := range result.Outdated() {
+		for _, resource := range file.Resources {
+			got = append(got, resource.Type+"@"+resource.CurrentVersion+"->"+resource.LatestVersion)
+		}
+	}
+	want := []string{"Microsoft.Web/sites@2021-02-01->2023-01-01", "Microsoft.KeyVault/vaults@2023-02-01-preview->2023-07-01"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Outdated() = %v, want %v", got, want)
+	}
+}
+
+func TestPlanApply(t *testing.T) {
+	tests := []struct {
+		name    string
+		pins    string
+		keep    func(change Change) bool
+		to      map[string]string
+		want    []string
+		wantOut map[string][]string
+	}{
+		{
+			name: "all-changes",
+			keep: func(Change) bool { return true },
+			want: []string{"Microsoft.Web/sites 2021-02-01 -> 2023-01-01", "Microsoft.KeyVault/vaults 2023-02-01-preview -> 2023-07-01"},
+			wantOut: map[string][]string{
+				"main.bicep":          {"Microsoft.Web/sites@2023-01-01", "Microsoft.Web/serverfarms@2022-09-01"},
+				"modules/vault.bicep": {"Microsoft.KeyVault/vaults@2023-07-01"},
+			},
+		},
+		{
+			name: "pinned",
+			pins: `{"pins": [{"resource": "Microsoft.Web/sites", "version": "2022-09-01"}]}`,
+			keep: func(Change) bool { return true },
+			want: []string{"Microsoft.Web/sites 2021-02-01 -> 2022-09-01", "Microsoft.KeyVault/vaults 2023-02-01-preview -> 2023-07-01"},
+			wantOut: map[string][]string{
+				"main.bicep":          {"Microsoft.Web/sites@2022-09-01"},
+				"modules/vault.bicep": {"Microsoft.KeyVault/vaults@2023-07-01"},
+			},
+		},
+		{
+			name: "removed-and-retargeted-changes",
+			keep: func(change Change) bool { return change.Type == "Microsoft.Web/sites" },
+			to:   map[string]string{"Microsoft.Web/sites": "2022-09-01"},
+			want: []string{"Microsoft.Web/sites 2021-02-01 -> 2023-01-01", "Microsoft.KeyVault/vaults 2023-02-01-preview -> 2023-07-01"},
+			wantOut: map[string][]string{
+				"main.bicep":          {"Microsoft.Web/sites@2022-09-01"},
+				"modules/vault.bicep": {"Microsoft.KeyVault/vaults@2023-02-01-preview"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := setup(t, tt.pins)
+			plan, err := Plan(context.Background(), dir, withProvider(fakeProvider{}))
+			if err != nil {
+				t.Fatalf("Plan() error = %v", err)
+			}
+
+			got := []string{}
+			for _, change := range plan.Changes {
+				got = append(got, change.Type+" "+change.From+" -> "+change.To)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Plan() changes = %v, want %v", got, tt.want)
+			}
+
+			changes := []Change{}
+			for _, change := range plan.Changes {
+				if tt.keep(change) {
+					if to, ok := tt.to[change.Type]; ok {
+						change.To = to
+					}
+					changes = append(changes, change)
+				}
+			}
+			plan.Changes = changes
+			if _, err := Apply(context.Background(), plan, WithInPlace(true)); err != nil {
+				t.Fatalf("Apply() error = %v", err)
+			}
+			for name, wants := range tt.wantOut {
+				data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, want := range wants {
+					if !strings.Contains(string(data), want) {
+						t.Errorf("Apply() %s = %q, want it to contain %q", name, data, want)
+					}
+				}
+			}
+
+			if _, err := Apply(context.Background(), plan); !errors.Is(err, ErrApplied) {
+				t.Errorf("Apply() error = %v, want %v", err, ErrApplied)
+			}
+		})
+	}
+}
+
+func TestApplyRepeatedDeclarations(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.bicep")
+	content := "resource app 'Microsoft.Web/sites@2021-02-01' = {\n  name: 'app'\n}\n\n" +
+		"resource legacy 'Microsoft.Web/sites@2021-02-01' = {\n  name: 'legacy'\n}\n\n" +
+		"resource other 'Microsoft.Web/sites@2021-02-01' = {\n  name: 'other'\n}\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	plan, err := Plan(context.Background(), dir, withProvider(fakeProvider{}))
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+	if len(plan.Changes) != 3 {
+		t.Fatalf("Plan() changes = %v, want 3", len(plan.Changes))
+	}
+
+	// An unknown target version fails before anything is written, and the plan can still be applied
+	retargeted := plan.Changes[2]
+	plan.Changes[2].To = "2099-01-01"
+	if _, err := Apply(context.Background(), plan, WithInPlace(true)); !errors.Is(err, ErrUnknownVersion) {
+		t.Fatalf("Apply() error = %v, want %v", err, ErrUnknownVersion)
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != content {
+		t.Fatalf("Apply() wrote %q, %v, want the file untouched", data, err)
+	}
+
+	retargeted.To = "2022-09-01"
+	plan.Changes = []Change{plan.Changes[0], retargeted}
+	if _, err := Apply(context.Background(), plan, WithInPlace(true)); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := strings.NewReplacer("sites@2021-02-01' = {\n  name: 'app'", "sites@2023-01-01' = {\n  name: 'app'",
+		"sites@2021-02-01' = {\n  name: 'other'", "sites@2022-09-01' = {\n  name: 'other'").Replace(content)
+	if string(data) != want {
+		t.Errorf("Apply() content =\n%s\nwant\n%s", data, want)
+	}
+}
@@ -0,0 +1,58 @@
+package bruh_test
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/christosgalano/bruh/pkg/bruh"
+)
+
+// The available API versions are fetched from the Microsoft Learn website, so the examples are compiled but not run.
+
+func ExampleScan() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	result, err := bruh.Scan(ctx, "./infra",
+		bruh.WithExclude("samples/**"),
+		bruh.WithProgress(func(p bruh.Progress) {
+			fmt.Fprintf(os.Stderr, "\rfetched %d/%d", p.Done, p.Total)
+		}),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, file := range result.Outdated() {
+		for _, resource := range file.Resources {
+			fmt.Printf("%s:%d: %s %s -> %s\n", file.Path, resource.Line, resource.Type, resource.CurrentVersion, resource.LatestVersion)
+		}
+	}
+}
+
+func ExamplePlan() {
+	ctx := context.Background()
+
+	plan, err := bruh.Plan(ctx, "./infra", bruh.WithIncludePreview(false))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Keep only the GA versions of preview resources
+	changes := []bruh.Change{}
+	for _, change := range plan.Changes {
+		if change.Status == bruh.StatusPromotable {
+			changes = append(changes, change)
+		}
+	}
+	plan.Changes = changes
+
+	written, err := bruh.Apply(ctx, plan, bruh.WithInPlace(true))
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(written)
+}
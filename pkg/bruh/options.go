@@ -0,0 +1,115 @@
+package bruh
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/christosgalano/bruh/internal/bicep"
+	"github.com/christosgalano/bruh/internal/scanner"
+)
+
+// Progress reports the progress of fetching the available versions of the resources of a call.
+type Progress struct {
+	// Resource is the type (or module reference) of the resource whose versions were fetched.
+	Resource string
+
+	// Done is the number of resources whose versions were fetched so far, out of Total.
+	Done  int
+	Total int
+}
+
+// Option configures a call of Scan, Plan or Apply.
+type Option func(*options)
+
+// options are the settings of a call.
+type options struct {
+	includePreview bool
+	filter         bicep.Filter
+	inPlace        bool
+	client         *http.Client
+	logger         *log.Logger
+	progress       func(Progress)
+	provider       scanner.Provider
+}
+
+// newOptions returns the settings of a call with the given options applied.
+func newOptions(opts []Option) *options {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithIncludePreview sets whether preview API versions (and pre-release module tags) are considered for the latest version.
+func WithIncludePreview(includePreview bool) Option {
+	return func(o *options) {
+		o.includePreview = includePreview
+	}
+}
+
+// WithInclude sets the glob patterns of the files scanned in a directory (e.g. *.bicep or modules/**), all the supported files by default.
+// Patterns are matched against paths relative to the directory, with forward slashes, and ** matches any number of directories.
+func WithInclude(patterns ...string) Option {
+	return func(o *options) {
+		o.filter.Include = append(o.filter.Include, patterns...)
+	}
+}
+
+// WithExclude sets the glob patterns of the files and directories skipped in a directory (e.g. node_modules or samples/**/*.json).
+func WithExclude(patterns ...string) Option {
+	return func(o *options) {
+		o.filter.Exclude = append(o.filter.Exclude, patterns...)
+	}
+}
+
+// WithIgnoreFiles sets whether the files and directories matched by .gitignore and .bruhignore files are skipped.
+func WithIgnoreFiles(ignoreFiles bool) Option {
+	return func(o *options) {
+		o.filter.IgnoreFiles = ignoreFiles
+	}
+}
+
+// WithHidden sets whether hidden directories (e.g. .git or .terraform) are scanned, which are skipped by default.
+func WithHidden(hidden bool) Option {
+	return func(o *options) {
+		o.filter.Hidden = hidden
+	}
+}
+
+// WithInPlace sets whether Apply updates the files in place, instead of writing the updated content to new files with the "_updated" suffix
+// (or the ".updated" suffix for Terraform files, e.g. main.tf.updated).
+func WithInPlace(inPlace bool) Option {
+	return func(o *options) {
+		o.inPlace = inPlace
+	}
+}
+
+// WithHTTPClient sets the HTTP client used to fetch the available versions, http.DefaultClient by default.
+func WithHTTPClient(client *http.Client) Option {
+	return func(o *options) {
+		o.client = client
+	}
+}
+
+// WithLogger sets the logger of the requests made to fetch the available versions, which are not logged by default.
+func WithLogger(logger *log.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// WithProgress sets a function called each time the available versions of a resource are fetched.
+// Calls are serialized, so the function does not need to be safe for concurrent use.
+func WithProgress(progress func(Progress)) Option {
+	return func(o *options) {
+		o.progress = progress
+	}
+}
+
+// withProvider sets the provider of the available versions, instead of the Microsoft Learn website and module registries (used by tests).
+func withProvider(provider scanner.Provider) Option {
+	return func(o *options) {
+		o.provider = provider
+	}
+}
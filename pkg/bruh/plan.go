@@ -0,0 +1,165 @@
+package bruh
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/christosgalano/bruh/internal/bicep"
+	"github.com/christosgalano/bruh/internal/config"
+	"github.com/christosgalano/bruh/internal/types"
+)
+
+var (
+	// ErrApplied is returned when a plan that was already applied is applied again.
+	ErrApplied = errors.New("plan already applied")
+
+	// ErrUnknownVersion is returned when the target version of a change is not an available version of its resource.
+	ErrUnknownVersion = errors.New("unknown target version")
+)
+
+// Change is a change of a plan, updating the version of a resource.
+type Change struct {
+	// File is the path of the file, and Line the line of the version in it, starting from 1.
+	File string
+	Line int
+
+	// Type is the resource type, or the reference of a registry module.
+	Type string
+
+	// Function is the function the API version is passed to (e.g. listKeys), if any.
+	Function string
+
+	// From is the current version, and To the version it is updated to: the latest one, unless it is capped by a pin.
+	// To may be set to another available version before applying the plan.
+	From string
+	To   string
+
+	// Status is the status of the current version: outdated or promotable.
+	Status Status
+}
+
+// UpdatePlan is the list of changes bringing the outdated resources of a path to their latest versions.
+// Changes can be removed (or their target version changed) before applying the plan, to apply only some of them.
+type UpdatePlan struct {
+	Path    string
+	Changes []Change
+
+	directory *types.BicepDirectory
+	applied   bool
+}
+
+// Plan scans a path and returns the changes that would bring each outdated resource to its latest version.
+// Resources pinned in the closest project configuration (.bruh.json) are never updated past their pinned version.
+func Plan(ctx context.Context, path string, opts ...Option) (*UpdatePlan, error) {
+	o := newOptions(opts)
+	bicepDirectory, err := scan(ctx, path, o)
+	if err != nil {
+		return nil, err
+	}
+
+	projectConfig, err := config.Find(bicepDirectory.Path)
+	if err != nil {
+		return nil, err
+	}
+	plan := &UpdatePlan{Path: bicepDirectory.Path, Changes: []Change{}, directory: bicepDirectory}
+	for i := range bicepDirectory.Files {
+		projectConfig.Apply(&bicepDirectory.Files[i])
+		for _, resource := range bicepDirectory.Files[i].Resources {
+			status := resource.Status()
+			if resource.Skipped || (status != types.StatusOutdated && status != types.StatusPromotable) {
+				continue
+			}
+			plan.Changes = append(plan.Changes, Change{
+				File:     bicepDirectory.Files[i].Path,
+				Line:     resource.Line,
+				Type:     resource.ID,
+				Function: resource.Function,
+				From:     resource.CurrentAPIVersion,
+				To:       resource.LatestAPIVersion(),
+				Status:   Status(status.String()),
+			})
+		}
+	}
+	return plan, nil
+}
+
+// changeKey identifies the resource of a change in its file.
+type changeKey struct {
+	file         string
+	line         int
+	resourceType string
+	function     string
+}
+
+// available returns true if a version is one of the available versions of a resource.
+func available(resource types.Resource, version string) bool {
+	for _, v := range resource.AvailableAPIVersions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply writes the changes of a plan, updating the files in place with WithInPlace(true), or writing new files with the "_updated" suffix otherwise.
+// It returns the paths of the written files. A plan can only be applied once.
+// If the target version of a change is not available, it returns ErrUnknownVersion before writing any file.
+func Apply(ctx context.Context, plan *UpdatePlan, opts ...Option) ([]string, error) {
+	if plan.applied {
+		return nil, ErrApplied
+	}
+	o := newOptions(opts)
+
+	changes := map[changeKey]Change{}
+	for _, change := range plan.Changes {
+		changes[changeKey{file: change.File, line: change.Line, resourceType: change.Type, function: change.Function}] = change
+	}
+
+	// Match the changes with the resources first, so that nothing is written if a target version is unknown
+	kept := make([]map[int]Change, len(plan.directory.Files))
+	for i, bicepFile := range plan.directory.Files {
+		kept[i] = map[int]Change{}
+		for j, resource := range bicepFile.Resources {
+			change, ok := changes[changeKey{file: bicepFile.Path, line: resource.Line, resourceType: resource.ID, function: resource.Function}]
+			if !ok || change.From != resource.CurrentAPIVersion {
+				continue
+			}
+			if !available(resource, change.To) {
+				return nil, fmt.Errorf("%w %q for %s in %s:%d", ErrUnknownVersion, change.To, change.Type, change.File, change.Line)
+			}
+			kept[i][j] = change
+		}
+	}
+
+	plan.applied = true
+	written := []string{}
+	for i := range plan.directory.Files {
+		if err := ctx.Err(); err != nil {
+			return written, err
+		}
+		if len(kept[i]) == 0 {
+			continue
+		}
+
+		// Skip the resources whose change was removed from the plan, and cap the ones whose target version was changed
+		bicepFile := &plan.directory.Files[i]
+		for j := range bicepFile.Resources {
+			resource := &bicepFile.Resources[j]
+			change, ok := kept[i][j]
+			if !ok {
+				resource.Skipped = true
+				continue
+			}
+			if change.To != resource.LatestAPIVersion() {
+				resource.Cap(change.To)
+			}
+		}
+
+		if err := bicep.UpdateFile(bicepFile, o.inPlace); err != nil {
+			return written, err
+		}
+		written = append(written, bicepFile.Path)
+	}
+	return written, nil
+}
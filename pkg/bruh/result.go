@@ -0,0 +1,114 @@
+package bruh
+
+import (
+	"github.com/christosgalano/bruh/internal/types"
+)
+
+// Status is the status of the API version (or module tag) of a resource.
+type Status string
+
+const (
+	// StatusLatest is the status of a resource using the latest version.
+	StatusLatest Status = "latest"
+
+	// StatusOutdated is the status of a resource using an older version.
+	StatusOutdated Status = "outdated"
+
+	// StatusPromotable is the status of a resource using a preview API version while a GA version of the same date or newer is available.
+	StatusPromotable Status = "promotable"
+
+	// StatusUnknown is the status of a resource whose type (or module) does not exist.
+	StatusUnknown Status = "unknown"
+
+	// StatusUnresolved is the status of a resource whose API version is not statically resolvable (e.g. an ARM template expression).
+	StatusUnresolved Status = "unresolved"
+)
+
+// Resource is a resource declared in a file, or an API version passed to a function, or a registry module reference.
+type Resource struct {
+	// Type is the resource type (e.g. Microsoft.Web/sites), or the reference of a registry module (e.g. br/public:avm/res/web/site).
+	Type string
+
+	// Line is the line of the API version in the file, starting from 1.
+	Line int
+
+	// Function is the function the API version is passed to (e.g. listKeys), if any.
+	Function string
+
+	// Module is true for registry module references, whose versions are tags.
+	Module bool
+
+	// CurrentVersion is the version used by the resource, and LatestVersion the latest available one.
+	CurrentVersion string
+	LatestVersion  string
+
+	// AvailableVersions are the available versions, newest first.
+	AvailableVersions []string
+
+	// Status is the status of the current version.
+	Status Status
+
+	// Suggestions are the closest existing resource types of an unknown one.
+	Suggestions []string
+
+	// Pinned is true if the available versions are capped by a pin of the project configuration (set by Plan).
+	Pinned bool
+}
+
+// File is a scanned file along with its resources, in order of appearance.
+type File struct {
+	Path      string
+	Resources []Resource
+}
+
+// Result is the result of a scan: the scanned path (the directory of a scanned file) and its files.
+type Result struct {
+	Path  string
+	Files []File
+}
+
+// Outdated returns the resources of the result whose version is outdated or promotable, grouped by file.
+func (r *Result) Outdated() []File {
+	files := []File{}
+	for _, file := range r.Files {
+		outdated := File{Path: file.Path}
+		for _, resource := range file.Resources {
+			if resource.Status == StatusOutdated || resource.Status == StatusPromotable {
+				outdated.Resources = append(outdated.Resources, resource)
+			}
+		}
+		if len(outdated.Resources) > 0 {
+			files = append(files, outdated)
+		}
+	}
+	return files
+}
+
+// newResource returns the public representation of a resource.
+func newResource(resource types.Resource) Resource {
+	return Resource{
+		Type:              resource.ID,
+		Line:              resource.Line,
+		Function:          resource.Function,
+		Module:            resource.Module,
+		CurrentVersion:    resource.CurrentAPIVersion,
+		LatestVersion:     resource.LatestAPIVersion(),
+		AvailableVersions: resource.AvailableAPIVersions,
+		Status:            Status(resource.Status().String()),
+		Suggestions:       resource.Suggestions,
+		Pinned:            resource.Pinned,
+	}
+}
+
+// newResult returns the public representation of a scanned directory.
+func newResult(bicepDirectory *types.BicepDirectory) *Result {
+	result := &Result{Path: bicepDirectory.Path, Files: make([]File, 0, len(bicepDirectory.Files))}
+	for _, bicepFile := range bicepDirectory.Files {
+		file := File{Path: bicepFile.Path, Resources: make([]Resource, 0, len(bicepFile.Resources))}
+		for _, resource := range bicepFile.Resources {
+			file.Resources = append(file.Resources, newResource(resource))
+		}
+		result.Files = append(result.Files, file)
+	}
+	return result
+}